@@ -12,6 +12,12 @@ type MimcHash struct {
 	Hash     frontend.Variable `gnark:",public"`
 }
 
+// HashAssign 单个域元素哈希电路的赋值参数
+type HashAssign struct {
+	PreImage []byte // 原像，需小于标量域模数
+	Hash     []byte // 原像的哈希值
+}
+
 func (m *MimcHash) Define(api frontend.API) error {
 	mimc, err := mimc.NewMiMC(api)
 	if err != nil {
@@ -24,15 +30,14 @@ func (m *MimcHash) Define(api frontend.API) error {
 	return nil
 }
 
-func (m *MimcHash) PreCompile(params any) {
+func (m *MimcHash) PreCompile(params NoParams) error {
 	// 留空
+	return nil
 }
 
-func (m *MimcHash) Assign(params any) {
-	args := params.([]interface{})
-	preImage := args[0].([][]byte)
-	hash := args[1].([]byte)
-	m.PreImage = preImage[0]
-	m.Hash = hash
-	logger.Info("Assigning MimcHash circuit with preImage %v and hash %v", preImage, hash)
+func (m *MimcHash) Assign(params HashAssign) error {
+	m.PreImage = params.PreImage
+	m.Hash = params.Hash
+	logger.Info("Assigning MimcHash circuit with preImage %v and hash %v", params.PreImage, params.Hash)
+	return nil
 }
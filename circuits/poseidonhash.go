@@ -22,13 +22,12 @@ func (c *Poseidon2Hash) Define(api frontend.API) error {
 	return nil
 }
 
-func (c *Poseidon2Hash) PreCompile(params any) {
+func (c *Poseidon2Hash) PreCompile(params NoParams) error {
+	return nil
 }
 
-func (c *Poseidon2Hash) Assign(params any) {
-	args := params.([]interface{})
-	preImage := args[0].([][]byte)
-	hash := args[1].([]byte)
-	c.PreImage = preImage[0]
-	c.Hash = hash
+func (c *Poseidon2Hash) Assign(params HashAssign) error {
+	c.PreImage = params.PreImage
+	c.Hash = params.Hash
+	return nil
 }
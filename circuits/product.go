@@ -2,6 +2,9 @@ package circuits
 
 import "github.com/consensys/gnark/frontend"
 
+// NoParams 用于不需要编译参数或赋值参数的电路
+type NoParams struct{}
+
 // Product 是一个简单且运行高效的电路，用于内部测试
 type Product struct {
 	P frontend.Variable
@@ -9,27 +12,27 @@ type Product struct {
 	N frontend.Variable `gnark:",public"`
 }
 
+// ProductAssign Product电路的赋值参数，N = P * Q 由Assign计算
+type ProductAssign struct {
+	P int
+	Q int
+}
+
 // Define 实现了电路的约束逻辑
 func (tc *Product) Define(api frontend.API) error {
 	api.AssertIsEqual(tc.N, api.Mul(tc.P, tc.Q))
 	return nil
 }
 
-func (tc *Product) PreCompile(params any) {
+func (tc *Product) PreCompile(params NoParams) error {
 	// 预编译逻辑为空
 	// 对于Product，PreCompile不需要任何参数
+	return nil
 }
 
-func (tc *Product) Assign(params any) {
-	// 将params转换为切片以处理多个参数
-	args := params.([]interface{})
-	if len(args) != 2 {
-		panic("Assign params must be []interface{} with length 2")
-	}
-
-	p := args[0].(int)
-	q := args[1].(int)
-	tc.P = p
-	tc.Q = q
-	tc.N = p * q
+func (tc *Product) Assign(params ProductAssign) error {
+	tc.P = params.P
+	tc.Q = params.Q
+	tc.N = params.P * params.Q
+	return nil
 }
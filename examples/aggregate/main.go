@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"strconv"
 
 	"github.com/oliverustc/gnarkabc/circuits"
@@ -46,39 +47,67 @@ func (c *DummyAggregate) Define(api frontend.API) error {
 func GenerateGroth16InnerProofs() {
 	curve := utils.CurveMap["BN254"]
 	var circuit circuits.Product
-	circuit.PreCompile(nil)
+	circuit.PreCompile(circuits.NoParams{})
 	g := groth16wrapper.NewWrapper(&circuit, curve)
-	g.Compile()
-	g.Setup()
-	g.WriteCCS("output/groth16_ccs")
-	g.WritePK("output/groth16_pk")
-	g.WriteVK("output/groth16_vk")
+	if err := g.Compile(); err != nil {
+		logger.Fatal("%v", err)
+	}
+	if err := g.Setup(); err != nil {
+		logger.Fatal("%v", err)
+	}
+	if err := g.WriteCCS("groth16_ccs"); err != nil {
+		logger.Fatal("%v", err)
+	}
+	if err := g.WritePK("groth16_pk"); err != nil {
+		logger.Fatal("%v", err)
+	}
+	if err := g.WriteVK("groth16_vk"); err != nil {
+		logger.Fatal("%v", err)
+	}
 	for i := 0; i < 5; i++ {
 		p := utils.RandInt(0, 100)
 		q := utils.RandInt(0, 100)
-		circuit.Assign([]any{p, q})
+		circuit.Assign(circuits.ProductAssign{P: p, Q: q})
 		g.SetAssignment(&circuit)
-		g.Prove()
-		g.Verify()
-		g.WriteProof("output/groth16_proof_" + strconv.Itoa(i))
-		g.WriteWitness("output/groth16_witness_"+strconv.Itoa(i), false)
+		if err := g.Prove(); err != nil {
+			logger.Fatal("%v", err)
+		}
+		if err := g.Verify(); err != nil {
+			logger.Fatal("%v", err)
+		}
+		if err := g.WriteProof("groth16_proof_" + strconv.Itoa(i)); err != nil {
+			logger.Fatal("%v", err)
+		}
+		if err := g.WriteWitness("groth16_witness_"+strconv.Itoa(i), false); err != nil {
+			logger.Fatal("%v", err)
+		}
 	}
 }
 
-func (c *DummyAggregate) PreCompile(params any) {
+func (c *DummyAggregate) PreCompile(params circuits.NoParams) error {
 	curve := utils.CurveMap["BN254"]
 	var circuit circuits.Product
-	circuit.PreCompile(nil)
+	circuit.PreCompile(circuits.NoParams{})
 	g := groth16wrapper.NewWrapper(&circuit, curve)
-	g.ReadCCS("output/groth16_ccs")
-	g.ReadPK("output/groth16_pk")
-	g.ReadVK("output/groth16_vk")
-	g.ReadProof("output/groth16_proof_0")
-	g.ReadWitness("output/groth16_witness_0", false)
+	if err := g.ReadCCS("groth16_ccs"); err != nil {
+		return err
+	}
+	if err := g.ReadPK("groth16_pk"); err != nil {
+		return err
+	}
+	if err := g.ReadVK("groth16_vk"); err != nil {
+		return err
+	}
+	if err := g.ReadProof("groth16_proof_0"); err != nil {
+		return err
+	}
+	if err := g.ReadWitness("groth16_witness_0", false); err != nil {
+		return err
+	}
 
 	circuitVK, err := stdgroth16.ValueOfVerifyingKey[sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](g.VK)
 	if err != nil {
-		logger.Info("failed to during ValueofVerifyingKey, %v", err)
+		return fmt.Errorf("failed to during ValueofVerifyingKey: %w", err)
 	}
 	c.verifyingKey = circuitVK
 	witnessPlaceholder := stdgroth16.PlaceholderWitness[sw_bn254.ScalarField](g.CCS)
@@ -87,32 +116,44 @@ func (c *DummyAggregate) PreCompile(params any) {
 		c.Proofs[i] = proofPlaceholder
 		c.PublicInputs[i] = witnessPlaceholder
 	}
+	return nil
 }
 
-func (c *DummyAggregate) Assign(params any) {
+func (c *DummyAggregate) Assign(params circuits.NoParams) error {
 	curve := utils.CurveMap["BN254"]
 	var circuit circuits.Product
-	circuit.PreCompile(nil)
+	circuit.PreCompile(circuits.NoParams{})
 	g := groth16wrapper.NewWrapper(&circuit, curve)
-	g.ReadCCS("output/groth16_ccs")
-	g.ReadPK("output/groth16_pk")
-	g.ReadVK("output/groth16_vk")
+	if err := g.ReadCCS("groth16_ccs"); err != nil {
+		return err
+	}
+	if err := g.ReadPK("groth16_pk"); err != nil {
+		return err
+	}
+	if err := g.ReadVK("groth16_vk"); err != nil {
+		return err
+	}
 
 	for i := 0; i < 5; i++ {
-		g.ReadProof("output/groth16_proof_" + strconv.Itoa(i))
-		g.ReadWitness("output/groth16_witness_"+strconv.Itoa(i), false)
+		if err := g.ReadProof("groth16_proof_" + strconv.Itoa(i)); err != nil {
+			return err
+		}
+		if err := g.ReadWitness("groth16_witness_"+strconv.Itoa(i), false); err != nil {
+			return err
+		}
 
 		circuitWitness, err := stdgroth16.ValueOfWitness[sw_bn254.ScalarField](g.WitnessFull)
 		if err != nil {
-			logger.Info("failed to during ValueofWitness, %v", err)
+			return fmt.Errorf("failed to during ValueofWitness: %w", err)
 		}
 		circuitProof, err := stdgroth16.ValueOfProof[sw_bn254.G1Affine, sw_bn254.G2Affine](g.Proof)
 		if err != nil {
-			logger.Info("failed to during ValueofProof, %v", err)
+			return fmt.Errorf("failed to during ValueofProof: %w", err)
 		}
 		c.PublicInputs[i] = circuitWitness
 		c.Proofs[i] = circuitProof
 	}
+	return nil
 }
 
 func main() {
@@ -122,30 +163,56 @@ func main() {
 	var g *groth16wrapper.Groth16Wrapper
 	if !utils.CheckFileExists("output/aggregate_ccs") {
 		var circuit DummyAggregate
-		circuit.PreCompile(nil)
+		if err := circuit.PreCompile(circuits.NoParams{}); err != nil {
+			logger.Fatal("%v", err)
+		}
 		g = groth16wrapper.NewWrapper(&circuit, utils.CurveMap["BN254"])
-		g.Compile()
-		g.WriteCCS("output/aggregate_ccs")
+		if err := g.Compile(); err != nil {
+			logger.Fatal("%v", err)
+		}
+		if err := g.WriteCCS("aggregate_ccs"); err != nil {
+			logger.Fatal("%v", err)
+		}
 	} else {
 		var circuit DummyAggregate
-		circuit.PreCompile(nil)
+		if err := circuit.PreCompile(circuits.NoParams{}); err != nil {
+			logger.Fatal("%v", err)
+		}
 		g = groth16wrapper.NewWrapper(&circuit, utils.CurveMap["BN254"])
-		g.ReadCCS("output/aggregate_ccs")
+		if err := g.ReadCCS("aggregate_ccs"); err != nil {
+			logger.Fatal("%v", err)
+		}
 	}
 
 	if !utils.CheckFileExists("output/aggregate_pk") {
-		g.Setup()
-		g.WritePK("output/aggregate_pk")
-		g.WriteVK("output/aggregate_vk")
+		if err := g.Setup(); err != nil {
+			logger.Fatal("%v", err)
+		}
+		if err := g.WritePK("aggregate_pk"); err != nil {
+			logger.Fatal("%v", err)
+		}
+		if err := g.WriteVK("aggregate_vk"); err != nil {
+			logger.Fatal("%v", err)
+		}
 	} else {
-		g.ReadPK("output/aggregate_pk")
-		g.ReadVK("output/aggregate_vk")
+		if err := g.ReadPK("aggregate_pk"); err != nil {
+			logger.Fatal("%v", err)
+		}
+		if err := g.ReadVK("aggregate_vk"); err != nil {
+			logger.Fatal("%v", err)
+		}
 	}
 
 	var circuit DummyAggregate
-	circuit.PreCompile(nil)
+	if err := circuit.Assign(circuits.NoParams{}); err != nil {
+		logger.Fatal("%v", err)
+	}
 	g.SetAssignment(&circuit)
-	g.Prove()
-	g.Verify()
+	if err := g.Prove(); err != nil {
+		logger.Fatal("%v", err)
+	}
+	if err := g.Verify(); err != nil {
+		logger.Fatal("%v", err)
+	}
 
 }
@@ -3,9 +3,11 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"os"
 	"time"
 
+	"github.com/oliverustc/gnarkabc/hash/fieldpack"
 	"github.com/oliverustc/gnarkabc/hash/mimchash"
 	"github.com/oliverustc/gnarkabc/logger"
 	"github.com/oliverustc/gnarkabc/wrapper"
@@ -17,9 +19,14 @@ import (
 type MiMCHash struct {
 	PreImage []frontend.Variable
 	Hash     frontend.Variable `gnark:",public"`
+	inputLen int
 }
 
 func (c *MiMCHash) Define(api frontend.API) error {
+	// 约束原像是 inputLen 个字节的规范编码
+	if _, err := fieldpack.Unpack(api, c.PreImage, c.inputLen); err != nil {
+		return err
+	}
 	mimc, err := mimc.NewMiMC(api)
 	if err != nil {
 		return err
@@ -33,27 +40,32 @@ func (c *MiMCHash) Define(api frontend.API) error {
 	return nil
 }
 
-func (c *MiMCHash) PreCompile(params any) {
-	args := params.([]interface{})
-	inputLen := args[0].(int)
-	var preImageLen int
-	if inputLen%32 == 0 {
-		preImageLen = inputLen / 32
-	} else {
-		preImageLen = inputLen/32 + 1
-	}
-	c.PreImage = make([]frontend.Variable, preImageLen)
+// MiMCHashParams MiMCHash电路的编译参数
+type MiMCHashParams struct {
+	InputLen int      // 输入字符串的字节数
+	Field    *big.Int // 电路所在曲线的标量域
+}
+
+// MiMCHashAssign MiMCHash电路的赋值参数
+type MiMCHashAssign struct {
+	PreImage [][]byte // 输入字符串经 fieldpack.Pack 编码后的元素
+	Hash     []byte
 }
 
-func (c *MiMCHash) Assign(params any) {
-	args := params.([]interface{})
-	preImage := args[0].([][]byte)
-	hash := args[1].([]byte)
-	c.PreImage = make([]frontend.Variable, len(preImage))
-	for i := range preImage {
-		c.PreImage[i] = preImage[i]
+// PreCompile 根据输入字符串长度确定编码后的元素数量
+func (c *MiMCHash) PreCompile(params MiMCHashParams) error {
+	c.inputLen = params.InputLen
+	c.PreImage = make([]frontend.Variable, fieldpack.NbElements(params.InputLen, params.Field))
+	return nil
+}
+
+func (c *MiMCHash) Assign(params MiMCHashAssign) error {
+	c.PreImage = make([]frontend.Variable, len(params.PreImage))
+	for i := range params.PreImage {
+		c.PreImage[i] = params.PreImage[i]
 	}
-	c.Hash = hash
+	c.Hash = params.Hash
+	return nil
 }
 
 type Performance struct {
@@ -66,41 +78,34 @@ type Performance struct {
 	ConstraintNum int    `json:"constraint_num"`
 }
 
-func MiMCHashZKP(input string, curveName string, scheme string) Performance {
+func MiMCHashZKP(input string, curveName string, scheme string) (Performance, error) {
 	var mc MiMCHash
-	inputLen := len(input)
 	mod := mimchash.MiMCCaseMap[curveName].Curve.ScalarField()
-	inputBytes := mimchash.ConvertString2Byte(input, mod)
+	inputBytes := fieldpack.Pack([]byte(input), mod)
 	hashFunc := mimchash.MiMCCaseMap[curveName].Hash
 	hash := mimchash.MiMCHash(hashFunc, inputBytes)
-	preCompileParams := []any{inputLen}
-	assignParams := []any{inputBytes, hash}
-	switch scheme {
-	case "groth16":
-		gw := wrapper.Groth16ZKP(&mc, curveName, preCompileParams, assignParams)
-		return Performance{
-			Scheme:        scheme,
-			HashAlg:       "mimc",
-			Curve:         curveName,
-			PreImage:      input,
-			ProveTime:     gw.BenchmarkProve(10).Milliseconds(),
-			VerifyTime:    gw.BenchmarkVerify(10).Milliseconds(),
-			ConstraintNum: gw.ConstraintNum,
-		}
-	case "plonk":
-		pw := wrapper.PlonkZKP(&mc, curveName, preCompileParams, assignParams)
-		return Performance{
-			Scheme:        scheme,
-			HashAlg:       "mimc",
-			Curve:         curveName,
-			PreImage:      input,
-			ProveTime:     pw.BenchmarkProve(10).Milliseconds(),
-			VerifyTime:    pw.BenchmarkVerify(10).Milliseconds(),
-			ConstraintNum: pw.ConstraintNum,
-		}
-	default:
-		panic("unknown scheme")
+	assignParams := MiMCHashAssign{PreImage: inputBytes, Hash: hash}
+	ps, err := wrapper.ZKP(scheme, &mc, curveName, MiMCHashParams{InputLen: len(input), Field: mod}, assignParams)
+	if err != nil {
+		return Performance{}, err
+	}
+	proveTime, err := ps.BenchmarkProve(10)
+	if err != nil {
+		return Performance{}, err
+	}
+	verifyTime, err := ps.BenchmarkVerify(10)
+	if err != nil {
+		return Performance{}, err
 	}
+	return Performance{
+		Scheme:        scheme,
+		HashAlg:       "mimc",
+		Curve:         curveName,
+		PreImage:      input,
+		ProveTime:     proveTime.Milliseconds(),
+		VerifyTime:    verifyTime.Milliseconds(),
+		ConstraintNum: ps.GetConstraintNum(),
+	}, nil
 }
 
 func main() {
@@ -108,8 +113,14 @@ func main() {
 	var p []Performance
 	for curveName := range mimchash.MiMCCaseMap {
 		logger.Info("mimc hash zkp with string input on curve: [%s]", curveName)
-		p = append(p, MiMCHashZKP(input, curveName, "groth16"))
-		p = append(p, MiMCHashZKP(input, curveName, "plonk"))
+		for _, scheme := range wrapper.SchemeList {
+			perf, err := MiMCHashZKP(input, curveName, scheme)
+			if err != nil {
+				logger.Error("mimc hash zkp on curve [%s] scheme [%s] failed: %v", curveName, scheme, err)
+				return
+			}
+			p = append(p, perf)
+		}
 	}
 	// 将p写入json文件
 	jsonData, err := json.Marshal(p)
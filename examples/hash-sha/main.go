@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/oliverustc/gnarkabc/logger"
+	"github.com/oliverustc/gnarkabc/wrapper"
 )
 
 type Performance struct {
@@ -21,19 +22,23 @@ type Performance struct {
 
 var sha256CurveList = []string{"BN254", "BLS12-377", "BLS12-381", "BLS24-315"}
 
-func performance() []Performance {
+func performance() ([]Performance, error) {
 	var p []Performance
-	for _, scheme := range []string{"groth16", "plonk"} {
+	for _, scheme := range wrapper.SchemeList {
 		for _, curve := range sha256CurveList {
 			logger.Info("Sha256ZKP on curve [%s] scheme [%s]", curve, scheme)
-			p = append(p, Sha256ZKP(scheme, curve, "z"))
+			perf, err := Sha256ZKP(scheme, curve, "z")
+			if err != nil {
+				return nil, err
+			}
+			p = append(p, perf)
 			// for hashName := range shahash.HashCaseMap {
 			// 	logger.Info("Sha3ZKP on curve [%s] scheme [%s] hash [%s]", curve, scheme, hashName)
 			// 	p = append(p, Sha3ZKP(scheme, curve, "z", hashName))
 			// }
 		}
 	}
-	return p
+	return p, nil
 }
 
 func main() {
@@ -42,7 +47,9 @@ func main() {
 	args := os.Args[1:]
 	if len(args) == 0 {
 		logger.Info("Sha256ZKP on curve [BN254] scheme [groth16]")
-		Sha256ZKP("groth16", "BN254", "z")
+		if _, err := Sha256ZKP("groth16", "BN254", "z"); err != nil {
+			logger.Error("Sha256ZKP failed: %v", err)
+		}
 		return
 	}
 	if args[0] == "performance" {
@@ -58,7 +65,11 @@ func main() {
 		}
 
 		logger.Info("Gathering performance data...")
-		pList := performance()
+		pList, err := performance()
+		if err != nil {
+			logger.Error("Failed to gather performance data: %v", err)
+			return
+		}
 		// 将pList写入json文件
 		jsonData, err := json.Marshal(pList)
 		if err != nil {
@@ -42,48 +42,41 @@ func (sc *Sha256Circuit) Define(api frontend.API) error {
 	return nil
 }
 
-func (sc *Sha256Circuit) PreCompile(params any) {
-	preImageLen := params.([]interface{})[0].(int)
+// PreCompile 根据原像长度确定电路形状
+func (sc *Sha256Circuit) PreCompile(preImageLen int) error {
 	sc.PreImage = make([]uints.U8, preImageLen)
+	return nil
 }
 
-func (sc *Sha256Circuit) Assign(params any) {
-	args := params.([]interface{})
-	preImage := args[0].(string)
+// Assign 计算原像的SHA256并赋值
+func (sc *Sha256Circuit) Assign(preImage string) error {
 	preImageU8, hashU8Arr := shahash.CalcSha256(preImage)
 	sc.PreImage = preImageU8
 	sc.Hash = hashU8Arr
+	return nil
 }
 
-func Sha256ZKP(scheme string, curveName string, preImage string) Performance {
+func Sha256ZKP(scheme string, curveName string, preImage string) (Performance, error) {
 	var sc Sha256Circuit
-	preCompileParams := []any{len(preImage)}
-
-	assignParams := []any{preImage}
-	switch scheme {
-	case "groth16":
-		gw := wrapper.Groth16ZKP(&sc, curveName, preCompileParams, assignParams)
-		return Performance{
-			Scheme:        scheme,
-			HashAlg:       "SHA256",
-			Curve:         curveName,
-			PreImage:      preImage,
-			ProveTime:     gw.BenchmarkProve(10).Milliseconds(),
-			VerifyTime:    gw.BenchmarkVerify(10).Milliseconds(),
-			ConstraintNum: gw.ConstraintNum,
-		}
-	case "plonk":
-		pw := wrapper.PlonkZKP(&sc, curveName, preCompileParams, assignParams)
-		return Performance{
-			Scheme:        scheme,
-			HashAlg:       "SHA256",
-			Curve:         curveName,
-			PreImage:      preImage,
-			ProveTime:     pw.BenchmarkProve(10).Milliseconds(),
-			VerifyTime:    pw.BenchmarkVerify(10).Milliseconds(),
-			ConstraintNum: pw.ConstraintNum,
-		}
-	default:
-		return Performance{}
+	ps, err := wrapper.ZKP(scheme, &sc, curveName, len(preImage), preImage)
+	if err != nil {
+		return Performance{}, err
+	}
+	proveTime, err := ps.BenchmarkProve(10)
+	if err != nil {
+		return Performance{}, err
+	}
+	verifyTime, err := ps.BenchmarkVerify(10)
+	if err != nil {
+		return Performance{}, err
 	}
+	return Performance{
+		Scheme:        scheme,
+		HashAlg:       "SHA256",
+		Curve:         curveName,
+		PreImage:      preImage,
+		ProveTime:     proveTime.Milliseconds(),
+		VerifyTime:    verifyTime.Milliseconds(),
+		ConstraintNum: ps.GetConstraintNum(),
+	}, nil
 }
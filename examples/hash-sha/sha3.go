@@ -37,54 +37,63 @@ func (sc *Sha3Circuit) Define(api frontend.API) error {
 	return nil
 }
 
-func (sc *Sha3Circuit) PreCompile(params any) {
-	args := params.([]interface{})
-	preImageLen := args[0].(int)
-	sc.PreImage = make([]uints.U8, preImageLen)
-	zkSha3Name := args[1].(string)
-	hashLen := shahash.HashCaseMap[zkSha3Name].Native().Size()
-	sc.Hash = make([]uints.U8, hashLen)
-	sc.Hasher = zkSha3Name
+// Sha3CompileParams Sha3Circuit的编译参数
+type Sha3CompileParams struct {
+	PreImageLen int
+	Hasher      string
 }
 
-func (sc *Sha3Circuit) Assign(params any) {
-	args := params.([]interface{})
-	preImage := args[0].(string)
-	zkSha3Name := args[1].(string)
-	preImageU8, HashU8 := shahash.CalcSha3(preImage, zkSha3Name)
+// Sha3Assign Sha3Circuit的赋值参数
+type Sha3Assign struct {
+	PreImage string
+	Hasher   string
+}
+
+func (sc *Sha3Circuit) PreCompile(params Sha3CompileParams) error {
+	hashCase, ok := shahash.HashCaseMap[params.Hasher]
+	if !ok {
+		return fmt.Errorf("invalid hasher: %s", params.Hasher)
+	}
+	sc.PreImage = make([]uints.U8, params.PreImageLen)
+	sc.Hash = make([]uints.U8, hashCase.Native().Size())
+	sc.Hasher = params.Hasher
+	return nil
+}
+
+func (sc *Sha3Circuit) Assign(params Sha3Assign) error {
+	if _, ok := shahash.HashCaseMap[params.Hasher]; !ok {
+		return fmt.Errorf("invalid hasher: %s", params.Hasher)
+	}
+	preImageU8, HashU8 := shahash.CalcSha3(params.PreImage, params.Hasher)
 	sc.PreImage = preImageU8
 	sc.Hash = HashU8
-	sc.Hasher = zkSha3Name
+	sc.Hasher = params.Hasher
+	return nil
 }
 
-func Sha3ZKP(scheme string, curveName string, preImage string, zkSha3Name string) Performance {
+func Sha3ZKP(scheme string, curveName string, preImage string, zkSha3Name string) (Performance, error) {
 	var sc Sha3Circuit
-	preCompileParams := []any{len(preImage), zkSha3Name}
-	assignParams := []any{preImage, zkSha3Name}
-	switch scheme {
-	case "groth16":
-		gw := wrapper.Groth16ZKP(&sc, curveName, preCompileParams, assignParams)
-		return Performance{
-			Scheme:        scheme,
-			HashAlg:       zkSha3Name,
-			Curve:         curveName,
-			PreImage:      preImage,
-			ProveTime:     gw.BenchmarkProve(10).Milliseconds(),
-			VerifyTime:    gw.BenchmarkVerify(10).Milliseconds(),
-			ConstraintNum: gw.ConstraintNum,
-		}
-	case "plonk":
-		pw := wrapper.PlonkZKP(&sc, curveName, preCompileParams, assignParams)
-		return Performance{
-			Scheme:        scheme,
-			HashAlg:       zkSha3Name,
-			Curve:         curveName,
-			PreImage:      preImage,
-			ProveTime:     pw.BenchmarkProve(10).Milliseconds(),
-			VerifyTime:    pw.BenchmarkVerify(10).Milliseconds(),
-			ConstraintNum: pw.ConstraintNum,
-		}
-	default:
-		return Performance{}
+	preCompileParams := Sha3CompileParams{PreImageLen: len(preImage), Hasher: zkSha3Name}
+	assignParams := Sha3Assign{PreImage: preImage, Hasher: zkSha3Name}
+	ps, err := wrapper.ZKP(scheme, &sc, curveName, preCompileParams, assignParams)
+	if err != nil {
+		return Performance{}, err
+	}
+	proveTime, err := ps.BenchmarkProve(10)
+	if err != nil {
+		return Performance{}, err
+	}
+	verifyTime, err := ps.BenchmarkVerify(10)
+	if err != nil {
+		return Performance{}, err
 	}
+	return Performance{
+		Scheme:        scheme,
+		HashAlg:       zkSha3Name,
+		Curve:         curveName,
+		PreImage:      preImage,
+		ProveTime:     proveTime.Milliseconds(),
+		VerifyTime:    verifyTime.Milliseconds(),
+		ConstraintNum: ps.GetConstraintNum(),
+	}, nil
 }
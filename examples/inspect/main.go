@@ -0,0 +1,116 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/oliverustc/gnarkabc/circuits"
+	"github.com/oliverustc/gnarkabc/hash/mimchash"
+	"github.com/oliverustc/gnarkabc/hash/poseidon2hash"
+	"github.com/oliverustc/gnarkabc/logger"
+	"github.com/oliverustc/gnarkabc/utils"
+	"github.com/oliverustc/gnarkabc/wrapper/inspect"
+
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/frontend/cs/scs"
+)
+
+// 统计 circuits 中各电路的约束系统，输出表格或 JSON；
+// 使用 -soundness 时改为用合法赋值检查电路是否约束不足，列出可疑的变量
+func main() {
+	curveName := flag.String("curve", "BN254", "curve name")
+	asJSON := flag.Bool("json", false, "print the reports as json")
+	top := flag.Int("top", 10, "number of locations and labels listed in the table")
+	soundness := flag.Bool("soundness", false, "search for under-constrained wires instead of counting constraints")
+	flag.Parse()
+
+	curve, ok := utils.CurveMap[*curveName]
+	if !ok {
+		logger.Error("unsupported curve: %s", *curveName)
+		os.Exit(1)
+	}
+	circuitList := map[string]frontend.Circuit{
+		"Product":       &circuits.Product{},
+		"MimcHash":      &circuits.MimcHash{},
+		"Poseidon2Hash": &circuits.Poseidon2Hash{},
+	}
+	// 各电路的合法赋值
+	preImage := big.NewInt(42).Bytes()
+	assignments := map[string]frontend.Circuit{
+		"Product": &circuits.Product{P: 3, Q: 4, N: 12},
+		"MimcHash": &circuits.MimcHash{
+			PreImage: preImage,
+			Hash:     mimchash.MiMCHash(mimchash.MiMCCaseMap[*curveName].Hash, [][]byte{preImage}),
+		},
+		"Poseidon2Hash": &circuits.Poseidon2Hash{
+			PreImage: preImage,
+			Hash:     poseidon2hash.Poseidon2Hash(poseidon2hash.Poseidon2CaseMap[*curveName].Hash, [][]byte{preImage}),
+		},
+	}
+	builders := map[string]frontend.NewBuilder{
+		"groth16": r1cs.NewBuilder[constraint.U64],
+		"plonk":   scs.NewBuilder[constraint.U64],
+	}
+	for name, circuit := range circuitList {
+		for scheme, builder := range builders {
+			ccs, report, err := inspect.Compile(curve, builder, circuit)
+			if err != nil {
+				// Poseidon2 电路只支持部分曲线
+				logger.Warn("inspect %s for %s on %s failed: %v", name, scheme, *curveName, err)
+				continue
+			}
+			if *soundness {
+				if err := analyze(ccs, assignments[name], name, scheme, *asJSON); err != nil {
+					logger.Error("analyze %s for %s failed: %v", name, scheme, err)
+					os.Exit(1)
+				}
+				continue
+			}
+			if *asJSON {
+				data, err := report.JSON()
+				if err != nil {
+					logger.Error("marshal report failed: %v", err)
+					os.Exit(1)
+				}
+				fmt.Println(string(data))
+				continue
+			}
+			fmt.Printf("== %s (%s)\n", name, scheme)
+			if err := report.WriteTable(os.Stdout, *top); err != nil {
+				logger.Error("write table failed: %v", err)
+				os.Exit(1)
+			}
+			fmt.Println()
+		}
+	}
+}
+
+// analyze 检查电路是否约束不足，输出可疑的变量
+func analyze(ccs constraint.ConstraintSystem, assignment frontend.Circuit, name, scheme string, asJSON bool) error {
+	w, err := frontend.NewWitness(assignment, ccs.Field())
+	if err != nil {
+		return err
+	}
+	s, err := inspect.Analyze(ccs, w)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		data, err := s.JSON()
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	fmt.Printf("== %s (%s)\n", name, scheme)
+	if err := s.WriteTable(os.Stdout); err != nil {
+		return err
+	}
+	fmt.Println()
+	return nil
+}
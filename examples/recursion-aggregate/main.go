@@ -1,19 +1,71 @@
 package main
 
-import "os"
+import (
+	"os"
+	"strconv"
 
+	"github.com/oliverustc/gnarkabc/circuits"
+	"github.com/oliverustc/gnarkabc/logger"
+	"github.com/oliverustc/gnarkabc/utils"
+	"github.com/oliverustc/gnarkabc/wrapper/aggregation"
+	"github.com/oliverustc/gnarkabc/wrapper/cache"
+	"github.com/oliverustc/gnarkabc/wrapper/groth16wrapper"
+
+	"github.com/consensys/gnark-crypto/ecc"
+)
+
+// 用法: go run ./examples/recursion-aggregate [叶子数] [扇入]，默认聚合 16 个叶子，扇入为 2
 func main() {
-	args := os.Args[1:]
-	switch args[0] {
-	case "genleaf":
-		GenerateLeafProof(16)
-	case "ra1":
-		RecursionAggregateLeafProofs([]int{0, 1, 2, 3, 4, 5, 6, 7}, 0)
-		RecursionAggregateLeafProofs([]int{0, 2, 4, 6}, 1)
-		RecursionAggregateLeafProofs([]int{0, 4}, 2)
-	case "ra2":
-		RecursionAggregateLeafProofs([]int{8, 9, 10, 11, 12, 13, 14, 15}, 0)
-		RecursionAggregateLeafProofs([]int{8, 10, 12, 14}, 1)
-		RecursionAggregateLeafProofs([]int{8, 12}, 2)
+	nbLeaves, fanIn := 16, 2
+	if len(os.Args) > 1 {
+		nbLeaves, _ = strconv.Atoi(os.Args[1])
+	}
+	if len(os.Args) > 2 {
+		fanIn, _ = strconv.Atoi(os.Args[2])
+	}
+
+	curve := ecc.BN254
+	var circuit circuits.Product
+	circuit.PreCompile(circuits.NoParams{})
+	leafZK := groth16wrapper.NewWrapper(&circuit, curve)
+	if err := leafZK.Compile(); err != nil {
+		logger.Fatal("%v", err)
+	}
+	if err := leafZK.Setup(); err != nil {
+		logger.Fatal("%v", err)
+	}
+	// 叶子证明在同一曲线的节点电路中验证
+	leafZK.SetRecursionTarget(curve)
+	leaves := make([]aggregation.Leaf, nbLeaves)
+	for i := range leaves {
+		circuit.Assign(circuits.ProductAssign{P: utils.RandInt(2, 100), Q: utils.RandInt(2, 100)})
+		leafZK.SetAssignment(&circuit)
+		if err := leafZK.Prove(); err != nil {
+			logger.Fatal("%v", err)
+		}
+		if err := leafZK.GenerateWitness(true); err != nil {
+			logger.Fatal("%v", err)
+		}
+		leaves[i] = aggregation.Leaf{Proof: leafZK.Proof, Witness: leafZK.WitnessPublic}
+	}
+
+	// 各层节点电路的参数以约束系统摘要为键缓存，再次运行时不需要重新设置
+	artifactCache, err := cache.New("output/cache")
+	if err != nil {
+		logger.Fatal("%v", err)
+	}
+	res, err := aggregation.Aggregate(leafZK.CCS, leafZK.VK, leaves, aggregation.Config{FanIn: fanIn, Parallelism: 2, Cache: artifactCache})
+	if err != nil {
+		logger.Fatal("%v", err)
+	}
+	logger.Info("aggregated %d proofs in %d levels, commitment: %s", nbLeaves, res.Depth, res.Commitment.String())
+	if err := res.Root.WriteProof("aggregate.proof"); err != nil {
+		logger.Fatal("%v", err)
+	}
+	if err := res.Root.WriteVK("aggregate.vk"); err != nil {
+		logger.Fatal("%v", err)
+	}
+	if err := res.Root.WriteWitness("aggregate.wit", true); err != nil {
+		logger.Fatal("%v", err)
 	}
 }
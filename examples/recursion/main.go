@@ -1,9 +1,11 @@
 package main
 
 import (
+	"math/big"
 	"os"
 
 	"github.com/oliverustc/gnarkabc/circuits"
+	"github.com/oliverustc/gnarkabc/hash/mimchash"
 	"github.com/oliverustc/gnarkabc/logger"
 	"github.com/oliverustc/gnarkabc/utils"
 	"github.com/oliverustc/gnarkabc/wrapper/groth16wrapper"
@@ -11,44 +13,75 @@ import (
 
 func generateProductGroth16InnerProofs() {
 	var innerCircuit circuits.Product
-	innerCircuit.PreCompile(nil)
+	innerCircuit.PreCompile(circuits.NoParams{})
 	for _, curveName := range utils.Groth16RecursionCurveList {
 		curve := utils.CurveMap[curveName]
 		zk := groth16wrapper.NewWrapper(&innerCircuit, curve)
-		zk.Compile()
-		zk.Setup()
+		if err := zk.Compile(); err != nil {
+			logger.Fatal("%v", err)
+		}
+		if err := zk.Setup(); err != nil {
+			logger.Fatal("%v", err)
+		}
 		p := utils.RandInt(0, 1000)
 		q := utils.RandInt(0, 1000)
-		assignParams := []any{p, q}
+		assignParams := circuits.ProductAssign{P: p, Q: q}
 		innerCircuit.Assign(assignParams)
 		zk.SetAssignment(&innerCircuit)
-		zk.Prove()
-		zk.Verify()
+		if err := zk.Prove(); err != nil {
+			logger.Fatal("%v", err)
+		}
+		if err := zk.Verify(); err != nil {
+			logger.Fatal("%v", err)
+		}
 
-		zk.WriteCCS("output/inner_groth16_product_" + curveName + ".ccs")
-		zk.WriteVK("output/inner_groth16_product_" + curveName + ".vk")
-		zk.WriteProof("output/inner_groth16_product_" + curveName + ".proof")
-		zk.WriteWitness("output/inner_groth16_product_"+curveName+".wit", false)
+		if err := zk.WriteCCS("inner_groth16_product_" + curveName + ".ccs"); err != nil {
+			logger.Fatal("%v", err)
+		}
+		if err := zk.WriteVK("inner_groth16_product_" + curveName + ".vk"); err != nil {
+			logger.Fatal("%v", err)
+		}
+		if err := zk.WriteProof("inner_groth16_product_" + curveName + ".proof"); err != nil {
+			logger.Fatal("%v", err)
+		}
+		if err := zk.WriteWitness("inner_groth16_product_"+curveName+".wit", false); err != nil {
+			logger.Fatal("%v", err)
+		}
 	}
 }
 
 func generateMimcHashGroth16InnerProofs() {
 	var innerCircuit circuits.MimcHash
-	innerCircuit.PreCompile(nil)
+	innerCircuit.PreCompile(circuits.NoParams{})
 	for _, curveName := range utils.Groth16RecursionCurveList {
 		curve := utils.CurveMap[curveName]
 		zk := groth16wrapper.NewWrapper(&innerCircuit, curve)
-		zk.Compile()
-		zk.Setup()
-		p := utils.RandInt(0, 1000)
-		assignParams := []any{p}
+		if err := zk.Compile(); err != nil {
+			logger.Fatal("%v", err)
+		}
+		if err := zk.Setup(); err != nil {
+			logger.Fatal("%v", err)
+		}
+		preImage := big.NewInt(int64(utils.RandInt(0, 1000))).Bytes()
+		hash := mimchash.MiMCHash(mimchash.MiMCCaseMap[curveName].Hash, [][]byte{preImage})
+		assignParams := circuits.HashAssign{PreImage: preImage, Hash: hash}
 		innerCircuit.Assign(assignParams)
 		zk.SetAssignment(&innerCircuit)
-		zk.Prove()
-		zk.Verify()
-		zk.WriteCCS("output/inner_groth16_mimc_" + curveName + ".ccs")
-		zk.WriteVK("output/inner_groth16_mimc_" + curveName + ".vk")
-		zk.WriteProof("output/inner_groth16_mimc_" + curveName + ".proof")
+		if err := zk.Prove(); err != nil {
+			logger.Fatal("%v", err)
+		}
+		if err := zk.Verify(); err != nil {
+			logger.Fatal("%v", err)
+		}
+		if err := zk.WriteCCS("inner_groth16_mimc_" + curveName + ".ccs"); err != nil {
+			logger.Fatal("%v", err)
+		}
+		if err := zk.WriteVK("inner_groth16_mimc_" + curveName + ".vk"); err != nil {
+			logger.Fatal("%v", err)
+		}
+		if err := zk.WriteProof("inner_groth16_mimc_" + curveName + ".proof"); err != nil {
+			logger.Fatal("%v", err)
+		}
 	}
 }
 
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"github.com/oliverustc/gnarkabc/circuits"
 	"github.com/oliverustc/gnarkabc/logger"
 	"github.com/oliverustc/gnarkabc/utils"
 	"github.com/oliverustc/gnarkabc/wrapper/groth16wrapper"
@@ -25,30 +26,39 @@ func (sc *Product) Define(api frontend.API) error {
 	return nil
 }
 
-func (sc *Product) PreCompile(params ...interface{}) {
+func (sc *Product) PreCompile(params circuits.NoParams) error {
 	// 留空
+	return nil
 }
 
-func (sc *Product) Assign(params ...interface{}) {
-	x := params[0].(int)
+func (sc *Product) Assign(x int) error {
 	y := x*x*x + x + 5
 	sc.X = x
 	sc.Y = y
+	return nil
 }
 
 func main() {
 	curveName := "BN254"
 	curve := utils.CurveMap[curveName]
 	var sc Product
-	sc.PreCompile()
+	sc.PreCompile(circuits.NoParams{})
 	var scAssign Product
 	scAssign.Assign(128)
 	groth16 := groth16wrapper.NewWrapper(&sc, curve)
-	groth16.Compile()
-	groth16.Setup()
+	if err := groth16.Compile(); err != nil {
+		logger.Fatal("%v", err)
+	}
+	if err := groth16.Setup(); err != nil {
+		logger.Fatal("%v", err)
+	}
 	groth16.SetAssignment(&scAssign)
-	groth16.Prove()
-	groth16.Verify()
+	if err := groth16.Prove(); err != nil {
+		logger.Fatal("%v", err)
+	}
+	if err := groth16.Verify(); err != nil {
+		logger.Fatal("%v", err)
+	}
 
 	logger.Info("groth16 compile time: %s", groth16.CompileTime.String())
 	logger.Info("groth16 setup time: %s", groth16.SetupTime.String())
@@ -56,11 +66,19 @@ func main() {
 	logger.Info("groth16 verify time: %s", groth16.VerifyTime.String())
 
 	plonk := plonkwrapper.NewWrapper(&sc, curve)
-	plonk.Compile()
-	plonk.Setup()
+	if err := plonk.Compile(); err != nil {
+		logger.Fatal("%v", err)
+	}
+	if err := plonk.Setup(); err != nil {
+		logger.Fatal("%v", err)
+	}
 	plonk.SetAssignment(&scAssign)
-	plonk.Prove()
-	plonk.Verify()
+	if err := plonk.Prove(); err != nil {
+		logger.Fatal("%v", err)
+	}
+	if err := plonk.Verify(); err != nil {
+		logger.Fatal("%v", err)
+	}
 
 	logger.Info("plonk compile time: %s", plonk.CompileTime.String())
 	logger.Info("plonk setup time: %s", plonk.SetupTime.String())
@@ -0,0 +1,73 @@
+// Package fieldpack 将字节串单射地编码为标量域元素。
+// 第一个元素是字节串的长度，其后按 ChunkSize 个字节一块依次编码，最后一块可以较短；
+// 每块按大端序解释为整数，块长使该整数总小于标量域模数，因此编码不需要取模，
+// 不同的字节串（包括仅相差前导零字节的字节串）得到不同的元素序列。Unpack 是对应的电路内解码器
+package fieldpack
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// ErrNonCanonical 表示元素序列不是任何字节串的编码
+var ErrNonCanonical = errors.New("non-canonical field packing")
+
+// ChunkSize 返回标量域上每个元素编码的字节数，即满足 2^(8n) < field 的最大 n；
+// BN254、BLS12 和 BLS24 曲线为 31，BW6-633 为 39，BW6-761 为 47
+func ChunkSize(field *big.Int) int {
+	return (field.BitLen() - 1) / 8
+}
+
+// NbElements 返回 n 个字节编码后的元素个数，包括长度前缀
+func NbElements(n int, field *big.Int) int {
+	chunk := ChunkSize(field)
+	return 1 + (n+chunk-1)/chunk
+}
+
+// Pack 返回 data 的编码，每个元素为大端序字节，可以直接作为 MiMCHash、Poseidon2Hash 的输入或电路赋值
+func Pack(data []byte, field *big.Int) [][]byte {
+	chunk := ChunkSize(field)
+	elements := make([][]byte, 0, NbElements(len(data), field))
+	elements = append(elements, element(big.NewInt(int64(len(data)))))
+	for lo := 0; lo < len(data); lo += chunk {
+		elements = append(elements, element(new(big.Int).SetBytes(data[lo:min(lo+chunk, len(data))])))
+	}
+	return elements
+}
+
+// element 返回 v 去掉前导零字节的大端序表示，0 表示为 []byte{0}：
+// 原生哈希写入空切片时不吸收任何元素，而电路中吸收 0
+func element(v *big.Int) []byte {
+	if v.Sign() == 0 {
+		return []byte{0}
+	}
+	return v.Bytes()
+}
+
+// Decode 是 Pack 的逆运算，元素序列不是规范编码时返回 ErrNonCanonical
+func Decode(elements [][]byte, field *big.Int) ([]byte, error) {
+	if len(elements) == 0 {
+		return nil, fmt.Errorf("%w: missing length prefix", ErrNonCanonical)
+	}
+	length := new(big.Int).SetBytes(elements[0])
+	if !length.IsInt64() || length.Int64() > int64(len(elements))*int64(ChunkSize(field)) {
+		return nil, fmt.Errorf("%w: length %s exceeds %d elements", ErrNonCanonical, length, len(elements))
+	}
+	n := int(length.Int64())
+	if len(elements) != NbElements(n, field) {
+		return nil, fmt.Errorf("%w: %d elements for %d bytes, expected %d", ErrNonCanonical, len(elements), n, NbElements(n, field))
+	}
+	chunk := ChunkSize(field)
+	data := make([]byte, n)
+	for i, e := range elements[1:] {
+		lo := i * chunk
+		size := min(chunk, n-lo)
+		v := new(big.Int).SetBytes(e)
+		if v.BitLen() > 8*size {
+			return nil, fmt.Errorf("%w: element %d does not fit in %d bytes", ErrNonCanonical, i+1, size)
+		}
+		v.FillBytes(data[lo : lo+size])
+	}
+	return data, nil
+}
@@ -0,0 +1,100 @@
+package fieldpack
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/oliverustc/gnarkabc/hash/mimchash"
+)
+
+func TestPackRoundTrip(t *testing.T) {
+	for curveName, c := range mimchash.MiMCCaseMap {
+		field := c.Curve.ScalarField()
+		chunk := ChunkSize(field)
+		if new(big.Int).Lsh(big.NewInt(1), uint(8*chunk)).Cmp(field) >= 0 {
+			t.Fatalf("%s: chunk of %d bytes does not fit in the scalar field", curveName, chunk)
+		}
+		inputs := [][]byte{
+			nil,
+			[]byte("a"),
+			[]byte("\x00a"),
+			bytes.Repeat([]byte{0}, chunk),
+			bytes.Repeat([]byte{0xff}, chunk+1),
+			bytes.Repeat([]byte("helloworld"), 10),
+		}
+		seen := make(map[string]bool)
+		for _, input := range inputs {
+			elements := Pack(input, field)
+			if len(elements) != NbElements(len(input), field) {
+				t.Fatalf("%s: %d elements for %d bytes", curveName, len(elements), len(input))
+			}
+			// 每个元素都小于模数，不需要取模
+			for _, e := range elements {
+				if new(big.Int).SetBytes(e).Cmp(field) >= 0 {
+					t.Fatalf("%s: element %x not reduced", curveName, e)
+				}
+			}
+			decoded, err := Decode(elements, field)
+			if err != nil {
+				t.Fatalf("%s: %v", curveName, err)
+			}
+			if !bytes.Equal(decoded, input) {
+				t.Fatalf("%s: decoded %x, expected %x", curveName, decoded, input)
+			}
+			// 不同的输入得到不同的哈希
+			hash := string(mimchash.MiMCHash(c.Hash, elements))
+			if seen[hash] {
+				t.Fatalf("%s: collision on %q", curveName, input)
+			}
+			seen[hash] = true
+		}
+	}
+}
+
+// 值为 0 的元素表示为 []byte{0}，否则原生哈希不吸收该元素，与电路中的哈希不一致
+func TestPackZeroElements(t *testing.T) {
+	for curveName, c := range mimchash.MiMCCaseMap {
+		field := c.Curve.ScalarField()
+		for _, tc := range []struct {
+			input    []byte
+			elements [][]byte
+		}{
+			{nil, [][]byte{{0}}},
+			{[]byte{0}, [][]byte{{1}, {0}}},
+			{[]byte{0, 0, 1}, [][]byte{{3}, {1}}},
+		} {
+			elements := Pack(tc.input, field)
+			if len(elements) != len(tc.elements) {
+				t.Fatalf("%s: %x packed into %x", curveName, tc.input, elements)
+			}
+			for i := range elements {
+				if !bytes.Equal(elements[i], tc.elements[i]) {
+					t.Fatalf("%s: %x packed into %x, expected %x", curveName, tc.input, elements, tc.elements)
+				}
+			}
+			decoded, err := Decode(elements, field)
+			if err != nil || !bytes.Equal(decoded, tc.input) {
+				t.Fatalf("%s: decoded %x: %v", curveName, decoded, err)
+			}
+		}
+	}
+}
+
+func TestDecodeNonCanonical(t *testing.T) {
+	field := mimchash.MiMCCaseMap["BN254"].Curve.ScalarField()
+	elements := Pack([]byte("hello"), field)
+	cases := map[string][][]byte{
+		"empty":          nil,
+		"wrong length":   {{4}, elements[1]},
+		"missing chunk":  {{32}, elements[1]},
+		"huge length":    {bytes.Repeat([]byte{0xff}, 16), elements[1]},
+		"oversized last": {{5}, append([]byte{1}, elements[1]...)},
+	}
+	for name, c := range cases {
+		if _, err := Decode(c, field); !errors.Is(err, ErrNonCanonical) {
+			t.Fatalf("%s: expected ErrNonCanonical, got %v", name, err)
+		}
+	}
+}
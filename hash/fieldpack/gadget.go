@@ -0,0 +1,30 @@
+package fieldpack
+
+import (
+	"fmt"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/math/bits"
+)
+
+// Unpack 在电路中解码 Pack 得到的 n 个字节的编码，返回每个字节对应的变量。
+// 长度前缀被约束为 n，每块按字节数做位分解，既约束了块在范围内，也约束了返回的每个字节小于 256，
+// 因此满足约束的元素序列恰好是某个 n 字节串的编码。元素个数与 NbElements 不符时返回错误
+func Unpack(api frontend.API, elements []frontend.Variable, n int) ([]frontend.Variable, error) {
+	field := api.Compiler().Field()
+	if len(elements) != NbElements(n, field) {
+		return nil, fmt.Errorf("%d elements for %d bytes, expected %d", len(elements), n, NbElements(n, field))
+	}
+	api.AssertIsEqual(elements[0], n)
+	chunk := ChunkSize(field)
+	data := make([]frontend.Variable, 0, n)
+	for i, e := range elements[1:] {
+		size := min(chunk, n-i*chunk)
+		// 小端序的位，最高的字节在最后
+		b := bits.ToBinary(api, e, bits.WithNbDigits(8*size))
+		for j := size - 1; j >= 0; j-- {
+			data = append(data, bits.FromBinary(api, b[8*j:8*j+8]))
+		}
+	}
+	return data, nil
+}
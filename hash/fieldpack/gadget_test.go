@@ -0,0 +1,82 @@
+package fieldpack
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/oliverustc/gnarkabc/hash/mimchash"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/test"
+)
+
+// unpackCircuit 断言 Elements 是公开的 Data 的编码
+type unpackCircuit struct {
+	Elements []frontend.Variable
+	Data     []frontend.Variable `gnark:",public"`
+}
+
+func (c *unpackCircuit) Define(api frontend.API) error {
+	data, err := Unpack(api, c.Elements, len(c.Data))
+	if err != nil {
+		return err
+	}
+	for i := range data {
+		api.AssertIsEqual(data[i], c.Data[i])
+	}
+	return nil
+}
+
+func newUnpackCircuit(elements [][]byte, data []byte) (*unpackCircuit, *unpackCircuit) {
+	circuit := &unpackCircuit{Elements: make([]frontend.Variable, len(elements)), Data: make([]frontend.Variable, len(data))}
+	assignment := &unpackCircuit{Elements: make([]frontend.Variable, len(elements)), Data: make([]frontend.Variable, len(data))}
+	for i := range elements {
+		assignment.Elements[i] = elements[i]
+	}
+	for i := range data {
+		assignment.Data[i] = data[i]
+	}
+	return circuit, assignment
+}
+
+func TestUnpack(t *testing.T) {
+	for curveName, c := range mimchash.MiMCCaseMap {
+		field := c.Curve.ScalarField()
+		chunk := ChunkSize(field)
+		for _, input := range [][]byte{nil, []byte("\x00a"), bytes.Repeat([]byte{0xff}, chunk), bytes.Repeat([]byte("helloworld"), 10)} {
+			circuit, assignment := newUnpackCircuit(Pack(input, field), input)
+			if err := test.IsSolved(circuit, assignment, field); err != nil {
+				t.Fatalf("%s: %q: %v", curveName, input, err)
+			}
+		}
+
+		input := bytes.Repeat([]byte{0xff}, chunk+1)
+		elements := Pack(input, field)
+		// 长度前缀与字节数不符
+		circuit, assignment := newUnpackCircuit(elements, input)
+		assignment.Elements[0] = len(input) + 1
+		if test.IsSolved(circuit, assignment, field) == nil {
+			t.Fatalf("%s: wrong length prefix accepted", curveName)
+		}
+		// 最后一块只有一个字节，取值 256 时超出范围
+		circuit, assignment = newUnpackCircuit(elements, input)
+		assignment.Elements[2] = 256
+		if test.IsSolved(circuit, assignment, field) == nil {
+			t.Fatalf("%s: out-of-range chunk accepted", curveName)
+		}
+		// 完整的块加上 2^(8n) 后超出范围
+		circuit, assignment = newUnpackCircuit(elements, input)
+		v := new(big.Int).SetBytes(elements[1])
+		assignment.Elements[1] = v.Add(v, new(big.Int).Lsh(big.NewInt(1), uint(8*chunk)))
+		if test.IsSolved(circuit, assignment, field) == nil {
+			t.Fatalf("%s: oversized chunk accepted", curveName)
+		}
+	}
+
+	circuit := &unpackCircuit{Elements: make([]frontend.Variable, 1), Data: make([]frontend.Variable, 1)}
+	if _, err := frontend.Compile(mimchash.MiMCCaseMap["BN254"].Curve.ScalarField(), r1cs.NewBuilder, circuit); err == nil {
+		t.Fatal("expected element count mismatch")
+	}
+}
@@ -34,6 +34,10 @@ var MiMCCaseMap = map[string]MiMCCase{
 	"BW6-761":   {ecc.BW6_761, gchash.MIMC_BW6_761.New()},
 }
 
+// ConvertString2Byte 将字符串左侧补零后按 32 字节分块，超出模数的块被取模。
+// 补零使仅相差前导零字节的输入（如 "a" 和 "\x00a"）得到相同的结果，取模也会改变较高的块。
+//
+// Deprecated: 使用 fieldpack.Pack，其编码带有长度前缀且不需要取模
 func ConvertString2Byte(input string, mod *big.Int) [][]byte {
 	logger.Debug("input: %s", input)
 	inputLen := len(input)
@@ -52,6 +56,11 @@ func ConvertString2Byte(input string, mod *big.Int) [][]byte {
 			inputBigInt.Mod(inputBigInt, mod)
 			logger.Debug("after mod, inputBigInt: %v", inputBigInt)
 		}
+		// 0 的 big.Int.Bytes() 为空，原生哈希写入空切片时不吸收任何元素，而电路中吸收 0
+		if inputBigInt.Sign() == 0 {
+			inputBytes = append(inputBytes, []byte{0})
+			continue
+		}
 		inputBytes = append(inputBytes, inputBigInt.Bytes())
 	}
 	return inputBytes
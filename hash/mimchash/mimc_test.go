@@ -12,6 +12,10 @@ import (
 	"github.com/oliverustc/gnarkabc/logger"
 	"github.com/oliverustc/gnarkabc/utils"
 	"github.com/oliverustc/gnarkabc/wrapper"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash/mimc"
+	"github.com/consensys/gnark/test"
 )
 
 func TestConvertString2Byte(t *testing.T) {
@@ -24,6 +28,25 @@ func TestConvertString2Byte(t *testing.T) {
 	}
 }
 
+// 值为 0 的块表示为 []byte{0}：MiMCHash 写入空切片时不吸收任何元素，而电路中吸收 0
+func TestConvertString2ByteZeroChunk(t *testing.T) {
+	input := strings.Repeat("\x00", 32)
+	for _, curveName := range utils.CurveNameList {
+		mod := MiMCCaseMap[curveName].Curve.ScalarField()
+		data := ConvertString2Byte(input, mod)
+		if len(data) != 1 || !bytes.Equal(data[0], []byte{0}) {
+			t.Fatalf("%s: unexpected zero chunk %x", curveName, data)
+		}
+		if err := checkMiMC(curveName, []byte(input)); err != nil {
+			t.Fatalf("%s: %v", curveName, err)
+		}
+	}
+	h := MiMCCaseMap["BN254"].Hash
+	if bytes.Equal(MiMCHash(h, [][]byte{{}}), MiMCHash(h, [][]byte{{0}})) {
+		t.Fatal("MiMCHash absorbs an empty element")
+	}
+}
+
 func TestMiMCHashString(t *testing.T) {
 	input := strings.Repeat("helloworld", 10)
 	logger.Info("input: %s", input)
@@ -45,10 +68,14 @@ func TestMiMCHashStringZKP(t *testing.T) {
 		mod := MiMCCaseMap[curveName].Curve.ScalarField()
 		data := ConvertString2Byte(input, mod)
 		expectedHash := MiMCHash(hashFunc, data)
-		assignParams := []any{data, expectedHash}
+		assignParams := circuits.HashAssign{PreImage: data[0], Hash: expectedHash}
 		var mc circuits.MimcHash
-		wrapper.Groth16ZKP(&mc, curveName, nil, assignParams)
-		wrapper.PlonkZKP(&mc, curveName, nil, assignParams)
+		if _, err := wrapper.Groth16ZKP(&mc, curveName, circuits.NoParams{}, assignParams); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := wrapper.PlonkZKP(&mc, curveName, circuits.NoParams{}, assignParams); err != nil {
+			t.Fatal(err)
+		}
 	}
 }
 
@@ -59,10 +86,14 @@ func TestMiMCHashBigIntZKP(t *testing.T) {
 		logger.Info("mimc hash zkp with bigint input on curve: [%s]", curveName)
 		data := input.Bytes()
 		expectedHash := MiMCHash(MiMCCaseMap[curveName].Hash, [][]byte{data})
-		assignParams := []any{data, expectedHash}
+		assignParams := circuits.HashAssign{PreImage: data, Hash: expectedHash}
 		var mc circuits.MimcHash
-		wrapper.Groth16ZKP(&mc, curveName, nil, assignParams)
-		wrapper.PlonkZKP(&mc, curveName, nil, assignParams)
+		if _, err := wrapper.Groth16ZKP(&mc, curveName, circuits.NoParams{}, assignParams); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := wrapper.PlonkZKP(&mc, curveName, circuits.NoParams{}, assignParams); err != nil {
+			t.Fatal(err)
+		}
 	}
 }
 
@@ -101,3 +132,49 @@ func TestTwoConvertForBigInt(t *testing.T) {
 	}
 	logger.Info("two convert is equal")
 }
+
+// mimcCircuit 在电路中依次吸收 Data 中的元素，断言哈希等于公开的 Hash
+type mimcCircuit struct {
+	Data []frontend.Variable
+	Hash frontend.Variable `gnark:",public"`
+}
+
+func (c *mimcCircuit) Define(api frontend.API) error {
+	h, err := mimc.NewMiMC(api)
+	if err != nil {
+		return err
+	}
+	h.Write(c.Data...)
+	api.AssertIsEqual(h.Sum(), c.Hash)
+	return nil
+}
+
+// checkMiMC 在测试引擎中比较 input 的原生哈希与电路中的哈希
+func checkMiMC(curveName string, input []byte) error {
+	mod := MiMCCaseMap[curveName].Curve.ScalarField()
+	data := ConvertString2Byte(string(input), mod)
+	circuit := &mimcCircuit{Data: make([]frontend.Variable, len(data))}
+	assignment := &mimcCircuit{Data: make([]frontend.Variable, len(data)), Hash: MiMCHash(MiMCCaseMap[curveName].Hash, data)}
+	for i := range data {
+		assignment.Data[i] = data[i]
+	}
+	return test.IsSolved(circuit, assignment, mod)
+}
+
+// FuzzMiMCHash 检查任意输入在每条曲线上的原生哈希与电路中的哈希一致，不一致时报告缩小后的反例
+func FuzzMiMCHash(f *testing.F) {
+	for _, seed := range []string{"", "a", "\x00a", strings.Repeat("\x00", 32), strings.Repeat("\xff", 64), strings.Repeat("helloworld", 10)} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, input []byte) {
+		if len(input) > 512 {
+			t.Skip("input too long")
+		}
+		for _, curveName := range utils.CurveNameList {
+			if err := checkMiMC(curveName, input); err != nil {
+				reproducer := utils.MinimizeBytes(input, func(b []byte) bool { return checkMiMC(curveName, b) != nil })
+				t.Fatalf("mimc mismatch on %s: %v\nminimised reproducer: %q", curveName, err, reproducer)
+			}
+		}
+	})
+}
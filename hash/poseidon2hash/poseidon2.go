@@ -1,19 +1,23 @@
 package poseidon2hash
 
 import (
+	"fmt"
 	"hash"
 
+	"github.com/oliverustc/gnarkabc/utils"
+
 	"github.com/consensys/gnark-crypto/ecc"
+	poseidon2bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377/fr/poseidon2"
+	poseidon2bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381/fr/poseidon2"
+	poseidon2bls24315 "github.com/consensys/gnark-crypto/ecc/bls24-315/fr/poseidon2"
+	poseidon2bls24317 "github.com/consensys/gnark-crypto/ecc/bls24-317/fr/poseidon2"
+	poseidon2bn254 "github.com/consensys/gnark-crypto/ecc/bn254/fr/poseidon2"
+	poseidon2bw6633 "github.com/consensys/gnark-crypto/ecc/bw6-633/fr/poseidon2"
+	poseidon2bw6761 "github.com/consensys/gnark-crypto/ecc/bw6-761/fr/poseidon2"
 	gchash "github.com/consensys/gnark-crypto/hash"
-
-	// 导入MiMC哈希函数包以注册它们
-	_ "github.com/consensys/gnark-crypto/ecc/bls12-377/fr/poseidon2"
-	_ "github.com/consensys/gnark-crypto/ecc/bls12-381/fr/poseidon2"
-	_ "github.com/consensys/gnark-crypto/ecc/bls24-315/fr/poseidon2"
-	_ "github.com/consensys/gnark-crypto/ecc/bls24-317/fr/poseidon2"
-	_ "github.com/consensys/gnark-crypto/ecc/bn254/fr/poseidon2"
-	_ "github.com/consensys/gnark-crypto/ecc/bw6-633/fr/poseidon2"
-	_ "github.com/consensys/gnark-crypto/ecc/bw6-761/fr/poseidon2"
+	"github.com/consensys/gnark/frontend"
+	zkhash "github.com/consensys/gnark/std/hash"
+	"github.com/consensys/gnark/std/permutation/poseidon2"
 )
 
 type Poseidon2Case struct {
@@ -38,3 +42,49 @@ func Poseidon2Hash(h hash.Hash, data [][]byte) []byte {
 	}
 	return h.Sum(nil)
 }
+
+// std/hash/poseidon2 只有 BLS12-377 的默认参数，电路中按原生哈希的默认轮数构造置换，读取参数时才生成轮密钥
+var rounds = map[ecc.ID]func() (nbFullRounds, nbPartialRounds int){
+	ecc.BN254: func() (int, int) {
+		p := poseidon2bn254.GetDefaultParameters()
+		return p.NbFullRounds, p.NbPartialRounds
+	},
+	ecc.BLS12_377: func() (int, int) {
+		p := poseidon2bls12377.GetDefaultParameters()
+		return p.NbFullRounds, p.NbPartialRounds
+	},
+	ecc.BLS12_381: func() (int, int) {
+		p := poseidon2bls12381.GetDefaultParameters()
+		return p.NbFullRounds, p.NbPartialRounds
+	},
+	ecc.BLS24_315: func() (int, int) {
+		p := poseidon2bls24315.GetDefaultParameters()
+		return p.NbFullRounds, p.NbPartialRounds
+	},
+	ecc.BLS24_317: func() (int, int) {
+		p := poseidon2bls24317.GetDefaultParameters()
+		return p.NbFullRounds, p.NbPartialRounds
+	},
+	ecc.BW6_633: func() (int, int) {
+		p := poseidon2bw6633.GetDefaultParameters()
+		return p.NbFullRounds, p.NbPartialRounds
+	},
+	ecc.BW6_761: func() (int, int) {
+		p := poseidon2bw6761.GetDefaultParameters()
+		return p.NbFullRounds, p.NbPartialRounds
+	},
+}
+
+// NewHasher 返回电路中的 Poseidon2 哈希，与 Poseidon2CaseMap 中电路所在曲线的原生哈希一致，支持 utils.CurveNameList 中的所有曲线
+func NewHasher(api frontend.API) (zkhash.FieldHasher, error) {
+	curve, ok := utils.CurveOfField(api.Compiler().Field())
+	if !ok {
+		return nil, fmt.Errorf("%w: poseidon2 on field %s", utils.ErrUnsupportedCurve, api.Compiler().Field())
+	}
+	nbFullRounds, nbPartialRounds := rounds[curve]()
+	p, err := poseidon2.NewPoseidon2FromParameters(api, 2, nbFullRounds, nbPartialRounds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create poseidon2: %w", err)
+	}
+	return zkhash.NewMerkleDamgardHasher(api, p, 0), nil
+}
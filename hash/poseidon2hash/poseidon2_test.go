@@ -1,37 +1,120 @@
-package poseidon2hash
+// 外部测试包：wrapper 经 witnesshash 依赖 poseidon2hash，包内测试导入 wrapper 会形成循环导入
+package poseidon2hash_test
 
 import (
+	"bytes"
+	"strings"
 	"testing"
 
-	"github.com/oliverustc/gnarkabc/circuits"
-	"github.com/oliverustc/gnarkabc/hash/mimchash"
+	"github.com/oliverustc/gnarkabc/hash/fieldpack"
+	"github.com/oliverustc/gnarkabc/hash/poseidon2hash"
 	"github.com/oliverustc/gnarkabc/logger"
 	"github.com/oliverustc/gnarkabc/utils"
 	"github.com/oliverustc/gnarkabc/wrapper"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
 )
 
 func TestPoseidon2Hash(t *testing.T) {
 	input := utils.RandStr(100)
 
-	for curveName := range Poseidon2CaseMap {
-		mod := Poseidon2CaseMap[curveName].Curve.ScalarField()
-		inputBytes := mimchash.ConvertString2Byte(input, mod)
-		hashFunc := Poseidon2CaseMap[curveName].Hash
-		expectedHash := Poseidon2Hash(hashFunc, inputBytes)
+	for curveName := range poseidon2hash.Poseidon2CaseMap {
+		mod := poseidon2hash.Poseidon2CaseMap[curveName].Curve.ScalarField()
+		inputBytes := fieldpack.Pack([]byte(input), mod)
+		hashFunc := poseidon2hash.Poseidon2CaseMap[curveName].Hash
+		expectedHash := poseidon2hash.Poseidon2Hash(hashFunc, inputBytes)
 		logger.Info("curveName: %s, expectedHash: %x", curveName, expectedHash)
+
+		// 编码带有长度前缀，仅相差前导零字节的输入哈希不同
+		a := poseidon2hash.Poseidon2Hash(hashFunc, fieldpack.Pack([]byte("a"), mod))
+		if bytes.Equal(a, poseidon2hash.Poseidon2Hash(hashFunc, fieldpack.Pack([]byte("\x00a"), mod))) {
+			t.Fatalf("%s: \"a\" and \"\\x00a\" collide", curveName)
+		}
 	}
 }
 
 func TestPoseidon2ZKP(t *testing.T) {
-	input := utils.RandStr(32)
 	curveName := "BLS12-377"
 	logger.Info("poseidon2 hash zkp with string input on curve: [%s]", curveName)
-	hashFunc := Poseidon2CaseMap[curveName].Hash
-	mod := Poseidon2CaseMap[curveName].Curve.ScalarField()
-	inputBytes := mimchash.ConvertString2Byte(input, mod)
-	expectedHash := Poseidon2Hash(hashFunc, inputBytes)
-	assignParams := []any{inputBytes, expectedHash}
-	var mc circuits.Poseidon2Hash
-	wrapper.Groth16ZKP(&mc, curveName, nil, assignParams)
-	wrapper.PlonkZKP(&mc, curveName, nil, assignParams)
+	hashFunc := poseidon2hash.Poseidon2CaseMap[curveName].Hash
+	mod := poseidon2hash.Poseidon2CaseMap[curveName].Curve.ScalarField()
+	// 电路哈希完整的编码，包括长度前缀和所有块
+	data := fieldpack.Pack([]byte(utils.RandStr(100)), mod)
+	assignParams := poseidon2Assign{Data: data, Hash: poseidon2hash.Poseidon2Hash(hashFunc, data)}
+	var pc poseidon2Circuit
+	if _, err := wrapper.Groth16ZKP(&pc, curveName, len(data), assignParams); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wrapper.PlonkZKP(&pc, curveName, len(data), assignParams); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// poseidon2Circuit 在电路中依次吸收 Data 中的元素，断言哈希等于公开的 Hash
+type poseidon2Circuit struct {
+	Data []frontend.Variable
+	Hash frontend.Variable `gnark:",public"`
+}
+
+// poseidon2Assign 是 poseidon2Circuit 的赋值参数，Data 为 fieldpack.Pack 的输出
+type poseidon2Assign struct {
+	Data [][]byte
+	Hash []byte
+}
+
+func (c *poseidon2Circuit) Define(api frontend.API) error {
+	h, err := poseidon2hash.NewHasher(api)
+	if err != nil {
+		return err
+	}
+	h.Write(c.Data...)
+	api.AssertIsEqual(h.Sum(), c.Hash)
+	return nil
+}
+
+// PreCompile 按元素个数确定电路形状
+func (c *poseidon2Circuit) PreCompile(n int) error {
+	c.Data = make([]frontend.Variable, n)
+	return nil
+}
+
+func (c *poseidon2Circuit) Assign(params poseidon2Assign) error {
+	c.Data = make([]frontend.Variable, len(params.Data))
+	for i := range params.Data {
+		c.Data[i] = params.Data[i]
+	}
+	c.Hash = params.Hash
+	return nil
+}
+
+// checkPoseidon2 在测试引擎中比较 input 的原生哈希与电路中的哈希
+func checkPoseidon2(curveName string, input []byte) error {
+	c := poseidon2hash.Poseidon2CaseMap[curveName]
+	mod := c.Curve.ScalarField()
+	data := fieldpack.Pack(input, mod)
+	var circuit, assignment poseidon2Circuit
+	circuit.PreCompile(len(data))
+	if err := assignment.Assign(poseidon2Assign{Data: data, Hash: poseidon2hash.Poseidon2Hash(c.Hash, data)}); err != nil {
+		return err
+	}
+	return test.IsSolved(&circuit, &assignment, mod)
+}
+
+// FuzzPoseidon2Hash 检查任意输入在每条曲线上的原生哈希与电路中的哈希一致，不一致时报告缩小后的反例
+func FuzzPoseidon2Hash(f *testing.F) {
+	for _, seed := range []string{"", "a", "\x00a", strings.Repeat("\x00", 32), strings.Repeat("\xff", 64), strings.Repeat("helloworld", 10)} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, input []byte) {
+		if len(input) > 512 {
+			t.Skip("input too long")
+		}
+		for _, curveName := range utils.CurveNameList {
+			if err := checkPoseidon2(curveName, input); err != nil {
+				reproducer := utils.MinimizeBytes(input, func(b []byte) bool { return checkPoseidon2(curveName, b) != nil })
+				t.Fatalf("poseidon2 mismatch on %s: %v\nminimised reproducer: %q", curveName, err, reproducer)
+			}
+		}
+	})
 }
@@ -1,9 +1,19 @@
 package shahash
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
 	"testing"
 
 	"github.com/oliverustc/gnarkabc/logger"
+	"github.com/oliverustc/gnarkabc/utils"
+
+	"github.com/consensys/gnark/frontend"
+	zkhash "github.com/consensys/gnark/std/hash"
+	"github.com/consensys/gnark/std/hash/sha2"
+	"github.com/consensys/gnark/std/math/uints"
+	"github.com/consensys/gnark/test"
 )
 
 func TestCalcSha256(t *testing.T) {
@@ -25,3 +35,79 @@ func TestCalcSha3(t *testing.T) {
 		logger.Info("hashLen: %v", hashLen)
 	}
 }
+
+// shaCircuit 在电路中计算 PreImage 的哈希，断言其等于 Hash
+type shaCircuit struct {
+	PreImage []uints.U8
+	Hash     []uints.U8
+	hasher   string
+}
+
+func (c *shaCircuit) Define(api frontend.API) error {
+	var (
+		h   zkhash.BinaryHasher
+		err error
+	)
+	if c.hasher == "SHA-256" {
+		h, err = sha2.New(api)
+	} else {
+		h, err = HashCaseMap[c.hasher].ZK(api)
+	}
+	if err != nil {
+		return err
+	}
+	uapi, err := uints.New[uints.U32](api)
+	if err != nil {
+		return err
+	}
+	h.Write(c.PreImage)
+	res := h.Sum()
+	if len(res) != len(c.Hash) {
+		return fmt.Errorf("digest length %d, expected %d", len(res), len(c.Hash))
+	}
+	for i := range res {
+		uapi.ByteAssertEq(res[i], c.Hash[i])
+	}
+	return nil
+}
+
+// checkSha 在测试引擎中比较 input 的原生哈希与电路中的哈希
+func checkSha(curveName, hasher string, input []byte) error {
+	var digest []byte
+	if hasher == "SHA-256" {
+		sum := sha256.Sum256(input)
+		digest = sum[:]
+	} else {
+		h := HashCaseMap[hasher].Native()
+		h.Write(input)
+		digest = h.Sum(nil)
+	}
+	circuit := &shaCircuit{PreImage: make([]uints.U8, len(input)), Hash: make([]uints.U8, len(digest)), hasher: hasher}
+	assignment := &shaCircuit{PreImage: uints.NewU8Array(input), Hash: uints.NewU8Array(digest)}
+	return test.IsSolved(circuit, assignment, Sha3ScalarFieldMap[curveName])
+}
+
+// FuzzSha 检查任意输入的 SHA-256、SHA3 和 Keccak 原生哈希与电路中的哈希一致，不一致时报告缩小后的反例。
+// 按位运算的哈希只通过标量域依赖曲线，电路求解代价较高，因此每个输入按长度轮流选取一条曲线
+func FuzzSha(f *testing.F) {
+	// 空输入、单块以及跨越 SHA-256 (64 字节) 和 Keccak-256 (136 字节) 块边界的输入
+	for _, seed := range [][]byte{nil, []byte("a"), bytes.Repeat([]byte{0}, 56), bytes.Repeat([]byte{0xff}, 64), bytes.Repeat([]byte("helloworld"), 14)} {
+		f.Add(seed)
+	}
+	hashers := []string{"SHA-256"}
+	for name := range HashCaseMap {
+		hashers = append(hashers, name)
+	}
+	f.Fuzz(func(t *testing.T, input []byte) {
+		if len(input) > 256 {
+			t.Skip("input too long")
+		}
+		curveName := utils.ShaCurveNameList[len(input)%len(utils.ShaCurveNameList)]
+		for _, hasher := range hashers {
+			if err := checkSha(curveName, hasher, input); err != nil {
+				reproducer := utils.MinimizeBytes(input, func(b []byte) bool { return checkSha(curveName, hasher, b) != nil })
+				t.Fatalf("%s mismatch on %s: %v\nminimised reproducer: %q", hasher, curveName, err, reproducer)
+			}
+		}
+	})
+}
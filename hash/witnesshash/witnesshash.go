@@ -0,0 +1,205 @@
+// Package witnesshash 计算递归证明中内层公开输入的摘要，电路内外的结果相同。
+// 外层电路只公开这一个摘要而不是全部内层公开输入，验证者以 Digest 计算期望的摘要后与外层证明的公开输入比较
+package witnesshash
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+
+	"github.com/oliverustc/gnarkabc/hash/poseidon2hash"
+	"github.com/oliverustc/gnarkabc/utils"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	gchash "github.com/consensys/gnark-crypto/hash"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash"
+	"github.com/consensys/gnark/std/hash/mimc"
+	"github.com/consensys/gnark/std/hash/sha2"
+	"github.com/consensys/gnark/std/math/bits"
+	"github.com/consensys/gnark/std/math/emulated"
+	"github.com/consensys/gnark/std/math/uints"
+
+	// 导入MiMC哈希函数包以注册它们
+	_ "github.com/consensys/gnark-crypto/ecc/bls12-377/fr/mimc"
+	_ "github.com/consensys/gnark-crypto/ecc/bls12-381/fr/mimc"
+	_ "github.com/consensys/gnark-crypto/ecc/bls24-315/fr/mimc"
+	_ "github.com/consensys/gnark-crypto/ecc/bls24-317/fr/mimc"
+	_ "github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+	_ "github.com/consensys/gnark-crypto/ecc/bw6-633/fr/mimc"
+	_ "github.com/consensys/gnark-crypto/ecc/bw6-761/fr/mimc"
+)
+
+// Kind 是计算摘要使用的哈希函数
+type Kind int
+
+const (
+	MiMC      Kind = iota // 外层曲线标量域上的 MiMC
+	Poseidon2             // 外层曲线标量域上的 Poseidon2
+	SHA256                // SHA-256，结果截断为外层标量域中的元素
+)
+
+func (k Kind) String() string {
+	switch k {
+	case MiMC:
+		return "mimc"
+	case Poseidon2:
+		return "poseidon2"
+	case SHA256:
+		return "sha256"
+	default:
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+}
+
+// 外层曲线对应的原生 MiMC 和 Poseidon2，与 std/hash 中的电路实现一致
+var (
+	mimcHashes = map[ecc.ID]gchash.Hash{
+		ecc.BN254:     gchash.MIMC_BN254,
+		ecc.BLS12_377: gchash.MIMC_BLS12_377,
+		ecc.BLS12_381: gchash.MIMC_BLS12_381,
+		ecc.BLS24_315: gchash.MIMC_BLS24_315,
+		ecc.BLS24_317: gchash.MIMC_BLS24_317,
+		ecc.BW6_633:   gchash.MIMC_BW6_633,
+		ecc.BW6_761:   gchash.MIMC_BW6_761,
+	}
+	poseidon2Hashes = map[ecc.ID]gchash.Hash{
+		ecc.BN254:     gchash.POSEIDON2_BN254,
+		ecc.BLS12_377: gchash.POSEIDON2_BLS12_377,
+		ecc.BLS12_381: gchash.POSEIDON2_BLS12_381,
+		ecc.BLS24_315: gchash.POSEIDON2_BLS24_315,
+		ecc.BLS24_317: gchash.POSEIDON2_BLS24_317,
+		ecc.BW6_633:   gchash.POSEIDON2_BW6_633,
+		ecc.BW6_761:   gchash.POSEIDON2_BW6_761,
+	}
+)
+
+// chunkBits 是外层标量域中一个元素能无损容纳的位数
+func chunkBits(outer *big.Int) int {
+	return outer.BitLen() - 1
+}
+
+// checkCurve 检查曲线是否在 utils.CurveMap 中，未知曲线的 ecc.ID.String 会 panic
+func checkCurve(curve ecc.ID) error {
+	if _, ok := utils.CurveName(curve); !ok {
+		return fmt.Errorf("%w: input digest on curve id %d is not supported", utils.ErrUnsupportedCurve, int(curve))
+	}
+	return nil
+}
+
+// Digest 在电路外计算内层公开输入的摘要，inputs 为 inner 标量域中的元素，结果为 outer 标量域中的元素。
+// 每个输入取规范表示：MiMC 和 Poseidon2 将其从低位起按外层标量域位数减 1 分块后依次吸收；
+// SHA-256 对各输入按内层标量域字节数定长大端编码后的拼接计算哈希，结果按大端解释，外层标量域不足 256 位时保留低位
+func Digest(kind Kind, outer, inner ecc.ID, inputs []*big.Int) (*big.Int, error) {
+	if err := checkCurve(outer); err != nil {
+		return nil, err
+	}
+	if err := checkCurve(inner); err != nil {
+		return nil, err
+	}
+	innerField, outerField := inner.ScalarField(), outer.ScalarField()
+	for i, input := range inputs {
+		if input.Sign() < 0 || input.Cmp(innerField) >= 0 {
+			return nil, fmt.Errorf("input %d is not in the %s scalar field", i, inner.String())
+		}
+	}
+	chunk := chunkBits(outerField)
+	mask := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), uint(chunk)), big.NewInt(1))
+	switch kind {
+	case MiMC, Poseidon2:
+		h := mimcHashes[outer].New()
+		if kind == Poseidon2 {
+			h = poseidon2Hashes[outer].New()
+		}
+		buf := make([]byte, (outerField.BitLen()+7)/8)
+		for _, input := range inputs {
+			for lo := 0; lo < innerField.BitLen(); lo += chunk {
+				new(big.Int).And(new(big.Int).Rsh(input, uint(lo)), mask).FillBytes(buf)
+				h.Write(buf)
+			}
+		}
+		return new(big.Int).SetBytes(h.Sum(nil)), nil
+	case SHA256:
+		h := sha256.New()
+		buf := make([]byte, (innerField.BitLen()+7)/8)
+		for _, input := range inputs {
+			input.FillBytes(buf)
+			h.Write(buf)
+		}
+		return new(big.Int).And(new(big.Int).SetBytes(h.Sum(nil)), mask), nil
+	default:
+		return nil, fmt.Errorf("unknown hash kind: %s", kind.String())
+	}
+}
+
+// DigestWitness 读取内层见证者的公开输入后计算摘要，w 可以是公开见证者或完整见证者
+func DigestWitness(kind Kind, outer, inner ecc.ID, w witness.Witness) (*big.Int, error) {
+	if err := checkCurve(inner); err != nil {
+		return nil, err
+	}
+	inputs, err := utils.PublicInputs(w, inner.ScalarField())
+	if err != nil {
+		return nil, err
+	}
+	return Digest(kind, outer, inner, inputs)
+}
+
+// Define 在电路中计算以非原生算术表示的内层公开输入的摘要，与 Digest 相同
+func Define[FR emulated.FieldParams](api frontend.API, kind Kind, inputs []emulated.Element[FR]) (frontend.Variable, error) {
+	field, err := emulated.NewField[FR](api)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create field: %w", err)
+	}
+	chunk := chunkBits(api.Compiler().Field())
+	switch kind {
+	case MiMC, Poseidon2:
+		var h hash.FieldHasher
+		if kind == MiMC {
+			m, err := mimc.NewMiMC(api)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create mimc: %w", err)
+			}
+			h = &m
+		} else {
+			if h, err = poseidon2hash.NewHasher(api); err != nil {
+				return nil, err
+			}
+		}
+		for i := range inputs {
+			b := field.ToBitsCanonical(&inputs[i])
+			for lo := 0; lo < len(b); lo += chunk {
+				h.Write(bits.FromBinary(api, b[lo:min(lo+chunk, len(b))]))
+			}
+		}
+		return h.Sum(), nil
+	case SHA256:
+		h, err := sha2.New(api)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create sha256: %w", err)
+		}
+		uapi, err := uints.New[uints.U32](api)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create uints: %w", err)
+		}
+		var fr FR
+		nbBytes := (fr.Modulus().BitLen() + 7) / 8
+		for i := range inputs {
+			b := field.ToBitsCanonical(&inputs[i])
+			encoded := make([]uints.U8, nbBytes)
+			for j := range encoded {
+				lo := 8 * (nbBytes - 1 - j)
+				encoded[j] = uapi.ByteValueOf(bits.FromBinary(api, b[lo:min(lo+8, len(b))]))
+			}
+			h.Write(encoded)
+		}
+		sum := h.Sum()
+		digest := make([]frontend.Variable, 0, 8*len(sum))
+		for j := len(sum) - 1; j >= 0; j-- {
+			digest = append(digest, api.ToBinary(sum[j].Val, 8)...)
+		}
+		return bits.FromBinary(api, digest[:min(chunk, len(digest))]), nil
+	default:
+		return nil, fmt.Errorf("unknown hash kind: %s", kind.String())
+	}
+}
@@ -0,0 +1,83 @@
+package witnesshash
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/oliverustc/gnarkabc/utils"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/math/emulated"
+	"github.com/consensys/gnark/std/math/emulated/emparams"
+	"github.com/consensys/gnark/test"
+)
+
+// digestCircuit 在电路中计算 Inputs 的摘要并与公开的 Digest 比较
+type digestCircuit[FR emulated.FieldParams] struct {
+	Inputs []emulated.Element[FR]
+	Digest frontend.Variable `gnark:",public"`
+
+	kind Kind `gnark:"-"`
+}
+
+func (c *digestCircuit[FR]) Define(api frontend.API) error {
+	digest, err := Define(api, c.kind, c.Inputs)
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(digest, c.Digest)
+	return nil
+}
+
+// checkDigest 检查 inner 标量域上的输入在 outer 电路中的摘要与 Digest 一致，并且错误的摘要不能满足电路
+func checkDigest[FR emulated.FieldParams](t *testing.T, outer, inner ecc.ID) {
+	field := inner.ScalarField()
+	inputs := []*big.Int{
+		big.NewInt(0),
+		big.NewInt(15),
+		new(big.Int).Sub(field, big.NewInt(1)),
+		new(big.Int).Rsh(field, 3),
+	}
+	for _, kind := range []Kind{MiMC, Poseidon2, SHA256} {
+		digest, err := Digest(kind, outer, inner, inputs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		circuit := &digestCircuit[FR]{Inputs: make([]emulated.Element[FR], len(inputs)), kind: kind}
+		assignment := &digestCircuit[FR]{Inputs: make([]emulated.Element[FR], len(inputs)), Digest: digest}
+		for i := range inputs {
+			assignment.Inputs[i] = emulated.ValueOf[FR](inputs[i])
+		}
+		if err := test.IsSolved(circuit, assignment, outer.ScalarField()); err != nil {
+			t.Fatalf("%s digest of %s inputs on %s: %v", kind, inner.String(), outer.String(), err)
+		}
+		assignment.Digest = new(big.Int).Add(digest, big.NewInt(1))
+		if err := test.IsSolved(circuit, assignment, outer.ScalarField()); err == nil {
+			t.Fatalf("%s digest of %s inputs on %s: expected unsolved circuit with a wrong digest", kind, inner.String(), outer.String())
+		}
+	}
+}
+
+func TestDigestSolved(t *testing.T) {
+	// 内层标量域小于、等于、大于外层标量域
+	checkDigest[emparams.BLS12377Fr](t, ecc.BW6_761, ecc.BLS12_377)
+	checkDigest[emparams.BN254Fr](t, ecc.BN254, ecc.BN254)
+	checkDigest[emparams.BW6761Fr](t, ecc.BN254, ecc.BW6_761)
+}
+
+func TestDigestErrors(t *testing.T) {
+	if _, err := Digest(MiMC, ecc.UNKNOWN, ecc.BN254, nil); !errors.Is(err, utils.ErrUnsupportedCurve) {
+		t.Fatalf("expected ErrUnsupportedCurve, got %v", err)
+	}
+	if _, err := Digest(MiMC, ecc.BN254, ecc.BN254, []*big.Int{ecc.BN254.ScalarField()}); err == nil {
+		t.Fatal("expected error for an input outside the inner field")
+	}
+	if _, err := Digest(Kind(-1), ecc.BN254, ecc.BN254, nil); err == nil {
+		t.Fatal("expected error for an unknown hash kind")
+	}
+	if _, err := DigestWitness(SHA256, ecc.BN254, ecc.BN254, nil); !errors.Is(err, utils.ErrMissingAssignment) {
+		t.Fatalf("expected ErrMissingAssignment, got %v", err)
+	}
+}
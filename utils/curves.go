@@ -1,6 +1,10 @@
 package utils
 
-import "github.com/consensys/gnark-crypto/ecc"
+import (
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+)
 
 // CurveMap 定义了曲线名称到曲线ID的映射关系
 var CurveMap = map[string]ecc.ID{
@@ -19,8 +23,37 @@ var ShaCurveNameList = []string{"BN254", "BLS12-377", "BLS12-381", "BLS24-315",
 
 var Groth16RecursionCurveList = []string{"BN254", "BLS12-377", "BW6-761"}
 
+// RecursionPairList 列出递归验证的内层和外层曲线，原生验证的 2-chain 之外，非原生算术的内层曲线以 BN254 为外层
+var RecursionPairList = [][2]string{
+	{"BN254", "BN254"},
+	{"BLS12-381", "BN254"},
+	{"BW6-761", "BN254"},
+	{"BLS12-377", "BW6-761"},
+	{"BLS24-315", "BW6-633"},
+}
+
 var PlonkRecursionMap = map[string]ecc.ID{
 	"BN254":     ecc.BN254,
 	"BLS12-377": ecc.BW6_761,
 	"BW6-761":   ecc.BN254,
 }
+
+// CurveName 返回曲线ID在 CurveMap 中对应的名称
+func CurveName(id ecc.ID) (string, bool) {
+	for name, curve := range CurveMap {
+		if curve == id {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// CurveOfField 返回标量域为 field 的曲线ID，用于从约束系统或见证者的域确定曲线
+func CurveOfField(field *big.Int) (ecc.ID, bool) {
+	for _, curve := range CurveMap {
+		if curve.ScalarField().Cmp(field) == 0 {
+			return curve, true
+		}
+	}
+	return ecc.UNKNOWN, false
+}
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+
+	bls12377cs "github.com/consensys/gnark/constraint/bls12-377"
+	bls12381cs "github.com/consensys/gnark/constraint/bls12-381"
+	bls24315cs "github.com/consensys/gnark/constraint/bls24-315"
+	bls24317cs "github.com/consensys/gnark/constraint/bls24-317"
+	bn254cs "github.com/consensys/gnark/constraint/bn254"
+	bw6633cs "github.com/consensys/gnark/constraint/bw6-633"
+	bw6761cs "github.com/consensys/gnark/constraint/bw6-761"
+)
+
+// 包装器各步骤返回的哨兵错误，调用方可以通过 errors.Is 判断失败原因
+var (
+	ErrUnsatisfiedConstraint = errors.New("constraint not satisfied") // 赋值不满足电路约束
+	ErrInvalidProof          = errors.New("invalid proof")            // 证明验证失败
+	ErrMissingSetup          = errors.New("missing setup")            // 缺少约束系统或密钥
+	ErrMissingAssignment     = errors.New("missing assignment")       // 缺少电路赋值
+	ErrUnsupportedCurve      = errors.New("unsupported curve")        // 当前曲线不支持该操作
+	ErrIO                    = errors.New("io failure")               // 读写参数失败
+	ErrSRSTooSmall           = errors.New("srs too small")            // SRS 规模小于电路所需
+	ErrCorruptedArtifact     = errors.New("corrupted artifact")       // 缓存的参数文件校验失败
+)
+
+// IOError 记录读写参数文件时的失败操作及路径
+type IOError struct {
+	Op   string // 操作名称，如 "write pk"
+	Path string // 文件路径
+	Err  error  // 底层错误
+}
+
+func (e *IOError) Error() string {
+	return fmt.Sprintf("%s %s: %v", e.Op, e.Path, e.Err)
+}
+
+// Unwrap 同时暴露 ErrIO 和底层错误，使 errors.Is(err, ErrIO) 成立
+func (e *IOError) Unwrap() []error {
+	return []error{ErrIO, e.Err}
+}
+
+// IsUnsatisfiedConstraint 判断 err 是否来自求解器发现的约束不满足，
+// 用于和见证者格式错误、密钥损坏等其他证明失败区分
+func IsUnsatisfiedConstraint(err error) bool {
+	return isError[*bn254cs.UnsatisfiedConstraintError](err) ||
+		isError[*bls12377cs.UnsatisfiedConstraintError](err) ||
+		isError[*bls12381cs.UnsatisfiedConstraintError](err) ||
+		isError[*bls24315cs.UnsatisfiedConstraintError](err) ||
+		isError[*bls24317cs.UnsatisfiedConstraintError](err) ||
+		isError[*bw6633cs.UnsatisfiedConstraintError](err) ||
+		isError[*bw6761cs.UnsatisfiedConstraintError](err)
+}
+
+func isError[E error](err error) bool {
+	var target E
+	return errors.As(err, &target)
+}
@@ -0,0 +1,19 @@
+package utils
+
+import (
+	"fmt"
+	"os/exec"
+
+	"github.com/oliverustc/gnarkabc/logger"
+)
+
+// RunCommand 执行外部命令，失败时返回包含命令输出的错误
+func RunCommand(cmd *exec.Cmd) error {
+	logger.Debug("running command: %s", cmd.String())
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("run %s: %w\n%s", cmd.String(), err, out)
+	}
+	logger.Debug("command success: %s", string(out))
+	return nil
+}
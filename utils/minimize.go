@@ -0,0 +1,28 @@
+package utils
+
+// MinimizeBytes 在 fails 保持为 true 的前提下缩小输入，用于报告模糊测试发现的反例：
+// 先从大到小删除连续的字节块，再逐个将字节置零，返回的输入不再能删除任何一块或将任何一个字节置零
+func MinimizeBytes(input []byte, fails func([]byte) bool) []byte {
+	res := append([]byte(nil), input...)
+	for n := len(res); n >= 1; n /= 2 {
+		for i := 0; i+n <= len(res); {
+			candidate := append(append([]byte(nil), res[:i]...), res[i+n:]...)
+			if fails(candidate) {
+				res = candidate
+			} else {
+				i += n
+			}
+		}
+	}
+	for i := range res {
+		if res[i] == 0 {
+			continue
+		}
+		candidate := append([]byte(nil), res...)
+		candidate[i] = 0
+		if fails(candidate) {
+			res = candidate
+		}
+	}
+	return res
+}
@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMinimizeBytes(t *testing.T) {
+	// 包含 "ab" 时失败，最小的反例为 "ab"
+	input := []byte("xxxxaxxxxxabxxxx")
+	fails := func(b []byte) bool { return bytes.Contains(b, []byte("ab")) }
+	if res := MinimizeBytes(input, fails); !bytes.Equal(res, []byte("ab")) {
+		t.Fatalf("expected %q, got %q", "ab", res)
+	}
+	if string(input) != "xxxxaxxxxxabxxxx" {
+		t.Fatal("input modified")
+	}
+	// 长度不小于 3 时失败，字节都被置零
+	res := MinimizeBytes([]byte("hello"), func(b []byte) bool { return len(b) >= 3 })
+	if !bytes.Equal(res, []byte{0, 0, 0}) {
+		t.Fatalf("expected three zero bytes, got %q", res)
+	}
+}
@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark/backend/witness"
+)
+
+// WitnessValues 从见证者的二进制编码中读取所有值，公开值在前、私有值在后，同时返回公开值的个数。
+// field 为见证者所在的标量域，编码的长度与 field 的元素大小不符时返回 ErrUnsupportedCurve
+func WitnessValues(w witness.Witness, field *big.Int) ([]*big.Int, int, error) {
+	if w == nil {
+		return nil, 0, fmt.Errorf("%w: witness is nil", ErrMissingAssignment)
+	}
+	data, err := w.MarshalBinary()
+	if err != nil {
+		return nil, 0, fmt.Errorf("marshal witness failed: %w", err)
+	}
+	// 二进制编码以公开值个数、私有值个数和元素个数三个 uint32 开头，之后是定长的元素
+	if len(data) < 12 {
+		return nil, 0, fmt.Errorf("%w: witness too short", ErrCorruptedArtifact)
+	}
+	nbPublic := int(binary.BigEndian.Uint32(data[:4]))
+	nbElements := int(binary.BigEndian.Uint32(data[8:12]))
+	size := (field.BitLen() + 7) / 8
+	data = data[12:]
+	if len(data) != nbElements*size || nbPublic > nbElements {
+		return nil, 0, fmt.Errorf("%w: witness is not over a %d-bit scalar field", ErrUnsupportedCurve, field.BitLen())
+	}
+	values := make([]*big.Int, nbElements)
+	for i := range values {
+		values[i] = new(big.Int).SetBytes(data[i*size : (i+1)*size])
+	}
+	return values, nbPublic, nil
+}
+
+// PublicInputs 返回见证者的公开输入，w 可以是公开见证者或完整见证者
+func PublicInputs(w witness.Witness, field *big.Int) ([]*big.Int, error) {
+	if w == nil {
+		return nil, fmt.Errorf("%w: witness is nil", ErrMissingAssignment)
+	}
+	public, err := w.Public()
+	if err != nil {
+		return nil, fmt.Errorf("get public witness failed: %w", err)
+	}
+	values, nbPublic, err := WitnessValues(public, field)
+	if err != nil {
+		return nil, err
+	}
+	return values[:nbPublic], nil
+}
@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+)
+
+type witnessCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+	Z frontend.Variable `gnark:",public"`
+}
+
+func (c *witnessCircuit) Define(api frontend.API) error {
+	return nil
+}
+
+func TestWitnessValues(t *testing.T) {
+	field := ecc.BN254.ScalarField()
+	w, err := frontend.NewWitness(&witnessCircuit{X: 1, Y: 2, Z: -1}, field)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, nbPublic, err := WitnessValues(w, field)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 公开值在前、私有值在后，负数按标量域取模
+	minusOne := new(big.Int).Sub(field, big.NewInt(1))
+	if nbPublic != 2 || len(values) != 3 || values[0].Int64() != 2 || values[1].Cmp(minusOne) != 0 || values[2].Int64() != 1 {
+		t.Fatalf("unexpected values %v, %d public", values, nbPublic)
+	}
+	inputs, err := PublicInputs(w, field)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) != 2 || inputs[0].Int64() != 2 {
+		t.Fatalf("unexpected public inputs %v", inputs)
+	}
+
+	if _, err := PublicInputs(w, ecc.BW6_761.ScalarField()); !errors.Is(err, ErrUnsupportedCurve) {
+		t.Fatalf("expected ErrUnsupportedCurve, got %v", err)
+	}
+	if _, _, err := WitnessValues(nil, field); !errors.Is(err, ErrMissingAssignment) {
+		t.Fatalf("expected ErrMissingAssignment, got %v", err)
+	}
+}
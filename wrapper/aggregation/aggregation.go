@@ -0,0 +1,261 @@
+// Package aggregation 以 Groth16 递归证明将任意数量的叶子证明聚合为一个根证明。
+// 每个节点验证 FanIn 个子证明，根证明唯一的公开输入是对全部叶子公开输入的 Merkle 式承诺，见 Commitment。
+// AggregateHeterogeneous 则在一个证明中验证来自不同电路的证明，验证密钥须在 AllowList 中
+package aggregation
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/oliverustc/gnarkabc/logger"
+	"github.com/oliverustc/gnarkabc/utils"
+	"github.com/oliverustc/gnarkabc/wrapper/cache"
+	"github.com/oliverustc/gnarkabc/wrapper/groth16wrapper"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_bls12381"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_bn254"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_bw6761"
+	"github.com/consensys/gnark/std/math/emulated"
+	recursion_groth16 "github.com/consensys/gnark/std/recursion/groth16"
+)
+
+// Config 聚合的参数
+type Config struct {
+	FanIn       int          // 每个节点聚合的子证明数，可为 2、4 或 8
+	Parallelism int          // 同一层同时证明的节点数，小于 1 时按 1 处理
+	Cache       *cache.Cache // 缓存各层节点电路的参数，为空时每次重新编译和设置
+}
+
+// Leaf 是待聚合的叶子证明，所有叶子属于同一个电路
+type Leaf struct {
+	Proof   groth16.Proof
+	Witness witness.Witness // 叶子证明的公开见证者，也可以是完整见证者
+}
+
+// Result 是聚合的结果
+type Result struct {
+	Root       *groth16wrapper.Groth16Wrapper // 根节点的包装器，包含约束系统、密钥、证明和公开见证者
+	Commitment *big.Int                       // 对全部叶子公开输入的承诺，即根证明唯一的公开输入
+	Depth      int                            // 聚合树的层数
+}
+
+// Aggregate 在叶子电路所在的曲线上逐层聚合叶子证明，支持 BN254、BLS12-381 和 BW6-761。
+// 叶子数不是 FanIn 的幂时，每层末尾的空位以该层最后一个证明填充，空位不计入承诺。
+// 节点电路以非原生算术验证同一曲线上的证明，叶子证明需要由 RecursionTarget 为同一曲线的包装器生成
+func Aggregate(leafCCS constraint.ConstraintSystem, leafVK groth16.VerifyingKey, leaves []Leaf, cfg Config) (*Result, error) {
+	return run(leafCCS, leafVK, leaves, cfg, nil)
+}
+
+// run 执行聚合，job 不为空时在 job 中记录检查点
+func run(leafCCS constraint.ConstraintSystem, leafVK groth16.VerifyingKey, leaves []Leaf, cfg Config, job *Job) (*Result, error) {
+	if leafVK == nil {
+		return nil, fmt.Errorf("%w: leaf verifying key is nil", utils.ErrMissingSetup)
+	}
+	curve := leafVK.CurveID()
+	if err := checkShape(curve, len(leaves), cfg.FanIn); err != nil {
+		return nil, err
+	}
+	if cfg.Parallelism < 1 {
+		cfg.Parallelism = 1
+	}
+	switch curve {
+	case ecc.BN254:
+		return aggregate[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](curve, leafCCS, leafVK, leaves, cfg, job)
+	case ecc.BLS12_381:
+		return aggregate[sw_bls12381.ScalarField, sw_bls12381.G1Affine, sw_bls12381.G2Affine, sw_bls12381.GTEl](curve, leafCCS, leafVK, leaves, cfg, job)
+	case ecc.BW6_761:
+		return aggregate[sw_bw6761.ScalarField, sw_bw6761.G1Affine, sw_bw6761.G2Affine, sw_bw6761.GTEl](curve, leafCCS, leafVK, leaves, cfg, job)
+	default:
+		return nil, fmt.Errorf("%w: aggregation on %s is not supported", utils.ErrUnsupportedCurve, curve.String())
+	}
+}
+
+// child 是一个待验证的子证明及其摘要
+type child struct {
+	proof   groth16.Proof
+	witness witness.Witness
+	digest  *big.Int
+}
+
+func aggregate[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](curve ecc.ID, leafCCS constraint.ConstraintSystem, leafVK groth16.VerifyingKey, leaves []Leaf, cfg Config, job *Job) (*Result, error) {
+	nbInputs := len(recursion_groth16.PlaceholderWitness[FR](leafCCS).Public)
+	children := make([]child, len(leaves))
+	for i, leaf := range leaves {
+		if leaf.Proof == nil {
+			return nil, fmt.Errorf("%w: leaf %d proof is nil", utils.ErrMissingSetup, i)
+		}
+		inputs, err := utils.PublicInputs(leaf.Witness, curve.ScalarField())
+		if err != nil {
+			return nil, fmt.Errorf("leaf %d: %w", i, err)
+		}
+		if len(inputs) != nbInputs {
+			return nil, fmt.Errorf("leaf %d has %d public inputs, expected %d", i, len(inputs), nbInputs)
+		}
+		children[i] = child{proof: leaf.Proof, witness: leaf.Witness, digest: leafDigest(curve, inputs)}
+	}
+	if err := job.open(curve, cfg.FanIn, leafCCS, leafVK, children); err != nil {
+		return nil, err
+	}
+
+	depth := Depth(len(leaves), cfg.FanIn)
+	childCCS, childVK := leafCCS, leafVK
+	var nodes []*groth16wrapper.Groth16Wrapper
+	for level := 1; level <= depth; level++ {
+		zk, err := setupLevel[FR, G1El, G2El, GtEl](curve, childCCS, childVK, level == 1, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("setup level %d: %w", level, err)
+		}
+		parents := make([]child, (len(children)+cfg.FanIn-1)/cfg.FanIn)
+		nodes = make([]*groth16wrapper.Groth16Wrapper, len(parents))
+		if err := job.level(level, zk, len(parents)); err != nil {
+			return nil, err
+		}
+		logger.Info("aggregating level %d: %d proofs into %d nodes", level, len(children), len(parents))
+		err = forEach(len(parents), cfg.Parallelism, func(i int) error {
+			group := children[i*cfg.FanIn : min((i+1)*cfg.FanIn, len(children))]
+			digest := nodeDigest(curve, digestsOf(group), cfg.FanIn)
+			node, err := resumeNode(job, level, i, zk, digest)
+			if err != nil {
+				return fmt.Errorf("level %d node %d: %w", level, i, err)
+			}
+			if node == nil {
+				if err := job.begin(level, i); err != nil {
+					return err
+				}
+				if node, err = proveNode[FR, G1El, G2El, GtEl](zk, group, cfg.FanIn); err != nil {
+					return fmt.Errorf("level %d node %d: %w", level, i, err)
+				}
+				if err := job.finish(level, i, node); err != nil {
+					return fmt.Errorf("level %d node %d: %w", level, i, err)
+				}
+			}
+			parents[i] = child{proof: node.Proof, witness: node.WitnessPublic, digest: digest}
+			nodes[i] = node
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		children, childCCS, childVK = parents, zk.CCS, zk.VK
+	}
+	return &Result{Root: nodes[0], Commitment: children[0].digest, Depth: depth}, nil
+}
+
+// setupLevel 编译并设置一层的节点电路，配置了缓存时从缓存加载参数
+func setupLevel[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](curve ecc.ID, childCCS constraint.ConstraintSystem, childVK groth16.VerifyingKey, leaves bool, cfg Config) (*groth16wrapper.Groth16Wrapper, error) {
+	node, err := newNode[FR, G1El, G2El, GtEl](childCCS, childVK, cfg.FanIn, leaves)
+	if err != nil {
+		return nil, err
+	}
+	zk := groth16wrapper.NewWrapper(node, curve)
+	if cfg.Cache != nil {
+		if _, err := cfg.Cache.Setup(zk); err != nil {
+			return nil, err
+		}
+		return zk, nil
+	}
+	if err := zk.Compile(); err != nil {
+		return nil, err
+	}
+	if err := zk.Setup(); err != nil {
+		return nil, err
+	}
+	return zk, nil
+}
+
+// resumeNode 从 job 的检查点加载已完成的节点，节点的公开输入须为 digest，否则返回 nil 以重新证明
+func resumeNode(job *Job, level, i int, zk *groth16wrapper.Groth16Wrapper, digest *big.Int) (*groth16wrapper.Groth16Wrapper, error) {
+	node, err := job.load(level, i, zk)
+	if err != nil || node == nil {
+		return nil, err
+	}
+	got, err := digestOf(zk.Curve, node)
+	if err != nil {
+		return nil, err
+	}
+	if got.Cmp(digest) != 0 {
+		logger.Warn("level %d node %d has an unexpected digest, proving again", level, i)
+		return nil, nil
+	}
+	return node, nil
+}
+
+// proveNode 证明验证 group 中子证明的节点
+func proveNode[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](level *groth16wrapper.Groth16Wrapper, group []child, fanIn int) (*groth16wrapper.Groth16Wrapper, error) {
+	assignment, err := assignNode[FR, G1El, G2El, GtEl](level.Curve, group, fanIn)
+	if err != nil {
+		return nil, err
+	}
+	// 每个节点使用独立的包装器以便并行证明，约束系统和密钥在同一层内共享
+	zk := groth16wrapper.NewWrapper(level.Circuit, level.Curve)
+	zk.CCS, zk.PK, zk.VK = level.CCS, level.PK, level.VK
+	zk.SetAssignment(assignment)
+	// 节点证明由上一层在同一曲线上验证
+	zk.SetRecursionTarget(level.Curve)
+	if err := zk.Prove(); err != nil {
+		return nil, err
+	}
+	if err := zk.Verify(); err != nil {
+		return nil, err
+	}
+	return zk, nil
+}
+
+// assignNode 为节点电路赋值，group 不足 fanIn 时以最后一个子证明填充空位
+func assignNode[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](curve ecc.ID, group []child, fanIn int) (*Node[FR, G1El, G2El, GtEl], error) {
+	assignment := &Node[FR, G1El, G2El, GtEl]{
+		Proofs:    make([]recursion_groth16.Proof[G1El, G2El], fanIn),
+		Witnesses: make([]recursion_groth16.Witness[FR], fanIn),
+		Empty:     make([]frontend.Variable, fanIn),
+		Digest:    nodeDigest(curve, digestsOf(group), fanIn),
+	}
+	for i := range fanIn {
+		c := group[min(i, len(group)-1)]
+		var err error
+		if assignment.Proofs[i], err = recursion_groth16.ValueOfProof[G1El, G2El](c.proof); err != nil {
+			return nil, fmt.Errorf("failed to convert proof: %w", err)
+		}
+		if assignment.Witnesses[i], err = recursion_groth16.ValueOfWitness[FR](c.witness); err != nil {
+			return nil, fmt.Errorf("failed to convert witness: %w", err)
+		}
+		assignment.Empty[i] = 0
+		if i >= len(group) {
+			assignment.Empty[i] = 1
+		}
+	}
+	return assignment, nil
+}
+
+func digestsOf(group []child) []*big.Int {
+	digests := make([]*big.Int, len(group))
+	for i := range group {
+		digests[i] = group[i].digest
+	}
+	return digests
+}
+
+// forEach 以至多 parallelism 个协程对 0..n-1 调用 fn，返回所有错误
+func forEach(n, parallelism int, fn func(i int) error) error {
+	sem := make(chan struct{}, parallelism)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			errs[i] = fn(i)
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
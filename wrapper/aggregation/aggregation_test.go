@@ -0,0 +1,295 @@
+package aggregation
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/oliverustc/gnarkabc/circuits"
+	"github.com/oliverustc/gnarkabc/utils"
+	"github.com/oliverustc/gnarkabc/wrapper/groth16wrapper"
+	"github.com/oliverustc/gnarkabc/wrapper/store"
+	"github.com/oliverustc/gnarkabc/wrapper/testutil"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_bn254"
+	recursion_groth16 "github.com/consensys/gnark/std/recursion/groth16"
+	"github.com/consensys/gnark/test"
+)
+
+// proveLeaves 在 BN254 上生成 n 个乘积电路的叶子证明，第 i 个证明的公开输入为 (i+2)*(i+3)
+func proveLeaves(t *testing.T, n int) (*groth16wrapper.Groth16Wrapper, []Leaf) {
+	t.Helper()
+	var circuit circuits.Product
+	circuit.PreCompile(circuits.NoParams{})
+	zk := groth16wrapper.NewWrapper(&circuit, ecc.BN254)
+	if err := zk.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	if err := zk.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	zk.SetRecursionTarget(ecc.BN254)
+	leaves := make([]Leaf, n)
+	for i := range leaves {
+		if err := circuit.Assign(circuits.ProductAssign{P: i + 2, Q: i + 3}); err != nil {
+			t.Fatal(err)
+		}
+		zk.SetAssignment(&circuit)
+		if err := zk.Prove(); err != nil {
+			t.Fatal(err)
+		}
+		if err := zk.GenerateWitness(true); err != nil {
+			t.Fatal(err)
+		}
+		leaves[i] = Leaf{Proof: zk.Proof, Witness: zk.WitnessPublic}
+	}
+	return zk, leaves
+}
+
+func witnesses(leaves []Leaf) []witness.Witness {
+	ws := make([]witness.Witness, len(leaves))
+	for i := range leaves {
+		ws[i] = leaves[i].Witness
+	}
+	return ws
+}
+
+func TestDepth(t *testing.T) {
+	for _, tc := range []struct{ nbLeaves, fanIn, depth int }{
+		{1, 2, 1}, {2, 2, 1}, {3, 2, 2}, {4, 2, 2}, {5, 2, 3},
+		{4, 4, 1}, {5, 4, 2}, {16, 4, 2}, {17, 4, 3},
+		{8, 8, 1}, {9, 8, 2}, {64, 8, 2}, {1000, 8, 4},
+	} {
+		if depth := Depth(tc.nbLeaves, tc.fanIn); depth != tc.depth {
+			t.Fatalf("Depth(%d, %d) = %d, expected %d", tc.nbLeaves, tc.fanIn, depth, tc.depth)
+		}
+	}
+}
+
+func TestCommitment(t *testing.T) {
+	_, leaves := proveLeaves(t, 3)
+	ws := witnesses(leaves)
+	curve := ecc.BN254
+
+	digests := make([]*big.Int, len(ws))
+	for i, w := range ws {
+		var err error
+		if digests[i], err = LeafDigest(curve, w); err != nil {
+			t.Fatal(err)
+		}
+		expected := hashElements(curve, []*big.Int{big.NewInt(1), big.NewInt(int64((i + 2) * (i + 3)))})
+		if digests[i].Cmp(expected) != 0 {
+			t.Fatalf("leaf %d: unexpected digest", i)
+		}
+	}
+	zero := new(big.Int)
+	left := hashElements(curve, []*big.Int{digests[0], digests[1]})
+	right := hashElements(curve, []*big.Int{digests[2], zero})
+	expected := hashElements(curve, []*big.Int{left, right})
+	root, err := Commitment(curve, ws, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root.Cmp(expected) != 0 {
+		t.Fatal("unexpected commitment")
+	}
+
+	// 空位与显式的 0 摘要不同，叶子顺序和扇入都影响承诺
+	if wide, err := Commitment(curve, ws, 4); err != nil {
+		t.Fatal(err)
+	} else if wide.Cmp(hashElements(curve, []*big.Int{digests[0], digests[1], digests[2], zero})) != 0 {
+		t.Fatal("unexpected commitment with fan-in 4")
+	}
+	swapped, err := Commitment(curve, []witness.Witness{ws[1], ws[0], ws[2]}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if swapped.Cmp(root) == 0 {
+		t.Fatal("expected commitment to depend on leaf order")
+	}
+
+	if _, err := Commitment(curve, ws, 3); err == nil {
+		t.Fatal("expected error for fan-in 3")
+	}
+	if _, err := Commitment(curve, nil, 2); !errors.Is(err, utils.ErrMissingAssignment) {
+		t.Fatalf("expected ErrMissingAssignment, got %v", err)
+	}
+	if _, err := Commitment(ecc.BLS12_377, ws, 2); !errors.Is(err, utils.ErrUnsupportedCurve) {
+		t.Fatalf("expected ErrUnsupportedCurve, got %v", err)
+	}
+}
+
+// 在测试引擎中检查带空位的第一层节点电路，不进行设置和证明
+func TestNodeSolved(t *testing.T) {
+	zk, leaves := proveLeaves(t, 3)
+	curve := ecc.BN254
+	circuit, err := newNode[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](zk.CCS, zk.VK, 4, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	group := make([]child, len(leaves))
+	for i, leaf := range leaves {
+		inputs, err := utils.PublicInputs(leaf.Witness, curve.ScalarField())
+		if err != nil {
+			t.Fatal(err)
+		}
+		group[i] = child{proof: leaf.Proof, witness: leaf.Witness, digest: leafDigest(curve, inputs)}
+	}
+	assignment, err := assignNode[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](curve, group, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := test.IsSolved(circuit, assignment, curve.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+	root, err := Commitment(curve, witnesses(leaves), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root.Cmp(assignment.Digest.(*big.Int)) != 0 {
+		t.Fatal("node digest differs from the native commitment")
+	}
+
+	// 将有效的叶子标记为空位后摘要不再匹配
+	assignment.Empty[2] = 1
+	if err := test.IsSolved(circuit, assignment, curve.ScalarField()); err == nil {
+		t.Fatal("expected unsolved circuit when a leaf is marked empty")
+	}
+}
+
+func TestAggregateErrors(t *testing.T) {
+	zk, leaves := proveLeaves(t, 2)
+	if _, err := Aggregate(zk.CCS, zk.VK, leaves, Config{FanIn: 3}); err == nil {
+		t.Fatal("expected error for fan-in 3")
+	}
+	if _, err := Aggregate(zk.CCS, nil, leaves, Config{FanIn: 2}); !errors.Is(err, utils.ErrMissingSetup) {
+		t.Fatalf("expected ErrMissingSetup, got %v", err)
+	}
+	if _, err := Aggregate(zk.CCS, zk.VK, []Leaf{{Witness: leaves[0].Witness}}, Config{FanIn: 2}); !errors.Is(err, utils.ErrMissingSetup) {
+		t.Fatalf("expected ErrMissingSetup, got %v", err)
+	}
+}
+
+// 三个叶子按扇入 2 聚合为两层，第一层的第二个节点含一个空位
+func TestAggregateRecursion(t *testing.T) {
+	zk, leaves := proveLeaves(t, 3)
+	res, err := Aggregate(zk.CCS, zk.VK, leaves, Config{FanIn: 2, Parallelism: 2, Cache: testutil.ArtifactCache(t)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Depth != 2 {
+		t.Fatalf("expected depth 2, got %d", res.Depth)
+	}
+	root, err := Commitment(ecc.BN254, witnesses(leaves), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root.Cmp(res.Commitment) != 0 {
+		t.Fatal("root commitment differs from the native commitment")
+	}
+	inputs, err := utils.PublicInputs(res.Root.WitnessPublic, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) != 1 || inputs[0].Cmp(root) != 0 {
+		t.Fatal("root proof does not expose the commitment")
+	}
+	if err := res.Root.Verify(recursion_groth16.GetNativeVerifierOptions(ecc.BN254.ScalarField(), ecc.BN254.ScalarField())); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 不进行递归证明，以叶子电路代替节点电路检查任务清单的检查点、摘要校验和版本检查
+func TestJobCheckpoint(t *testing.T) {
+	zk, leaves := proveLeaves(t, 3)
+	curve := ecc.BN254
+	children := make([]child, len(leaves))
+	for i, leaf := range leaves {
+		inputs, err := utils.PublicInputs(leaf.Witness, curve.ScalarField())
+		if err != nil {
+			t.Fatal(err)
+		}
+		children[i] = child{proof: leaf.Proof, witness: leaf.Witness, digest: leafDigest(curve, inputs)}
+	}
+	s := store.NewMemory()
+	job := NewJob(s, Config{FanIn: 2})
+	if _, err := job.Run(zk.CCS, zk.VK, leaves); !errors.Is(err, utils.ErrMissingSetup) {
+		t.Fatalf("expected ErrMissingSetup without cache, got %v", err)
+	}
+	if err := job.open(curve, 2, zk.CCS, zk.VK, children); err != nil {
+		t.Fatal(err)
+	}
+	if err := job.level(1, zk, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := job.begin(1, 0); err != nil {
+		t.Fatal(err)
+	}
+	node := groth16wrapper.NewWrapper(zk.Circuit, curve)
+	node.Proof, node.WitnessPublic = leaves[0].Proof, leaves[0].Witness
+	if err := job.finish(1, 0, node); err != nil {
+		t.Fatal(err)
+	}
+	if err := job.begin(1, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重新打开任务后完成的节点可以加载，中断的节点需要重新证明
+	job = NewJob(s, Config{FanIn: 2})
+	if err := job.open(curve, 2, zk.CCS, zk.VK, children); err != nil {
+		t.Fatal(err)
+	}
+	if err := job.level(1, zk, 2); err != nil {
+		t.Fatal(err)
+	}
+	m, err := job.Manifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Levels[0].Nodes[0].State != NodeDone || m.Levels[0].Nodes[1].State != NodeProving {
+		t.Fatalf("unexpected node states %+v", m.Levels[0].Nodes)
+	}
+	loaded, err := job.load(1, 0, zk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded == nil {
+		t.Fatal("expected completed node to be loaded")
+	}
+	if err := loaded.Verify(recursion_groth16.GetNativeVerifierOptions(curve.ScalarField(), curve.ScalarField())); err != nil {
+		t.Fatal(err)
+	}
+	if loaded, err := job.load(1, 1, zk); err != nil || loaded != nil {
+		t.Fatalf("expected interrupted node to be proved again, got %v, %v", loaded, err)
+	}
+
+	// 产物与摘要不符时重新证明
+	var buf bytes.Buffer
+	if _, err := leaves[1].Proof.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := job.write(nodeFile(1, 0, "proof"), buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if loaded, err := job.load(1, 0, zk); err != nil || loaded != nil {
+		t.Fatalf("expected tampered node to be proved again, got %v, %v", loaded, err)
+	}
+
+	// 参数、叶子或电路版本不同时拒绝继续
+	if err := NewJob(s, Config{}).open(curve, 4, zk.CCS, zk.VK, children); !errors.Is(err, ErrJobMismatch) {
+		t.Fatalf("expected ErrJobMismatch for fan-in, got %v", err)
+	}
+	if err := NewJob(s, Config{}).open(curve, 2, zk.CCS, zk.VK, []child{children[1], children[0], children[2]}); !errors.Is(err, ErrJobMismatch) {
+		t.Fatalf("expected ErrJobMismatch for leaves, got %v", err)
+	}
+	other, _ := proveLeaves(t, 1)
+	if err := NewJob(s, Config{}).open(curve, 2, zk.CCS, other.VK, children); !errors.Is(err, ErrJobMismatch) {
+		t.Fatalf("expected ErrJobMismatch for leaf verifying key, got %v", err)
+	}
+	if err := job.level(1, other, 2); !errors.Is(err, ErrJobMismatch) {
+		t.Fatalf("expected ErrJobMismatch for level verifying key, got %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package aggregation
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/oliverustc/gnarkabc/utils"
+	"github.com/oliverustc/gnarkabc/wrapper/groth16wrapper"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+)
+
+// AllowList 是允许聚合的验证密钥列表，以验证密钥摘要为叶子构成深度为 Depth 的 MiMC Merkle 树。
+// 电路只依赖 Depth，同一深度下增删验证密钥只改变 Root，不需要重新编译和设置
+type AllowList struct {
+	Curve  ecc.ID
+	Depth  int
+	Leaves []*big.Int // 各验证密钥的摘要，见 groth16wrapper.CircuitVKHash
+	levels [][]*big.Int
+}
+
+// NewAllowList 创建允许列表，depth 为 0 时使用容纳全部验证密钥的最小深度
+func NewAllowList(curve ecc.ID, vks []groth16.VerifyingKey, depth int) (*AllowList, error) {
+	if _, ok := hashes[curve]; !ok {
+		return nil, fmt.Errorf("%w: allow-list on %s is not supported", utils.ErrUnsupportedCurve, curve.String())
+	}
+	if len(vks) == 0 {
+		return nil, fmt.Errorf("%w: allow-list is empty", utils.ErrMissingSetup)
+	}
+	if depth == 0 {
+		depth = 1
+		for 1<<depth < len(vks) {
+			depth++
+		}
+	}
+	if depth < 1 || depth > 32 || 1<<depth < len(vks) {
+		return nil, fmt.Errorf("allow-list of depth %d cannot hold %d verifying keys", depth, len(vks))
+	}
+	l := &AllowList{Curve: curve, Depth: depth, Leaves: make([]*big.Int, len(vks))}
+	for i, vk := range vks {
+		var err error
+		if l.Leaves[i], err = vkDigest(curve, vk); err != nil {
+			return nil, fmt.Errorf("verifying key %d: %w", i, err)
+		}
+	}
+	// 不足 2^depth 的叶子以 0 补齐
+	level := make([]*big.Int, 1<<depth)
+	for i := range level {
+		if i < len(l.Leaves) {
+			level[i] = l.Leaves[i]
+		} else {
+			level[i] = new(big.Int)
+		}
+	}
+	l.levels = [][]*big.Int{level}
+	for len(level) > 1 {
+		parents := make([]*big.Int, len(level)/2)
+		for i := range parents {
+			parents[i] = hashElements(curve, level[2*i:2*i+2])
+		}
+		l.levels = append(l.levels, parents)
+		level = parents
+	}
+	return l, nil
+}
+
+// Root 返回 Merkle 树的根
+func (l *AllowList) Root() *big.Int {
+	return l.levels[len(l.levels)-1][0]
+}
+
+// Path 返回第 i 个验证密钥到根的路径上各层的兄弟节点
+func (l *AllowList) Path(i int) []*big.Int {
+	path := make([]*big.Int, l.Depth)
+	for d := range path {
+		path[d] = l.levels[d][(i>>d)^1]
+	}
+	return path
+}
+
+// Index 返回验证密钥在列表中的位置，不在列表中时返回 -1
+func (l *AllowList) Index(vk groth16.VerifyingKey) (int, error) {
+	digest, err := vkDigest(l.Curve, vk)
+	if err != nil {
+		return -1, err
+	}
+	for i, leaf := range l.Leaves {
+		if leaf.Cmp(digest) == 0 {
+			return i, nil
+		}
+	}
+	return -1, nil
+}
+
+// vkDigest 计算 curve 上验证密钥的摘要
+func vkDigest(curve ecc.ID, vk groth16.VerifyingKey) (*big.Int, error) {
+	if vk != nil && vk.CurveID() != curve {
+		return nil, fmt.Errorf("%w: verifying key is on %s, expected %s", utils.ErrUnsupportedCurve, vk.CurveID().String(), curve.String())
+	}
+	return groth16wrapper.CircuitVKHash(vk)
+}
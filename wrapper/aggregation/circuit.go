@@ -0,0 +1,92 @@
+package aggregation
+
+import (
+	"fmt"
+
+	"github.com/oliverustc/gnarkabc/utils"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra"
+	"github.com/consensys/gnark/std/hash/mimc"
+	"github.com/consensys/gnark/std/math/bits"
+	"github.com/consensys/gnark/std/math/emulated"
+	recursion_groth16 "github.com/consensys/gnark/std/recursion/groth16"
+)
+
+// Node 是聚合树的节点电路，验证 FanIn 个子证明，并以子节点摘要的 MiMC 哈希作为唯一的公开输入。
+// 子证明为叶子时摘要为 MiMC(k, x_1, ..., x_k)，否则为子证明的公开输入 Digest；填充的空位摘要为 0
+type Node[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT] struct {
+	Proofs    []recursion_groth16.Proof[G1El, G2El]
+	Witnesses []recursion_groth16.Witness[FR]
+	Empty     []frontend.Variable // 空位为 1，空位上放置重复的有效证明
+	Digest    frontend.Variable   `gnark:",public"`
+
+	vk     recursion_groth16.VerifyingKey[G1El, G2El, GtEl] `gnark:"-"` // 子证明的验证密钥，作为常量编译进电路
+	leaves bool                                             `gnark:"-"` // 子证明是否为叶子
+}
+
+// newNode 创建验证 childCCS 电路证明的节点电路占位
+func newNode[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](childCCS constraint.ConstraintSystem, childVK groth16.VerifyingKey, fanIn int, leaves bool) (*Node[FR, G1El, G2El, GtEl], error) {
+	if childCCS == nil || childVK == nil {
+		return nil, fmt.Errorf("%w: child constraint system or verifying key is nil", utils.ErrMissingSetup)
+	}
+	vk, err := recursion_groth16.ValueOfVerifyingKeyFixed[G1El, G2El, GtEl](childVK)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert verifying key: %w", err)
+	}
+	node := &Node[FR, G1El, G2El, GtEl]{
+		Proofs:    make([]recursion_groth16.Proof[G1El, G2El], fanIn),
+		Witnesses: make([]recursion_groth16.Witness[FR], fanIn),
+		Empty:     make([]frontend.Variable, fanIn),
+		vk:        vk,
+		leaves:    leaves,
+	}
+	for i := range fanIn {
+		node.Proofs[i] = recursion_groth16.PlaceholderProof[G1El, G2El](childCCS)
+		node.Witnesses[i] = recursion_groth16.PlaceholderWitness[FR](childCCS)
+	}
+	return node, nil
+}
+
+func (n *Node[FR, G1El, G2El, GtEl]) Define(api frontend.API) error {
+	verifier, err := recursion_groth16.NewVerifier[FR, G1El, G2El, GtEl](api)
+	if err != nil {
+		return fmt.Errorf("failed to create verifier: %w", err)
+	}
+	field, err := emulated.NewField[FR](api)
+	if err != nil {
+		return fmt.Errorf("failed to create field: %w", err)
+	}
+	h, err := mimc.NewMiMC(api)
+	if err != nil {
+		return fmt.Errorf("failed to create mimc: %w", err)
+	}
+	digests := make([]frontend.Variable, len(n.Proofs))
+	for i := range n.Proofs {
+		if err := verifier.AssertProof(n.vk, n.Proofs[i], n.Witnesses[i]); err != nil {
+			return err
+		}
+		// 内层标量域与原生域相同，规范表示的比特可以直接组合为原生变量
+		inputs := make([]frontend.Variable, len(n.Witnesses[i].Public))
+		for j := range inputs {
+			inputs[j] = bits.FromBinary(api, field.ToBitsCanonical(&n.Witnesses[i].Public[j]))
+		}
+		var digest frontend.Variable
+		if n.leaves {
+			h.Reset()
+			h.Write(len(inputs))
+			h.Write(inputs...)
+			digest = h.Sum()
+		} else {
+			digest = inputs[0]
+		}
+		api.AssertIsBoolean(n.Empty[i])
+		digests[i] = api.Select(n.Empty[i], 0, digest)
+	}
+	h.Reset()
+	h.Write(digests...)
+	api.AssertIsEqual(h.Sum(), n.Digest)
+	return nil
+}
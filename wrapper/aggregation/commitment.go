@@ -0,0 +1,113 @@
+package aggregation
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/oliverustc/gnarkabc/utils"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	gchash "github.com/consensys/gnark-crypto/hash"
+	"github.com/consensys/gnark/backend/witness"
+
+	// 导入MiMC哈希函数包以注册它们
+	_ "github.com/consensys/gnark-crypto/ecc/bls12-381/fr/mimc"
+	_ "github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+	_ "github.com/consensys/gnark-crypto/ecc/bw6-761/fr/mimc"
+)
+
+// hashes 与节点电路中 MiMC 对应的原生哈希
+var hashes = map[ecc.ID]gchash.Hash{
+	ecc.BN254:     gchash.MIMC_BN254,
+	ecc.BLS12_381: gchash.MIMC_BLS12_381,
+	ecc.BW6_761:   gchash.MIMC_BW6_761,
+}
+
+// Depth 返回 nbLeaves 个叶子按 fanIn 聚合所需的层数，至少为 1
+func Depth(nbLeaves, fanIn int) int {
+	depth, width := 1, fanIn
+	for width < nbLeaves {
+		depth++
+		width *= fanIn
+	}
+	return depth
+}
+
+// Commitment 计算 Aggregate 的根证明对叶子公开见证者的承诺，与根证明的公开输入相同。
+// 叶子摘要为 MiMC(k, x_1, ..., x_k)，每个节点的摘要为 fanIn 个子节点摘要的 MiMC 哈希，不足 fanIn 的空位摘要为 0
+func Commitment(curve ecc.ID, leaves []witness.Witness, fanIn int) (*big.Int, error) {
+	if err := checkShape(curve, len(leaves), fanIn); err != nil {
+		return nil, err
+	}
+	digests := make([]*big.Int, len(leaves))
+	for i, leaf := range leaves {
+		var err error
+		if digests[i], err = LeafDigest(curve, leaf); err != nil {
+			return nil, fmt.Errorf("leaf %d: %w", i, err)
+		}
+	}
+	for range Depth(len(leaves), fanIn) {
+		parents := make([]*big.Int, (len(digests)+fanIn-1)/fanIn)
+		for i := range parents {
+			parents[i] = nodeDigest(curve, digests[i*fanIn:min((i+1)*fanIn, len(digests))], fanIn)
+		}
+		digests = parents
+	}
+	return digests[0], nil
+}
+
+// LeafDigest 计算叶子公开见证者的摘要 MiMC(k, x_1, ..., x_k)，k 为公开输入的个数
+func LeafDigest(curve ecc.ID, leaf witness.Witness) (*big.Int, error) {
+	if _, ok := hashes[curve]; !ok {
+		return nil, fmt.Errorf("%w: %s", utils.ErrUnsupportedCurve, curve.String())
+	}
+	inputs, err := utils.PublicInputs(leaf, curve.ScalarField())
+	if err != nil {
+		return nil, err
+	}
+	return leafDigest(curve, inputs), nil
+}
+
+func leafDigest(curve ecc.ID, inputs []*big.Int) *big.Int {
+	return hashElements(curve, append([]*big.Int{big.NewInt(int64(len(inputs)))}, inputs...))
+}
+
+// nodeDigest 计算节点的摘要，children 不足 fanIn 时以 0 补齐
+func nodeDigest(curve ecc.ID, children []*big.Int, fanIn int) *big.Int {
+	padded := make([]*big.Int, fanIn)
+	for i := range padded {
+		if i < len(children) {
+			padded[i] = children[i]
+		} else {
+			padded[i] = new(big.Int)
+		}
+	}
+	return hashElements(curve, padded)
+}
+
+func elementSize(curve ecc.ID) int {
+	return (curve.ScalarField().BitLen() + 7) / 8
+}
+
+func hashElements(curve ecc.ID, elements []*big.Int) *big.Int {
+	h := hashes[curve].New()
+	buf := make([]byte, elementSize(curve))
+	for _, e := range elements {
+		e.FillBytes(buf)
+		h.Write(buf)
+	}
+	return new(big.Int).SetBytes(h.Sum(nil))
+}
+
+func checkShape(curve ecc.ID, nbLeaves, fanIn int) error {
+	if _, ok := hashes[curve]; !ok {
+		return fmt.Errorf("%w: aggregation on %s is not supported", utils.ErrUnsupportedCurve, curve.String())
+	}
+	if fanIn != 2 && fanIn != 4 && fanIn != 8 {
+		return fmt.Errorf("fan-in must be 2, 4 or 8, got %d", fanIn)
+	}
+	if nbLeaves == 0 {
+		return fmt.Errorf("%w: no leaves to aggregate", utils.ErrMissingAssignment)
+	}
+	return nil
+}
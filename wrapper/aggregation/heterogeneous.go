@@ -0,0 +1,268 @@
+package aggregation
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/oliverustc/gnarkabc/utils"
+	"github.com/oliverustc/gnarkabc/wrapper/groth16wrapper"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_bls12381"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_bn254"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_bw6761"
+	"github.com/consensys/gnark/std/hash/mimc"
+	"github.com/consensys/gnark/std/math/bits"
+	"github.com/consensys/gnark/std/math/emulated"
+	recursion_groth16 "github.com/consensys/gnark/std/recursion/groth16"
+)
+
+// Slot 是异构聚合电路中的一个位置，验证密钥作为见证者输入并在允许列表中检查
+type Slot[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT] struct {
+	VK      recursion_groth16.VerifyingKey[G1El, G2El, GtEl]
+	Proof   recursion_groth16.Proof[G1El, G2El]
+	Witness recursion_groth16.Witness[FR]
+	Index   frontend.Variable   // 验证密钥在允许列表中的位置
+	Path    []frontend.Variable // 允许列表 Merkle 树中从叶子到根的兄弟节点
+	Empty   frontend.Variable   // 空位为 1，空位不检查允许列表，也不计入摘要
+}
+
+// Heterogeneous 验证至多 len(Slots) 个来自不同内层电路的证明，公开输入为允许列表的根和全部公开输入的摘要。
+// 非空位置 i 的摘要为 MiMC(h_i, k, x_1, ..., x_k)，h_i 为其验证密钥的摘要；空位的摘要为 0，Digest 为各位置摘要的 MiMC 哈希
+type Heterogeneous[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT] struct {
+	Slots  []Slot[FR, G1El, G2El, GtEl]
+	Root   frontend.Variable `gnark:",public"`
+	Digest frontend.Variable `gnark:",public"`
+}
+
+// newHeterogeneous 创建 slots 个位置、允许列表深度为 depth 的电路占位，内层电路的公开输入和承诺的个数与 shapeCCS 相同
+func newHeterogeneous[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](shapeCCS constraint.ConstraintSystem, slots, depth int) (*Heterogeneous[FR, G1El, G2El, GtEl], error) {
+	if shapeCCS == nil {
+		return nil, fmt.Errorf("%w: constraint system is nil", utils.ErrMissingSetup)
+	}
+	if slots < 1 {
+		return nil, fmt.Errorf("slot count must be positive, got %d", slots)
+	}
+	circuit := &Heterogeneous[FR, G1El, G2El, GtEl]{Slots: make([]Slot[FR, G1El, G2El, GtEl], slots)}
+	for i := range circuit.Slots {
+		circuit.Slots[i] = Slot[FR, G1El, G2El, GtEl]{
+			VK:      recursion_groth16.PlaceholderVerifyingKey[G1El, G2El, GtEl](shapeCCS),
+			Proof:   recursion_groth16.PlaceholderProof[G1El, G2El](shapeCCS),
+			Witness: recursion_groth16.PlaceholderWitness[FR](shapeCCS),
+			Path:    make([]frontend.Variable, depth),
+		}
+	}
+	return circuit, nil
+}
+
+func (c *Heterogeneous[FR, G1El, G2El, GtEl]) Define(api frontend.API) error {
+	verifier, err := recursion_groth16.NewVerifier[FR, G1El, G2El, GtEl](api)
+	if err != nil {
+		return fmt.Errorf("failed to create verifier: %w", err)
+	}
+	field, err := emulated.NewField[FR](api)
+	if err != nil {
+		return fmt.Errorf("failed to create field: %w", err)
+	}
+	h, err := mimc.NewMiMC(api)
+	if err != nil {
+		return fmt.Errorf("failed to create mimc: %w", err)
+	}
+	digests := make([]frontend.Variable, len(c.Slots))
+	for i := range c.Slots {
+		s := &c.Slots[i]
+		if err := verifier.AssertProof(s.VK, s.Proof, s.Witness); err != nil {
+			return err
+		}
+		api.AssertIsBoolean(s.Empty)
+
+		// 沿路径由验证密钥的摘要计算的根须等于允许列表的根
+		vkHash, err := groth16wrapper.HashVerifyingKey(api, &s.VK)
+		if err != nil {
+			return err
+		}
+		node := vkHash
+		index := api.ToBinary(s.Index, len(s.Path))
+		for d, sibling := range s.Path {
+			h.Reset()
+			h.Write(api.Select(index[d], sibling, node), api.Select(index[d], node, sibling))
+			node = h.Sum()
+		}
+		api.AssertIsEqual(api.Select(s.Empty, c.Root, node), c.Root)
+
+		inputs := make([]frontend.Variable, len(s.Witness.Public))
+		for j := range inputs {
+			inputs[j] = bits.FromBinary(api, field.ToBitsCanonical(&s.Witness.Public[j]))
+		}
+		h.Reset()
+		h.Write(vkHash, len(inputs))
+		h.Write(inputs...)
+		digests[i] = api.Select(s.Empty, 0, h.Sum())
+	}
+	h.Reset()
+	h.Write(digests...)
+	api.AssertIsEqual(h.Sum(), c.Digest)
+	return nil
+}
+
+// Inner 是异构聚合的一个内层证明
+type Inner struct {
+	VK      groth16.VerifyingKey
+	Proof   groth16.Proof
+	Witness witness.Witness // 内层证明的公开见证者，也可以是完整见证者
+}
+
+// HeterogeneousResult 是异构聚合的结果
+type HeterogeneousResult struct {
+	Proof  *groth16wrapper.Groth16Wrapper // 聚合电路的包装器，包含约束系统、密钥、证明和公开见证者
+	Root   *big.Int                       // 允许列表的根，即第一个公开输入
+	Digest *big.Int                       // 全部内层公开输入的摘要，即第二个公开输入，见 HeterogeneousDigest
+}
+
+// AggregateHeterogeneous 在一个证明中验证至多 slots 个来自不同内层电路的证明，支持 BN254、BLS12-381 和 BW6-761。
+// 内层验证密钥须在允许列表中，且各内层电路的公开输入和承诺的个数与 shapeCCS 相同；
+// 不足 slots 的位置为空位，以第一个内层证明填充。cfg 中只使用 Cache，内层证明需要由 RecursionTarget 为同一曲线的包装器生成
+func AggregateHeterogeneous(shapeCCS constraint.ConstraintSystem, list *AllowList, inners []Inner, slots int, cfg Config) (*HeterogeneousResult, error) {
+	if list == nil {
+		return nil, fmt.Errorf("%w: allow-list is nil", utils.ErrMissingSetup)
+	}
+	switch list.Curve {
+	case ecc.BN254:
+		return aggregateHeterogeneous[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](shapeCCS, list, inners, slots, cfg)
+	case ecc.BLS12_381:
+		return aggregateHeterogeneous[sw_bls12381.ScalarField, sw_bls12381.G1Affine, sw_bls12381.G2Affine, sw_bls12381.GTEl](shapeCCS, list, inners, slots, cfg)
+	case ecc.BW6_761:
+		return aggregateHeterogeneous[sw_bw6761.ScalarField, sw_bw6761.G1Affine, sw_bw6761.G2Affine, sw_bw6761.GTEl](shapeCCS, list, inners, slots, cfg)
+	default:
+		return nil, fmt.Errorf("%w: aggregation on %s is not supported", utils.ErrUnsupportedCurve, list.Curve.String())
+	}
+}
+
+func aggregateHeterogeneous[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](shapeCCS constraint.ConstraintSystem, list *AllowList, inners []Inner, slots int, cfg Config) (*HeterogeneousResult, error) {
+	circuit, err := newHeterogeneous[FR, G1El, G2El, GtEl](shapeCCS, slots, list.Depth)
+	if err != nil {
+		return nil, err
+	}
+	assignment, err := assignHeterogeneous[FR, G1El, G2El, GtEl](shapeCCS, list, inners, slots)
+	if err != nil {
+		return nil, err
+	}
+	zk := groth16wrapper.NewWrapper(circuit, list.Curve)
+	if cfg.Cache != nil {
+		if _, err := cfg.Cache.Setup(zk); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := zk.Compile(); err != nil {
+			return nil, err
+		}
+		if err := zk.Setup(); err != nil {
+			return nil, err
+		}
+	}
+	zk.SetAssignment(assignment)
+	zk.SetRecursionTarget(zk.Curve)
+	if err := zk.Prove(); err != nil {
+		return nil, err
+	}
+	if err := zk.Verify(); err != nil {
+		return nil, err
+	}
+	return &HeterogeneousResult{Proof: zk, Root: list.Root(), Digest: assignment.Digest.(*big.Int)}, nil
+}
+
+// assignHeterogeneous 为异构聚合电路赋值，检查内层验证密钥是否在允许列表中以及形状是否与 shapeCCS 一致
+func assignHeterogeneous[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](shapeCCS constraint.ConstraintSystem, list *AllowList, inners []Inner, slots int) (*Heterogeneous[FR, G1El, G2El, GtEl], error) {
+	if len(inners) == 0 {
+		return nil, fmt.Errorf("%w: no proofs to aggregate", utils.ErrMissingAssignment)
+	}
+	if len(inners) > slots {
+		return nil, fmt.Errorf("%d proofs do not fit in %d slots", len(inners), slots)
+	}
+	digest, err := HeterogeneousDigest(list.Curve, inners, slots)
+	if err != nil {
+		return nil, err
+	}
+	shape := recursion_groth16.PlaceholderVerifyingKey[G1El, G2El, GtEl](shapeCCS)
+	nbInputs := len(recursion_groth16.PlaceholderWitness[FR](shapeCCS).Public)
+	assignment := &Heterogeneous[FR, G1El, G2El, GtEl]{
+		Slots:  make([]Slot[FR, G1El, G2El, GtEl], slots),
+		Root:   list.Root(),
+		Digest: digest,
+	}
+	for i := range slots {
+		inner := inners[0]
+		if i < len(inners) {
+			inner = inners[i]
+		}
+		if inner.Proof == nil {
+			return nil, fmt.Errorf("%w: proof %d is nil", utils.ErrMissingSetup, i)
+		}
+		index, err := list.Index(inner.VK)
+		if err != nil {
+			return nil, fmt.Errorf("proof %d: %w", i, err)
+		}
+		if index < 0 {
+			return nil, fmt.Errorf("proof %d: verifying key is not in the allow-list", i)
+		}
+		s := &assignment.Slots[i]
+		if s.VK, err = recursion_groth16.ValueOfVerifyingKey[G1El, G2El, GtEl](inner.VK); err != nil {
+			return nil, fmt.Errorf("failed to convert verifying key: %w", err)
+		}
+		if len(s.VK.G1.K) != len(shape.G1.K) || !reflect.DeepEqual(s.VK.PublicAndCommitmentCommitted, shape.PublicAndCommitmentCommitted) {
+			return nil, fmt.Errorf("proof %d: verifying key does not match the shape of the aggregation circuit", i)
+		}
+		if s.Proof, err = recursion_groth16.ValueOfProof[G1El, G2El](inner.Proof); err != nil {
+			return nil, fmt.Errorf("failed to convert proof: %w", err)
+		}
+		if s.Witness, err = recursion_groth16.ValueOfWitness[FR](inner.Witness); err != nil {
+			return nil, fmt.Errorf("failed to convert witness: %w", err)
+		}
+		if len(s.Witness.Public) != nbInputs {
+			return nil, fmt.Errorf("proof %d has %d public inputs, expected %d", i, len(s.Witness.Public), nbInputs)
+		}
+		s.Index = index
+		s.Path = make([]frontend.Variable, list.Depth)
+		for d, sibling := range list.Path(index) {
+			s.Path[d] = sibling
+		}
+		s.Empty = 0
+		if i >= len(inners) {
+			s.Empty = 1
+		}
+	}
+	return assignment, nil
+}
+
+// HeterogeneousDigest 计算异构聚合对内层公开输入的摘要，与 AggregateHeterogeneous 证明的第二个公开输入相同
+func HeterogeneousDigest(curve ecc.ID, inners []Inner, slots int) (*big.Int, error) {
+	if _, ok := hashes[curve]; !ok {
+		return nil, fmt.Errorf("%w: aggregation on %s is not supported", utils.ErrUnsupportedCurve, curve.String())
+	}
+	if len(inners) > slots {
+		return nil, fmt.Errorf("%d proofs do not fit in %d slots", len(inners), slots)
+	}
+	digests := make([]*big.Int, slots)
+	for i := range digests {
+		if i >= len(inners) {
+			digests[i] = new(big.Int)
+			continue
+		}
+		digest, err := vkDigest(curve, inners[i].VK)
+		if err != nil {
+			return nil, fmt.Errorf("proof %d: %w", i, err)
+		}
+		inputs, err := utils.PublicInputs(inners[i].Witness, curve.ScalarField())
+		if err != nil {
+			return nil, fmt.Errorf("proof %d: %w", i, err)
+		}
+		digests[i] = hashElements(curve, append([]*big.Int{digest, big.NewInt(int64(len(inputs)))}, inputs...))
+	}
+	return hashElements(curve, digests), nil
+}
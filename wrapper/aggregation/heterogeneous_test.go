@@ -0,0 +1,188 @@
+package aggregation
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/oliverustc/gnarkabc/circuits"
+	"github.com/oliverustc/gnarkabc/utils"
+	"github.com/oliverustc/gnarkabc/wrapper/groth16wrapper"
+	"github.com/oliverustc/gnarkabc/wrapper/testutil"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	bn254mimc "github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_bn254"
+	"github.com/consensys/gnark/test"
+)
+
+// proveInner 在 BN254 上设置 circuit 并以 assignment 生成一个内层证明
+func proveInner(t *testing.T, circuit, assignment frontend.Circuit) (*groth16wrapper.Groth16Wrapper, Inner) {
+	t.Helper()
+	zk := groth16wrapper.NewWrapper(circuit, ecc.BN254)
+	if err := zk.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	if err := zk.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	zk.SetAssignment(assignment)
+	zk.SetRecursionTarget(ecc.BN254)
+	if err := zk.Prove(); err != nil {
+		t.Fatal(err)
+	}
+	if err := zk.GenerateWitness(true); err != nil {
+		t.Fatal(err)
+	}
+	return zk, Inner{VK: zk.VK, Proof: zk.Proof, Witness: zk.WitnessPublic}
+}
+
+// mimcInner 生成 MimcHash 电路的内层证明，与 Product 电路同样只有一个公开输入
+func mimcInner(t *testing.T) (*groth16wrapper.Groth16Wrapper, Inner) {
+	t.Helper()
+	var preImage fr.Element
+	preImage.SetUint64(42)
+	h := bn254mimc.NewMiMC()
+	h.Write(preImage.Marshal())
+	assignment := &circuits.MimcHash{PreImage: preImage.Marshal(), Hash: h.Sum(nil)}
+	return proveInner(t, &circuits.MimcHash{}, assignment)
+}
+
+func TestAllowList(t *testing.T) {
+	product, _ := proveInner(t, &circuits.Product{}, &circuits.Product{P: 3, Q: 5, N: 15})
+	hash, _ := mimcInner(t)
+	curve := ecc.BN254
+	list, err := NewAllowList(curve, []groth16.VerifyingKey{product.VK, hash.VK}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.Depth != 1 {
+		t.Fatalf("expected depth 1, got %d", list.Depth)
+	}
+	if list.Root().Cmp(hashElements(curve, list.Leaves)) != 0 {
+		t.Fatal("unexpected allow-list root")
+	}
+	if list.Leaves[0].Cmp(list.Leaves[1]) == 0 {
+		t.Fatal("expected different digests for different verifying keys")
+	}
+
+	// 更深的树以 0 补齐叶子，路径沿兄弟节点回到根
+	deep, err := NewAllowList(curve, []groth16.VerifyingKey{product.VK, hash.VK, product.VK}, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range deep.Leaves {
+		node := deep.Leaves[i]
+		for d, sibling := range deep.Path(i) {
+			if (i>>d)&1 == 0 {
+				node = hashElements(curve, []*big.Int{node, sibling})
+			} else {
+				node = hashElements(curve, []*big.Int{sibling, node})
+			}
+		}
+		if node.Cmp(deep.Root()) != 0 {
+			t.Fatalf("path of leaf %d does not lead to the root", i)
+		}
+	}
+	if i, err := list.Index(hash.VK); err != nil || i != 1 {
+		t.Fatalf("expected index 1, got %d, %v", i, err)
+	}
+
+	if _, err := NewAllowList(curve, []groth16.VerifyingKey{product.VK, hash.VK, product.VK}, 1); err == nil {
+		t.Fatal("expected error when the allow-list is too small")
+	}
+	if _, err := NewAllowList(curve, nil, 0); !errors.Is(err, utils.ErrMissingSetup) {
+		t.Fatalf("expected ErrMissingSetup, got %v", err)
+	}
+	if _, err := NewAllowList(ecc.BLS12_381, []groth16.VerifyingKey{product.VK}, 0); !errors.Is(err, utils.ErrUnsupportedCurve) {
+		t.Fatalf("expected ErrUnsupportedCurve, got %v", err)
+	}
+}
+
+// 在测试引擎中检查两个不同内层电路和一个空位的异构聚合电路，不进行设置和证明
+func TestHeterogeneousSolved(t *testing.T) {
+	product, productInner := proveInner(t, &circuits.Product{}, &circuits.Product{P: 3, Q: 5, N: 15})
+	hash, hashInner := mimcInner(t)
+	curve := ecc.BN254
+	list, err := NewAllowList(curve, []groth16.VerifyingKey{product.VK, hash.VK}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inners := []Inner{productInner, hashInner}
+	circuit, err := newHeterogeneous[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](product.CCS, 3, list.Depth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assignment, err := assignHeterogeneous[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](product.CCS, list, inners, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := test.IsSolved(circuit, assignment, curve.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+	digest, err := HeterogeneousDigest(curve, inners, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if digest.Cmp(assignment.Digest.(*big.Int)) != 0 {
+		t.Fatal("circuit digest differs from the native digest")
+	}
+
+	// 公开输入的摘要绑定了验证密钥，交换两个证明的位置后摘要不同
+	swapped, err := HeterogeneousDigest(curve, []Inner{hashInner, productInner}, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if swapped.Cmp(digest) == 0 {
+		t.Fatal("expected digest to depend on the slot order")
+	}
+
+	// 验证密钥不在允许列表中时拒绝赋值，电路中同样无法通过
+	other, err := NewAllowList(curve, []groth16.VerifyingKey{product.VK}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := assignHeterogeneous[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](product.CCS, other, inners, 3); err == nil {
+		t.Fatal("expected error for a verifying key outside the allow-list")
+	}
+	assignment.Root = other.Root()
+	if err := test.IsSolved(circuit, assignment, curve.ScalarField()); err == nil {
+		t.Fatal("expected unsolved circuit with a different allow-list root")
+	}
+
+	if _, err := assignHeterogeneous[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](product.CCS, list, inners, 1); err == nil {
+		t.Fatal("expected error when proofs exceed the slots")
+	}
+	if _, err := assignHeterogeneous[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](product.CCS, list, nil, 3); !errors.Is(err, utils.ErrMissingAssignment) {
+		t.Fatalf("expected ErrMissingAssignment, got %v", err)
+	}
+}
+
+// 两个不同内层电路在两个位置中的异构聚合
+func TestAggregateHeterogeneousRecursion(t *testing.T) {
+	product, productInner := proveInner(t, &circuits.Product{}, &circuits.Product{P: 3, Q: 5, N: 15})
+	hash, hashInner := mimcInner(t)
+	list, err := NewAllowList(ecc.BN254, []groth16.VerifyingKey{product.VK, hash.VK}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inners := []Inner{productInner, hashInner}
+	res, err := AggregateHeterogeneous(product.CCS, list, inners, 2, Config{Cache: testutil.ArtifactCache(t)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest, err := HeterogeneousDigest(ecc.BN254, inners, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inputs, err := utils.PublicInputs(res.Proof.WitnessPublic, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) != 2 || inputs[0].Cmp(list.Root()) != 0 || inputs[1].Cmp(digest) != 0 {
+		t.Fatal("aggregated proof does not expose the allow-list root and the digest")
+	}
+}
@@ -0,0 +1,364 @@
+package aggregation
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/big"
+	"sync"
+
+	"github.com/oliverustc/gnarkabc/logger"
+	"github.com/oliverustc/gnarkabc/utils"
+	"github.com/oliverustc/gnarkabc/wrapper/cache"
+	"github.com/oliverustc/gnarkabc/wrapper/groth16wrapper"
+	"github.com/oliverustc/gnarkabc/wrapper/store"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/constraint"
+)
+
+// 节点的状态
+const (
+	NodePending = "pending" // 尚未开始
+	NodeProving = "proving" // 正在证明，中断后重新证明
+	NodeDone    = "done"    // 证明和公开见证者已写入存储
+)
+
+const manifestFile = "manifest.json"
+
+// ErrJobMismatch 表示存储中的任务与本次聚合的曲线、扇入、电路版本或叶子不一致，拒绝混用两者的产物
+var ErrJobMismatch = errors.New("aggregation job mismatch")
+
+// Manifest 是聚合任务的清单，记录任务的参数和每个节点的状态
+type Manifest struct {
+	Curve       string   `json:"curve"`        // 曲线名称
+	FanIn       int      `json:"fan_in"`       // 扇入
+	LeafCircuit string   `json:"leaf_circuit"` // 叶子约束系统的摘要，与 cache.Key 相同
+	LeafVK      string   `json:"leaf_vk"`      // 叶子验证密钥的摘要
+	Leaves      []string `json:"leaves"`       // 各叶子公开输入的摘要，见 LeafDigest
+	Levels      []Level  `json:"levels"`       // 已开始的各层，第 i 项为第 i+1 层
+}
+
+// Level 记录一层节点电路的版本和各节点的状态
+type Level struct {
+	Circuit string      `json:"circuit"` // 节点约束系统的摘要，与 cache.Key 相同
+	VK      string      `json:"vk"`      // 节点验证密钥的摘要
+	Nodes   []NodeState `json:"nodes"`
+}
+
+// NodeState 是一个节点的状态
+type NodeState struct {
+	State  string `json:"state"`            // NodePending、NodeProving 或 NodeDone
+	Digest string `json:"digest,omitempty"` // 完成时证明与公开见证者编码的 sha256 摘要
+}
+
+// Job 是可以中断后继续的聚合任务，清单和每个完成节点的证明保存在 Store 中。
+// 重新运行时跳过摘要校验通过的已完成节点，从中断处继续证明
+type Job struct {
+	Store  store.ArtifactStore
+	Config Config // Cache 不能为空，否则重新运行时各层的密钥与已完成的证明不一致
+
+	mu       sync.Mutex
+	manifest *Manifest
+}
+
+// NewJob 创建保存在 s 中的聚合任务
+func NewJob(s store.ArtifactStore, cfg Config) *Job {
+	return &Job{Store: s, Config: cfg}
+}
+
+// Run 与 Aggregate 相同，但每完成一个节点都写入检查点。
+// 存储中已有清单时继续该任务，清单与本次的参数、电路版本或叶子不一致时返回 ErrJobMismatch
+func (j *Job) Run(leafCCS constraint.ConstraintSystem, leafVK groth16.VerifyingKey, leaves []Leaf) (*Result, error) {
+	if j.Store == nil {
+		return nil, fmt.Errorf("%w: job store is nil", utils.ErrMissingSetup)
+	}
+	if j.Config.Cache == nil {
+		return nil, fmt.Errorf("%w: resumable aggregation needs a parameter cache", utils.ErrMissingSetup)
+	}
+	return run(leafCCS, leafVK, leaves, j.Config, j)
+}
+
+// Manifest 返回任务清单的副本，任务尚未开始时读取存储中的清单，不存在时返回 nil
+func (j *Job) Manifest() (*Manifest, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	m := j.manifest
+	if m == nil {
+		var err error
+		if m, err = j.readManifest(); err != nil || m == nil {
+			return nil, err
+		}
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	var c Manifest
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// open 读取或创建任务清单，已有清单时检查曲线、扇入、叶子电路和叶子是否一致
+func (j *Job) open(curve ecc.ID, fanIn int, leafCCS constraint.ConstraintSystem, leafVK groth16.VerifyingKey, leaves []child) error {
+	if j == nil {
+		return nil
+	}
+	w := groth16wrapper.NewWrapper(nil, curve)
+	w.CCS = leafCCS
+	circuit, err := cache.Key(w)
+	if err != nil {
+		return err
+	}
+	vk, err := keyDigest(leafVK)
+	if err != nil {
+		return err
+	}
+	want := &Manifest{
+		Curve:       curve.String(),
+		FanIn:       fanIn,
+		LeafCircuit: circuit,
+		LeafVK:      vk,
+		Leaves:      make([]string, len(leaves)),
+	}
+	for i := range leaves {
+		want.Leaves[i] = leaves[i].digest.Text(16)
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	m, err := j.readManifest()
+	if err != nil {
+		return err
+	}
+	if m == nil {
+		logger.Info("starting aggregation job with %d leaves", len(leaves))
+		j.manifest = want
+		return j.saveManifest()
+	}
+	switch {
+	case m.Curve != want.Curve:
+		return fmt.Errorf("%w: job is on %s, got %s", ErrJobMismatch, m.Curve, want.Curve)
+	case m.FanIn != want.FanIn:
+		return fmt.Errorf("%w: job has fan-in %d, got %d", ErrJobMismatch, m.FanIn, want.FanIn)
+	case m.LeafCircuit != want.LeafCircuit || m.LeafVK != want.LeafVK:
+		return fmt.Errorf("%w: leaf circuit or verifying key changed", ErrJobMismatch)
+	case len(m.Leaves) != len(want.Leaves):
+		return fmt.Errorf("%w: job has %d leaves, got %d", ErrJobMismatch, len(m.Leaves), len(want.Leaves))
+	}
+	for i := range m.Leaves {
+		if m.Leaves[i] != want.Leaves[i] {
+			return fmt.Errorf("%w: leaf %d changed", ErrJobMismatch, i)
+		}
+	}
+	logger.Info("resuming aggregation job at level %d", len(m.Levels))
+	j.manifest = m
+	return nil
+}
+
+// level 记录或检查第 level 层节点电路的版本，该层有 nbNodes 个节点
+func (j *Job) level(level int, zk *groth16wrapper.Groth16Wrapper, nbNodes int) error {
+	if j == nil {
+		return nil
+	}
+	circuit, err := cache.Key(zk)
+	if err != nil {
+		return err
+	}
+	vk, err := keyDigest(zk.VK)
+	if err != nil {
+		return err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	m := j.manifest
+	if level <= len(m.Levels) {
+		l := m.Levels[level-1]
+		if l.Circuit != circuit || l.VK != vk {
+			return fmt.Errorf("%w: level %d circuit or verifying key changed", ErrJobMismatch, level)
+		}
+		if len(l.Nodes) != nbNodes {
+			return fmt.Errorf("%w: level %d has %d nodes, expected %d", ErrJobMismatch, level, len(l.Nodes), nbNodes)
+		}
+		return nil
+	}
+	nodes := make([]NodeState, nbNodes)
+	for i := range nodes {
+		nodes[i].State = NodePending
+	}
+	m.Levels = append(m.Levels, Level{Circuit: circuit, VK: vk, Nodes: nodes})
+	return j.saveManifest()
+}
+
+// load 读取已完成的节点，节点未完成或产物与摘要不符时返回 nil，后者需要重新证明
+func (j *Job) load(level, i int, zk *groth16wrapper.Groth16Wrapper) (*groth16wrapper.Groth16Wrapper, error) {
+	if j == nil {
+		return nil, nil
+	}
+	j.mu.Lock()
+	state := j.manifest.Levels[level-1].Nodes[i]
+	j.mu.Unlock()
+	switch state.State {
+	case NodeDone:
+	case NodeProving:
+		logger.Info("level %d node %d was interrupted, proving again", level, i)
+		return nil, nil
+	default:
+		return nil, nil
+	}
+	proof, err := j.read(nodeFile(level, i, "proof"))
+	if err != nil {
+		return nil, err
+	}
+	wit, err := j.read(nodeFile(level, i, "wit"))
+	if err != nil {
+		return nil, err
+	}
+	if proof == nil || wit == nil || nodeArtifactDigest(proof, wit) != state.Digest {
+		logger.Warn("level %d node %d does not match its digest, proving again", level, i)
+		return nil, nil
+	}
+	node := groth16wrapper.NewWrapper(zk.Circuit, zk.Curve)
+	node.CCS, node.PK, node.VK = zk.CCS, zk.PK, zk.VK
+	if err := node.UnmarshalProof(proof); err != nil {
+		return nil, fmt.Errorf("%w: level %d node %d proof: %v", utils.ErrCorruptedArtifact, level, i, err)
+	}
+	if err := node.UnmarshalWitness(wit, true); err != nil {
+		return nil, fmt.Errorf("%w: level %d node %d witness: %v", utils.ErrCorruptedArtifact, level, i, err)
+	}
+	logger.Debug("loaded level %d node %d from checkpoint", level, i)
+	return node, nil
+}
+
+// begin 将节点标记为正在证明
+func (j *Job) begin(level, i int) error {
+	if j == nil {
+		return nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.manifest.Levels[level-1].Nodes[i] = NodeState{State: NodeProving}
+	return j.saveManifest()
+}
+
+// finish 先写入节点的证明和公开见证者，再将节点标记为完成
+func (j *Job) finish(level, i int, node *groth16wrapper.Groth16Wrapper) error {
+	if j == nil {
+		return nil
+	}
+	var proof bytes.Buffer
+	if _, err := node.Proof.WriteTo(&proof); err != nil {
+		return err
+	}
+	wit, err := node.WitnessPublic.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	if err := j.write(nodeFile(level, i, "proof"), proof.Bytes()); err != nil {
+		return err
+	}
+	if err := j.write(nodeFile(level, i, "wit"), wit); err != nil {
+		return err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.manifest.Levels[level-1].Nodes[i] = NodeState{State: NodeDone, Digest: nodeArtifactDigest(proof.Bytes(), wit)}
+	return j.saveManifest()
+}
+
+// readManifest 读取存储中的清单，不存在时返回 nil
+func (j *Job) readManifest() (*Manifest, error) {
+	data, err := j.read(manifestFile)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("%w: job manifest: %v", utils.ErrCorruptedArtifact, err)
+	}
+	return &m, nil
+}
+
+// saveManifest 写入清单，调用方需持有 j.mu
+func (j *Job) saveManifest() error {
+	data, err := json.MarshalIndent(j.manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return j.write(manifestFile, data)
+}
+
+// read 读取存储中的产物，不存在时返回 nil
+func (j *Job) read(name string) ([]byte, error) {
+	file, err := j.Store.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, &utils.IOError{Op: "open job artifact", Path: name, Err: err}
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, &utils.IOError{Op: "read job artifact", Path: name, Err: err}
+	}
+	return data, nil
+}
+
+func (j *Job) write(name string, data []byte) error {
+	file, err := j.Store.Create(name)
+	if err != nil {
+		return &utils.IOError{Op: "create job artifact", Path: name, Err: err}
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return &utils.IOError{Op: "write job artifact", Path: name, Err: err}
+	}
+	if err := file.Close(); err != nil {
+		return &utils.IOError{Op: "close job artifact", Path: name, Err: err}
+	}
+	return nil
+}
+
+func nodeFile(level, i int, ext string) string {
+	return fmt.Sprintf("level_%d/node_%d.%s", level, i, ext)
+}
+
+// nodeArtifactDigest 计算节点证明与公开见证者编码的摘要
+func nodeArtifactDigest(proof, wit []byte) string {
+	h := sha256.New()
+	h.Write(proof)
+	h.Write(wit)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func keyDigest(vk groth16.VerifyingKey) (string, error) {
+	if vk == nil {
+		return "", fmt.Errorf("%w: verifying key is nil", utils.ErrMissingSetup)
+	}
+	h := sha256.New()
+	if _, err := vk.WriteTo(h); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// digestOf 返回节点的摘要，用于检查点中节点的公开输入
+func digestOf(curve ecc.ID, node *groth16wrapper.Groth16Wrapper) (*big.Int, error) {
+	inputs, err := utils.PublicInputs(node.WitnessPublic, curve.ScalarField())
+	if err != nil {
+		return nil, err
+	}
+	if len(inputs) != 1 {
+		return nil, fmt.Errorf("%w: node has %d public inputs", utils.ErrCorruptedArtifact, len(inputs))
+	}
+	return inputs[0], nil
+}
@@ -0,0 +1,240 @@
+package wrapper
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/oliverustc/gnarkabc/utils"
+)
+
+// BundleVersion 是当前证明包格式的版本号
+const BundleVersion = 1
+
+// bundleMagic 是二进制编码的文件头，用于区分二进制和 JSON 编码
+var bundleMagic = []byte("GNARKABC")
+
+// maxBundleField 限制二进制编码中单个字段的长度，避免损坏的长度前缀导致分配过大内存
+const maxBundleField = 1 << 30
+
+// Bundle 是自描述的证明包，包含验证所需的全部信息，验证者无需事先知道证明系统和曲线
+type Bundle struct {
+	Version       uint16   `json:"version"`                 // 格式版本
+	Scheme        string   `json:"scheme"`                  // 证明系统名称，见 SchemeList
+	Curve         string   `json:"curve"`                   // 曲线名称，见 utils.CurveMap
+	CircuitID     string   `json:"circuit_id"`              // 电路标识，由调用方约定
+	VKDigest      string   `json:"vk_digest"`               // 验证密钥的 sha256 摘要
+	VK            []byte   `json:"vk"`                      // 验证密钥
+	Proof         []byte   `json:"proof"`                   // 证明
+	PublicWitness []byte   `json:"public_witness"`          // 公开见证者
+	PublicInputs  []string `json:"public_inputs,omitempty"` // 可选的公开输入名称，顺序与公开见证者一致
+}
+
+// NewBundle 将已完成证明的包装器打包为证明包，publicInputs 为可选的公开输入名称
+func NewBundle(ps ProofSystem, circuitID string, publicInputs ...string) (*Bundle, error) {
+	curveName, ok := utils.CurveName(ps.CurveID())
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", utils.ErrUnsupportedCurve, ps.CurveID().String())
+	}
+	vk, err := decodeMarshaled(ps.MarshalVK())
+	if err != nil {
+		return nil, err
+	}
+	proof, err := decodeMarshaled(ps.MarshalProof())
+	if err != nil {
+		return nil, err
+	}
+	if err := ps.GenerateWitness(true); err != nil {
+		return nil, err
+	}
+	publicWitness, err := decodeMarshaled(ps.MarshalWitness(true))
+	if err != nil {
+		return nil, err
+	}
+	b := &Bundle{
+		Version:       BundleVersion,
+		Scheme:        ps.Scheme(),
+		Curve:         curveName,
+		CircuitID:     circuitID,
+		VKDigest:      VKDigest(vk),
+		VK:            vk,
+		Proof:         proof,
+		PublicWitness: publicWitness,
+		PublicInputs:  publicInputs,
+	}
+	if len(publicInputs) > 0 {
+		if err := b.checkSchema(); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// decodeMarshaled 将 Marshal* 返回的 base64 文本还原为原始字节
+func decodeMarshaled(data []byte, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(string(data))
+}
+
+// VKDigest 计算验证密钥的摘要
+func VKDigest(vk []byte) string {
+	sum := sha256.Sum256(vk)
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyBundle 仅根据证明包自身的内容完成验证：
+// 检查版本和摘要，按证明包记录的证明系统和曲线还原验证密钥、证明和公开见证者后验证
+func VerifyBundle(b *Bundle) error {
+	ps, err := b.load()
+	if err != nil {
+		return err
+	}
+	return ps.Verify()
+}
+
+// load 校验证明包并还原出可用于验证的包装器
+func (b *Bundle) load() (ProofSystem, error) {
+	if b.Version != BundleVersion {
+		return nil, fmt.Errorf("%w: unsupported bundle version %d", utils.ErrCorruptedArtifact, b.Version)
+	}
+	if got := VKDigest(b.VK); got != b.VKDigest {
+		return nil, fmt.Errorf("%w: vk digest mismatch, expected %s, got %s", utils.ErrCorruptedArtifact, b.VKDigest, got)
+	}
+	ps, err := New(b.Scheme, b.Curve, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := ps.UnmarshalVK(b.VK); err != nil {
+		return nil, fmt.Errorf("%w: vk: %w", utils.ErrCorruptedArtifact, err)
+	}
+	if err := ps.UnmarshalProof(b.Proof); err != nil {
+		return nil, fmt.Errorf("%w: proof: %w", utils.ErrCorruptedArtifact, err)
+	}
+	if err := ps.UnmarshalWitness(b.PublicWitness, true); err != nil {
+		return nil, fmt.Errorf("%w: public witness: %w", utils.ErrCorruptedArtifact, err)
+	}
+	if len(b.PublicInputs) > 0 {
+		if err := b.checkSchema(); err != nil {
+			return nil, err
+		}
+	}
+	return ps, nil
+}
+
+// witnessHeaderSize 是 gnark 见证者二进制编码的头部长度：公开变量数、私有变量数和元素数，各 4 字节
+const witnessHeaderSize = 12
+
+// checkSchema 检查公开输入名称的数量与公开见证者一致
+func (b *Bundle) checkSchema() error {
+	if len(b.PublicWitness) < witnessHeaderSize {
+		return fmt.Errorf("%w: public witness is truncated", utils.ErrCorruptedArtifact)
+	}
+	nbPublic := binary.BigEndian.Uint32(b.PublicWitness[:4])
+	if int(nbPublic) != len(b.PublicInputs) {
+		return fmt.Errorf("%w: %d public input names for %d public inputs", utils.ErrCorruptedArtifact, len(b.PublicInputs), nbPublic)
+	}
+	return nil
+}
+
+// NamedPublicInputs 按公开输入名称返回对应的值，证明包没有记录名称时返回 nil
+func (b *Bundle) NamedPublicInputs() (map[string]*big.Int, error) {
+	if len(b.PublicInputs) == 0 {
+		return nil, nil
+	}
+	if err := b.checkSchema(); err != nil {
+		return nil, err
+	}
+	elements := b.PublicWitness[witnessHeaderSize:]
+	if len(elements)%len(b.PublicInputs) != 0 {
+		return nil, fmt.Errorf("%w: malformed public witness", utils.ErrCorruptedArtifact)
+	}
+	size := len(elements) / len(b.PublicInputs)
+	values := make(map[string]*big.Int, len(b.PublicInputs))
+	for i, name := range b.PublicInputs {
+		values[name] = new(big.Int).SetBytes(elements[i*size : (i+1)*size])
+	}
+	return values, nil
+}
+
+// MarshalBinary 返回证明包的二进制编码：文件头、版本号、公开输入名称数量，其后各字段均为 4 字节大端长度前缀加内容
+func (b *Bundle) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Write(bundleMagic)
+	binary.Write(&buf, binary.BigEndian, b.Version)
+	fields := [][]byte{[]byte(b.Scheme), []byte(b.Curve), []byte(b.CircuitID), []byte(b.VKDigest), b.VK, b.Proof, b.PublicWitness}
+	for _, name := range b.PublicInputs {
+		fields = append(fields, []byte(name))
+	}
+	binary.Write(&buf, binary.BigEndian, uint32(len(b.PublicInputs)))
+	for _, field := range fields {
+		binary.Write(&buf, binary.BigEndian, uint32(len(field)))
+		buf.Write(field)
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary 解析 MarshalBinary 的输出
+func (b *Bundle) UnmarshalBinary(data []byte) error {
+	r := bytes.NewReader(data)
+	magic := make([]byte, len(bundleMagic))
+	if _, err := io.ReadFull(r, magic); err != nil || !bytes.Equal(magic, bundleMagic) {
+		return fmt.Errorf("%w: not a proof bundle", utils.ErrCorruptedArtifact)
+	}
+	var nbInputs uint32
+	if err := binary.Read(r, binary.BigEndian, &b.Version); err != nil {
+		return fmt.Errorf("%w: %w", utils.ErrCorruptedArtifact, err)
+	}
+	if err := binary.Read(r, binary.BigEndian, &nbInputs); err != nil {
+		return fmt.Errorf("%w: %w", utils.ErrCorruptedArtifact, err)
+	}
+	if int64(nbInputs) > int64(r.Len()) {
+		return fmt.Errorf("%w: too many public input names", utils.ErrCorruptedArtifact)
+	}
+	fields := make([][]byte, 7+int(nbInputs))
+	for i := range fields {
+		var n uint32
+		if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+			return fmt.Errorf("%w: %w", utils.ErrCorruptedArtifact, err)
+		}
+		if n > maxBundleField || int64(n) > int64(r.Len()) {
+			return fmt.Errorf("%w: field %d is truncated", utils.ErrCorruptedArtifact, i)
+		}
+		fields[i] = make([]byte, n)
+		if _, err := io.ReadFull(r, fields[i]); err != nil {
+			return fmt.Errorf("%w: %w", utils.ErrCorruptedArtifact, err)
+		}
+	}
+	if r.Len() != 0 {
+		return fmt.Errorf("%w: %d trailing bytes", utils.ErrCorruptedArtifact, r.Len())
+	}
+	b.Scheme, b.Curve, b.CircuitID, b.VKDigest = string(fields[0]), string(fields[1]), string(fields[2]), string(fields[3])
+	b.VK, b.Proof, b.PublicWitness = fields[4], fields[5], fields[6]
+	b.PublicInputs = nil
+	for _, name := range fields[7:] {
+		b.PublicInputs = append(b.PublicInputs, string(name))
+	}
+	return nil
+}
+
+// DecodeBundle 按文件头自动识别二进制或 JSON 编码并解析证明包
+func DecodeBundle(data []byte) (*Bundle, error) {
+	var b Bundle
+	if bytes.HasPrefix(data, bundleMagic) {
+		if err := b.UnmarshalBinary(data); err != nil {
+			return nil, err
+		}
+		return &b, nil
+	}
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("%w: %w", utils.ErrCorruptedArtifact, err)
+	}
+	return &b, nil
+}
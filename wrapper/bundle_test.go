@@ -0,0 +1,106 @@
+package wrapper
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/oliverustc/gnarkabc/circuits"
+	"github.com/oliverustc/gnarkabc/utils"
+)
+
+func TestBundle(t *testing.T) {
+	for _, scheme := range SchemeList {
+		for _, curveName := range []string{"BN254", "BLS12-381"} {
+			ps, err := ZKP(scheme, &circuits.Product{}, curveName, circuits.NoParams{}, circuits.ProductAssign{P: 5, Q: 7})
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := NewBundle(ps, "product", "N")
+			if err != nil {
+				t.Fatal(err)
+			}
+			values, err := b.NamedPublicInputs()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if values["N"].Int64() != 35 {
+				t.Fatalf("expected N = 35, got %s", values["N"])
+			}
+
+			// 二进制和 JSON 编码均可在没有任何上下文的情况下验证
+			bin, err := b.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			js, err := json.Marshal(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, data := range [][]byte{bin, js} {
+				decoded, err := DecodeBundle(data)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if decoded.Scheme != scheme || decoded.Curve != curveName || decoded.CircuitID != "product" {
+					t.Fatalf("unexpected bundle header %s %s %s", decoded.Scheme, decoded.Curve, decoded.CircuitID)
+				}
+				if err := VerifyBundle(decoded); err != nil {
+					t.Fatalf("%s on %s: %v", scheme, curveName, err)
+				}
+			}
+		}
+	}
+}
+
+func TestBundleRejected(t *testing.T) {
+	ps, err := ZKP(SchemeGroth16, &circuits.Product{}, "BN254", circuits.NoParams{}, circuits.ProductAssign{P: 5, Q: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewBundle(ps, "product", "N", "M"); !errors.Is(err, utils.ErrCorruptedArtifact) {
+		t.Fatalf("expected schema mismatch, got %v", err)
+	}
+	b, err := NewBundle(ps, "product")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bin, err := b.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 篡改公开输入后验证失败
+	tampered, err := DecodeBundle(bin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered.PublicWitness[len(tampered.PublicWitness)-1] ^= 1
+	if err := VerifyBundle(tampered); !errors.Is(err, utils.ErrInvalidProof) {
+		t.Fatalf("expected ErrInvalidProof, got %v", err)
+	}
+
+	// 替换验证密钥后摘要不匹配
+	other, err := ZKP(SchemeGroth16, &circuits.Product{}, "BN254", circuits.NoParams{}, circuits.ProductAssign{P: 5, Q: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ob, err := NewBundle(other, "product")
+	if err != nil {
+		t.Fatal(err)
+	}
+	swapped := *b
+	swapped.VK = ob.VK
+	if err := VerifyBundle(&swapped); !errors.Is(err, utils.ErrCorruptedArtifact) {
+		t.Fatalf("expected ErrCorruptedArtifact, got %v", err)
+	}
+
+	if _, err := DecodeBundle(bin[:len(bin)-1]); !errors.Is(err, utils.ErrCorruptedArtifact) {
+		t.Fatalf("expected ErrCorruptedArtifact for truncated bundle, got %v", err)
+	}
+	unknown := *b
+	unknown.Version = BundleVersion + 1
+	if err := VerifyBundle(&unknown); !errors.Is(err, utils.ErrCorruptedArtifact) {
+		t.Fatalf("expected ErrCorruptedArtifact for unknown version, got %v", err)
+	}
+}
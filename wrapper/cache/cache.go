@@ -0,0 +1,438 @@
+// Package cache 提供以约束系统摘要为键的参数缓存，
+// 电路改变后摘要随之改变，不会误用过期的 PK/VK
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/oliverustc/gnarkabc/logger"
+	"github.com/oliverustc/gnarkabc/utils"
+	"github.com/oliverustc/gnarkabc/wrapper/store"
+
+	"github.com/consensys/gnark-crypto/ecc"
+)
+
+const (
+	manifestFile = "manifest.json"
+	ccsFile      = "ccs"
+	pkFile       = "pk"
+	vkFile       = "vk"
+	tmpPrefix    = ".tmp-"
+	keySize      = 2 * sha256.Size // 键为 sha256 摘要的小写十六进制编码
+)
+
+// ErrInvalidKey 表示键不是 Key 生成的格式，拒绝这样的键以免删除或读取缓存目录之外的文件
+var ErrInvalidKey = errors.New("invalid cache key")
+
+// checkKey 检查键是否为 64 位小写十六进制字符串
+func checkKey(key string) error {
+	if !isKey(key) {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	return nil
+}
+
+func isKey(name string) bool {
+	if len(name) != keySize {
+		return false
+	}
+	for _, ch := range name {
+		if (ch < '0' || ch > '9') && (ch < 'a' || ch > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// Artifacts 是可被缓存的证明系统，Groth16Wrapper 和 PlonkWrapper 均满足该接口
+type Artifacts interface {
+	Scheme() string
+	CurveID() ecc.ID
+	Compile() error
+	Setup() error
+	GetConstraintNum() int
+	MarshalCCS() ([]byte, error)
+	SetStore(s store.ArtifactStore)
+	GetStore() store.ArtifactStore
+	WriteCCS(filePath string) error
+	ReadCCS(filePath string) error
+	WritePK(filePath string) error
+	ReadPK(filePath string) error
+	WriteVK(filePath string) error
+	ReadVK(filePath string) error
+}
+
+// srsSource 由依赖外部 SRS 的证明系统实现，PlonkWrapper 满足该接口。
+// SRSDigest 返回 nil 表示 Setup 自行生成仅供测试的参数
+type srsSource interface {
+	SRSDigest() ([]byte, error)
+}
+
+// Entry 描述缓存中的一组参数
+type Entry struct {
+	Key         string            `json:"key"`           // 约束系统、证明系统、曲线和 SRS 的摘要
+	SRS         string            `json:"srs,omitempty"` // 生成参数所用 SRS 的摘要，为空表示使用仅供测试的 SRS
+	Scheme      string            `json:"scheme"`        // 证明系统名称
+	Curve       string            `json:"curve"`         // 曲线名称
+	Created     time.Time         `json:"created"`       // 写入时间
+	Files       map[string]string `json:"files"`         // 文件名到 sha256 摘要的映射
+	Size        int64             `json:"size"`          // 参数文件总大小
+	Constraints int               `json:"constraints"`   // 约束数量
+}
+
+// Cache 是基于文件系统的参数缓存，每组参数存放在 Dir/<key> 目录下
+type Cache struct {
+	Dir string // 缓存根目录
+}
+
+// New 创建缓存，目录不存在时自动创建
+func New(dir string) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, &utils.IOError{Op: "create cache dir", Path: dir, Err: err}
+	}
+	return &Cache{Dir: dir}, nil
+}
+
+// Key 计算约束系统、证明系统和曲线的摘要，约束系统为空时先编译电路。
+// 包装器加载了 SRS 时摘要还包含 SRS 的摘要，使用生产 SRS 的包装器不会命中由 unsafekzg 生成的参数
+func Key(a Artifacts) (string, error) {
+	ccs, err := a.MarshalCCS()
+	if errors.Is(err, utils.ErrMissingSetup) {
+		if err = a.Compile(); err != nil {
+			return "", err
+		}
+		ccs, err = a.MarshalCCS()
+	}
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	h.Write([]byte(a.Scheme()))
+	h.Write([]byte{0})
+	h.Write([]byte(a.CurveID().String()))
+	h.Write([]byte{0})
+	h.Write(ccs)
+	srs, err := srsDigest(a)
+	if err != nil {
+		return "", err
+	}
+	if srs != nil {
+		h.Write([]byte{0})
+		h.Write(srs)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func srsDigest(a Artifacts) ([]byte, error) {
+	if s, ok := a.(srsSource); ok {
+		return s.SRSDigest()
+	}
+	return nil, nil
+}
+
+// Setup 从缓存加载 PK/VK，缓存缺失或损坏时执行 Setup 并写入缓存。
+// 约束系统为空时会先编译电路，返回值 hit 表示是否命中缓存。
+// c 为 nil 时不使用缓存，直接编译电路并执行 Setup
+func (c *Cache) Setup(a Artifacts) (hit bool, err error) {
+	if c == nil {
+		if err := a.Compile(); err != nil {
+			return false, err
+		}
+		return false, a.Setup()
+	}
+	key, err := Key(a)
+	if err != nil {
+		return false, err
+	}
+	err = c.load(key, a, false)
+	switch {
+	case err == nil:
+		logger.Debug("artifact cache hit: %s", key)
+		return true, nil
+	case errors.Is(err, utils.ErrCorruptedArtifact):
+		logger.Warn("artifact cache entry %s is corrupted, regenerating: %v", key, err)
+		if err := c.Remove(key); err != nil {
+			return false, err
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return false, err
+	}
+	logger.Debug("artifact cache miss: %s", key)
+	if err := a.Setup(); err != nil {
+		return false, err
+	}
+	return false, c.store(key, a)
+}
+
+// Load 按键加载约束系统和 PK/VK，读取前校验文件摘要
+func (c *Cache) Load(key string, a Artifacts) error {
+	return c.load(key, a, true)
+}
+
+// load 边读取边计算摘要，与清单一致后才交给包装器解析，文件只读取一次
+func (c *Cache) load(key string, a Artifacts, withCCS bool) error {
+	entry, err := c.manifest(key)
+	if err != nil {
+		return err
+	}
+	if entry.Scheme != a.Scheme() || entry.Curve != a.CurveID().String() {
+		return fmt.Errorf("artifact cache entry %s is for %s on %s, not %s on %s",
+			key, entry.Scheme, entry.Curve, a.Scheme(), a.CurveID().String())
+	}
+	names := []string{pkFile, vkFile}
+	if withCCS {
+		names = append([]string{ccsFile}, names...)
+	}
+	verified := store.NewMemory()
+	for _, name := range names {
+		if err := copyVerified(filepath.Join(c.Dir, key, name), entry.Files[name], verified, name); err != nil {
+			return err
+		}
+	}
+	restore := useStore(a, verified)
+	defer restore()
+	if withCCS {
+		if err := a.ReadCCS(ccsFile); err != nil {
+			return err
+		}
+	}
+	if err := a.ReadPK(pkFile); err != nil {
+		return err
+	}
+	return a.ReadVK(vkFile)
+}
+
+// copyVerified 将文件复制到 dst 的同时计算摘要，摘要与 want 不一致时不写入
+func copyVerified(filePath, want string, dst store.ArtifactStore, name string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("%w: %w", utils.ErrCorruptedArtifact, &utils.IOError{Op: "open artifact", Path: filePath, Err: err})
+	}
+	defer file.Close()
+	w, err := dst.Create(name)
+	if err != nil {
+		return err
+	}
+	h := sha256.New()
+	if _, err := io.Copy(w, io.TeeReader(file, h)); err != nil {
+		return &utils.IOError{Op: "read artifact", Path: filePath, Err: err}
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != want {
+		return fmt.Errorf("%w: digest mismatch for %s", utils.ErrCorruptedArtifact, filePath)
+	}
+	return w.Close()
+}
+
+// useStore 临时将包装器的存储切换到缓存条目目录，返回恢复原存储的函数
+func useStore(a Artifacts, s store.ArtifactStore) (restore func()) {
+	prev := a.GetStore()
+	a.SetStore(s)
+	return func() { a.SetStore(prev) }
+}
+
+// store 先写入临时目录，全部成功后再重命名为正式条目，避免留下不完整的条目
+func (c *Cache) store(key string, a Artifacts) error {
+	tmp, err := os.MkdirTemp(c.Dir, tmpPrefix+key)
+	if err != nil {
+		return &utils.IOError{Op: "create cache entry", Path: c.Dir, Err: err}
+	}
+	defer os.RemoveAll(tmp)
+
+	restore := useStore(a, store.NewFS(tmp))
+	defer restore()
+	if err := a.WriteCCS(ccsFile); err != nil {
+		return err
+	}
+	if err := a.WritePK(pkFile); err != nil {
+		return err
+	}
+	if err := a.WriteVK(vkFile); err != nil {
+		return err
+	}
+
+	entry := Entry{
+		Key:         key,
+		Scheme:      a.Scheme(),
+		Curve:       a.CurveID().String(),
+		Created:     time.Now().UTC(),
+		Files:       make(map[string]string),
+		Constraints: a.GetConstraintNum(),
+	}
+	srs, err := srsDigest(a)
+	if err != nil {
+		return err
+	}
+	if srs != nil {
+		entry.SRS = hex.EncodeToString(srs)
+	}
+	for _, name := range []string{ccsFile, pkFile, vkFile} {
+		digest, size, err := fileDigest(filepath.Join(tmp, name))
+		if err != nil {
+			return err
+		}
+		entry.Files[name] = digest
+		entry.Size += size
+	}
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	manifestPath := filepath.Join(tmp, manifestFile)
+	if err := os.WriteFile(manifestPath, data, 0644); err != nil {
+		return &utils.IOError{Op: "write cache manifest", Path: manifestPath, Err: err}
+	}
+
+	dir := filepath.Join(c.Dir, key)
+	if err := os.RemoveAll(dir); err != nil {
+		return &utils.IOError{Op: "remove cache entry", Path: dir, Err: err}
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		return &utils.IOError{Op: "commit cache entry", Path: dir, Err: err}
+	}
+	logger.Debug("stored artifact cache entry %s, size= %d", key, entry.Size)
+	return nil
+}
+
+// manifest 读取并解析条目清单，条目不存在时返回的错误满足 errors.Is(err, os.ErrNotExist)
+func (c *Cache) manifest(key string) (Entry, error) {
+	var entry Entry
+	if err := checkKey(key); err != nil {
+		return entry, err
+	}
+	manifestPath := filepath.Join(c.Dir, key, manifestFile)
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return entry, &utils.IOError{Op: "read cache manifest", Path: manifestPath, Err: err}
+	}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return entry, fmt.Errorf("%w: manifest %s: %v", utils.ErrCorruptedArtifact, manifestPath, err)
+	}
+	if entry.Key != key {
+		return entry, fmt.Errorf("%w: manifest %s records key %s", utils.ErrCorruptedArtifact, manifestPath, entry.Key)
+	}
+	return entry, nil
+}
+
+// verify 读取条目清单并校验各文件摘要
+func (c *Cache) verify(key string) (Entry, error) {
+	entry, err := c.manifest(key)
+	if err != nil {
+		return entry, err
+	}
+	dir := filepath.Join(c.Dir, key)
+	for _, name := range []string{ccsFile, pkFile, vkFile} {
+		want, ok := entry.Files[name]
+		if !ok {
+			return entry, fmt.Errorf("%w: %s missing from manifest", utils.ErrCorruptedArtifact, name)
+		}
+		got, _, err := fileDigest(filepath.Join(dir, name))
+		if err != nil {
+			return entry, fmt.Errorf("%w: %w", utils.ErrCorruptedArtifact, err)
+		}
+		if got != want {
+			return entry, fmt.Errorf("%w: digest mismatch for %s", utils.ErrCorruptedArtifact, filepath.Join(dir, name))
+		}
+	}
+	return entry, nil
+}
+
+// List 返回缓存中的全部条目，按写入时间排序；无法读取清单的条目被跳过
+func (c *Cache) List() ([]Entry, error) {
+	dirs, err := os.ReadDir(c.Dir)
+	if err != nil {
+		return nil, &utils.IOError{Op: "list cache dir", Path: c.Dir, Err: err}
+	}
+	var entries []Entry
+	for _, d := range dirs {
+		if !d.IsDir() || !isKey(d.Name()) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(c.Dir, d.Name(), manifestFile))
+		if err != nil {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Created.Before(entries[j].Created) })
+	return entries, nil
+}
+
+// Remove 删除指定条目，key 必须是 Key 返回的格式
+func (c *Cache) Remove(key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	dir := filepath.Join(c.Dir, key)
+	if err := os.RemoveAll(dir); err != nil {
+		return &utils.IOError{Op: "remove cache entry", Path: dir, Err: err}
+	}
+	return nil
+}
+
+// Prune 删除所有校验失败的条目，以及 maxAge 大于 0 时写入时间早于 maxAge 之前的条目，返回删除的条目数。
+// 只处理名称符合键格式的目录和 store 遗留的临时目录，缓存目录中的其他内容不受影响
+func (c *Cache) Prune(maxAge time.Duration) (int, error) {
+	dirs, err := os.ReadDir(c.Dir)
+	if err != nil {
+		return 0, &utils.IOError{Op: "list cache dir", Path: c.Dir, Err: err}
+	}
+	var removed int
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		// 正在写入的临时目录，只清理明显遗留的
+		if name := strings.TrimPrefix(d.Name(), tmpPrefix); name != d.Name() && len(name) > keySize && isKey(name[:keySize]) {
+			if info, err := d.Info(); err != nil || time.Since(info.ModTime()) < time.Hour {
+				continue
+			}
+			dir := filepath.Join(c.Dir, d.Name())
+			if err := os.RemoveAll(dir); err != nil {
+				return removed, &utils.IOError{Op: "remove cache entry", Path: dir, Err: err}
+			}
+			continue
+		}
+		if !isKey(d.Name()) {
+			continue
+		}
+		entry, err := c.verify(d.Name())
+		stale := maxAge > 0 && time.Since(entry.Created) > maxAge
+		if err == nil && !stale {
+			continue
+		}
+		logger.Debug("pruning artifact cache entry %s", d.Name())
+		if err := c.Remove(d.Name()); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+func fileDigest(filePath string) (string, int64, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", 0, &utils.IOError{Op: "open artifact", Path: filePath, Err: err}
+	}
+	defer file.Close()
+	h := sha256.New()
+	size, err := io.Copy(h, file)
+	if err != nil {
+		return "", 0, &utils.IOError{Op: "read artifact", Path: filePath, Err: err}
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
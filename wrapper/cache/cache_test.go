@@ -0,0 +1,228 @@
+package cache
+
+import (
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oliverustc/gnarkabc/circuits"
+	"github.com/oliverustc/gnarkabc/utils"
+	"github.com/oliverustc/gnarkabc/wrapper/groth16wrapper"
+	"github.com/oliverustc/gnarkabc/wrapper/plonkwrapper"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	kzg_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/kzg"
+	"github.com/consensys/gnark/frontend"
+)
+
+func newArtifacts(scheme string, circuit frontend.Circuit) Artifacts {
+	if scheme == "plonk" {
+		return plonkwrapper.NewWrapper(circuit, ecc.BN254)
+	}
+	return groth16wrapper.NewWrapper(circuit, ecc.BN254)
+}
+
+func TestCacheSetup(t *testing.T) {
+	c, err := New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var circuit circuits.Product
+	circuit.PreCompile(circuits.NoParams{})
+	for _, scheme := range []string{"groth16", "plonk"} {
+		a := newArtifacts(scheme, &circuit)
+		hit, err := c.Setup(a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hit {
+			t.Fatalf("%s: unexpected cache hit on empty cache", scheme)
+		}
+
+		// 同一电路再次设置时命中缓存
+		b := newArtifacts(scheme, &circuit)
+		if hit, err = c.Setup(b); err != nil {
+			t.Fatal(err)
+		}
+		if !hit {
+			t.Fatalf("%s: expected cache hit", scheme)
+		}
+		key, err := Key(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := c.Load(key, newArtifacts(scheme, &circuit)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 电路改变后摘要不同，不会复用旧参数
+	var mimc circuits.MimcHash
+	mimc.PreCompile(circuits.NoParams{})
+	hit, err := c.Setup(newArtifacts("groth16", &mimc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hit {
+		t.Fatal("unexpected cache hit for a different circuit")
+	}
+
+	entries, err := c.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+
+	// 不使用缓存时直接编译并执行 Setup
+	var none *Cache
+	a := newArtifacts("groth16", &circuit)
+	if hit, err := none.Setup(a); err != nil || hit {
+		t.Fatalf("setup without cache: hit %v, err %v", hit, err)
+	}
+	if _, err := a.MarshalCCS(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 加载了 SRS 的 PLONK 包装器不会命中由 unsafekzg 生成的参数，不同的 SRS 也不共用参数
+func TestCacheSRS(t *testing.T) {
+	c, err := New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var circuit circuits.Product
+	circuit.PreCompile(circuits.NoParams{})
+	if _, err := c.Setup(plonkwrapper.NewWrapper(&circuit, ecc.BN254)); err != nil {
+		t.Fatal(err)
+	}
+	keys := make(map[string]bool)
+	for _, alpha := range []int64{42, 43} {
+		srs, err := kzg_bn254.NewSRS(64, big.NewInt(alpha))
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := plonkwrapper.NewWrapper(&circuit, ecc.BN254)
+		p.SRS = srs
+		hit, err := c.Setup(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hit {
+			t.Fatalf("srs %d: unexpected cache hit", alpha)
+		}
+		key, err := Key(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[key] = true
+		entry, err := c.manifest(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entry.SRS == "" {
+			t.Fatalf("srs %d: digest not recorded", alpha)
+		}
+	}
+	if len(keys) != 2 {
+		t.Fatal("different SRS share a cache key")
+	}
+	entries, err := c.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 || entries[0].SRS != "" {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+}
+
+func TestCacheIntegrity(t *testing.T) {
+	c, err := New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var circuit circuits.Product
+	circuit.PreCompile(circuits.NoParams{})
+	a := newArtifacts("groth16", &circuit)
+	if _, err := c.Setup(a); err != nil {
+		t.Fatal(err)
+	}
+	key, err := Key(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 篡改 vk 后读取失败，Setup 重新生成
+	vkPath := filepath.Join(c.Dir, key, vkFile)
+	data, err := os.ReadFile(vkPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)-1] ^= 0xff
+	if err := os.WriteFile(vkPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Load(key, newArtifacts("groth16", &circuit)); !errors.Is(err, utils.ErrCorruptedArtifact) {
+		t.Fatalf("expected ErrCorruptedArtifact, got %v", err)
+	}
+	hit, err := c.Setup(newArtifacts("groth16", &circuit))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hit {
+		t.Fatal("corrupted entry must not be reported as a hit")
+	}
+	if err := c.Load(key, newArtifacts("groth16", &circuit)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Prune 清理损坏的条目和过期条目
+	if err := os.Remove(filepath.Join(c.Dir, key, pkFile)); err != nil {
+		t.Fatal(err)
+	}
+	removed, err := c.Prune(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 1 {
+		t.Fatalf("expected 1 pruned entry, got %d", removed)
+	}
+	if _, err := c.Setup(newArtifacts("groth16", &circuit)); err != nil {
+		t.Fatal(err)
+	}
+	if removed, err = c.Prune(time.Hour); err != nil || removed != 0 {
+		t.Fatalf("expected no pruned entry, got %d, %v", removed, err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if removed, err = c.Prune(time.Millisecond); err != nil || removed != 1 {
+		t.Fatalf("expected 1 pruned entry, got %d, %v", removed, err)
+	}
+	if err := c.Load(key, newArtifacts("groth16", &circuit)); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected missing entry, got %v", err)
+	}
+
+	// 只接受 Key 格式的键，Prune 不触碰缓存目录中的其他内容
+	for _, bad := range []string{"", "..", "../" + key, strings.ToUpper(key)} {
+		if err := c.Remove(bad); !errors.Is(err, ErrInvalidKey) {
+			t.Fatalf("Remove(%q): expected ErrInvalidKey, got %v", bad, err)
+		}
+		if err := c.Load(bad, newArtifacts("groth16", &circuit)); !errors.Is(err, ErrInvalidKey) {
+			t.Fatalf("Load(%q): expected ErrInvalidKey, got %v", bad, err)
+		}
+	}
+	other := filepath.Join(c.Dir, "notes")
+	if err := os.Mkdir(other, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if removed, err = c.Prune(time.Millisecond); err != nil || removed != 0 {
+		t.Fatalf("expected no pruned entry, got %d, %v", removed, err)
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Fatalf("unrelated directory was pruned: %v", err)
+	}
+}
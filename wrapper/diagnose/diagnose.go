@@ -0,0 +1,257 @@
+// Package diagnose 在 gnark 测试引擎中检查电路赋值，不需要编译和设置。
+// 赋值不满足约束时，报告失败的断言、断言在电路代码中的位置，以及涉及的电路字段及其值
+package diagnose
+
+import (
+	"bufio"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/oliverustc/gnarkabc/utils"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/schema"
+	"github.com/consensys/gnark/test"
+)
+
+var (
+	tVariable = reflect.TypeOf((*frontend.Variable)(nil)).Elem()
+	operandRe = regexp.MustCompile(`-?\d+`)
+)
+
+// Field 是电路的一个输入字段及其赋值，值已按标量域取模
+type Field struct {
+	Name   string `json:"name"` // 完整的字段名，嵌套字段和切片下标以 _ 连接，如 "Inputs_0"
+	Public bool   `json:"public"`
+	Value  string `json:"value"`
+}
+
+// Failure 描述赋值不满足的断言，可以通过 errors.Is(err, utils.ErrUnsatisfiedConstraint) 判断
+type Failure struct {
+	Assertion string   // 失败的断言及其操作数，如 "[assertIsEqual] 1 == 2"
+	Function  string   // 电路包中最内层的函数，如 "circuits.(*MimcHash).Define"
+	Location  string   // 该函数中调用断言的源码位置 file:line
+	Source    string   // 该位置的源码
+	Stack     []string // 从断言到 Define 的调用栈，每项为 "函数 文件:行号"
+	Involved  []Field  // 在该行源码中引用或值与断言操作数相同的字段
+	Fields    []Field  // 电路的所有输入字段
+	Err       error    // 测试引擎返回的原始错误，包含完整的调用栈
+}
+
+func (f *Failure) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s at %s", f.Assertion, f.Location)
+	if f.Function != "" {
+		fmt.Fprintf(&sb, " (%s)", f.Function)
+	}
+	if f.Source != "" {
+		fmt.Fprintf(&sb, "\n\t%s", f.Source)
+	}
+	for _, field := range f.Involved {
+		fmt.Fprintf(&sb, "\n\t%s = %s", field.Name, field.Value)
+	}
+	return sb.String()
+}
+
+// Unwrap 同时暴露 utils.ErrUnsatisfiedConstraint 和测试引擎的原始错误
+func (f *Failure) Unwrap() []error {
+	return []error{utils.ErrUnsatisfiedConstraint, f.Err}
+}
+
+// Check 在测试引擎中用 assignment 求解 circuit，满足时返回 nil，断言失败时返回 *Failure。
+// 缺少赋值时返回 utils.ErrMissingAssignment，Define 返回的其他错误原样包装
+func Check(circuit, assignment frontend.Circuit, field *big.Int) error {
+	if circuit == nil {
+		return fmt.Errorf("%w: circuit is nil", utils.ErrMissingSetup)
+	}
+	fields, err := Fields(assignment, field)
+	if err != nil {
+		return err
+	}
+	err = test.IsSolved(circuit, assignment, field)
+	if err == nil {
+		return nil
+	}
+	msg := err.Error()
+	// 断言失败时测试引擎从 panic 中恢复，错误信息为 panic 的内容加上 gnark/debug 记录的调用栈，
+	// Define 返回的错误则带有 "define: " 或 "deferred: " 前缀
+	idx := strings.Index(msg, "\n")
+	if idx < 0 || strings.HasPrefix(msg, "define: ") || strings.HasPrefix(msg, "deferred: ") {
+		return fmt.Errorf("check assignment failed: %w", err)
+	}
+	f := &Failure{Assertion: msg[:idx], Fields: fields, Err: err}
+	frames := parseStack(msg[idx+1:])
+	for _, fr := range frames {
+		f.Stack = append(f.Stack, fr.function+" "+fr.location())
+	}
+	if len(frames) > 0 {
+		fr := circuitFrame(circuit, frames)
+		f.Function, f.Location = fr.function, fr.location()
+		f.Source = sourceLine(fr.file, fr.line)
+	}
+	f.Involved = involved(f, fields)
+	return f
+}
+
+// Fields 返回赋值中所有输入字段的值，字段顺序与电路结构体中的声明顺序相同
+func Fields(assignment frontend.Circuit, field *big.Int) ([]Field, error) {
+	if assignment == nil || reflect.ValueOf(assignment).IsNil() {
+		return nil, utils.ErrMissingAssignment
+	}
+	var fields []Field
+	_, err := schema.Walk(field, assignment, tVariable, func(f schema.LeafInfo, tInput reflect.Value) error {
+		if tInput.IsNil() {
+			return fmt.Errorf("%w: field %s is not assigned", utils.ErrMissingAssignment, f.FullName())
+		}
+		fields = append(fields, Field{Name: f.FullName(), Public: f.Visibility == schema.Public})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 见证者中公开字段在前、私有字段在后，各自保持声明顺序，值已按标量域取模
+	w, err := frontend.NewWitness(assignment, field)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", utils.ErrMissingAssignment, err)
+	}
+	values, _, err := utils.WitnessValues(w, field)
+	if err != nil {
+		return nil, err
+	}
+	next := 0
+	for _, public := range []bool{true, false} {
+		for i := range fields {
+			if fields[i].Public != public {
+				continue
+			}
+			fields[i].Value = values[next].String()
+			next++
+		}
+	}
+	return fields, nil
+}
+
+// frame 是调用栈中的一项，未使用 debug 构建标签时 file 只有文件名
+type frame struct {
+	function string
+	file     string
+	line     int
+}
+
+func (fr frame) location() string {
+	return fmt.Sprintf("%s:%d", fr.file, fr.line)
+}
+
+// parseStack 解析 gnark/debug.Stack 的输出，每一帧为函数名一行加上以制表符开头的 file:line 一行
+func parseStack(stack string) []frame {
+	var frames []frame
+	lines := strings.Split(stack, "\n")
+	for i := 0; i+1 < len(lines); i += 2 {
+		loc := strings.TrimPrefix(lines[i+1], "\t")
+		j := strings.LastIndex(loc, ":")
+		if j < 0 {
+			break
+		}
+		line, err := strconv.Atoi(loc[j+1:])
+		if err != nil {
+			break
+		}
+		frames = append(frames, frame{function: lines[i], file: loc[:j], line: line})
+	}
+	return frames
+}
+
+// circuitFrame 返回位于电路 Define 所在目录中的最内层栈帧，并将文件名补全为完整路径；
+// 断言在电路调用的 gnark 组件中失败时，定位到电路中调用该组件的一行
+func circuitFrame(circuit frontend.Circuit, frames []frame) frame {
+	outer := frames[len(frames)-1]
+	method, ok := reflect.TypeOf(circuit).MethodByName("Define")
+	if !ok {
+		return outer
+	}
+	file, _ := runtime.FuncForPC(method.Func.Pointer()).FileLine(method.Func.Pointer())
+	dir := filepath.Dir(file)
+	for _, fr := range frames {
+		path := fr.file
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		if filepath.Dir(path) != dir {
+			continue
+		}
+		if _, err := os.Stat(path); err == nil {
+			fr.file = path
+			return fr
+		}
+	}
+	return outer
+}
+
+// sourceLine 读取文件中第 n 行的源码，文件不存在时返回空字符串
+func sourceLine(path string, n int) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if line == n {
+			return strings.TrimSpace(scanner.Text())
+		}
+	}
+	return ""
+}
+
+// involved 选出在失败的源码行中以 .Name 引用的字段，以及值与断言操作数相同的字段
+func involved(f *Failure, fields []Field) []Field {
+	operands := make(map[string]bool)
+	for _, op := range operandRe.FindAllString(f.Assertion, -1) {
+		operands[op] = true
+	}
+	var res []Field
+	for _, field := range fields {
+		if operands[field.Value] || referenced(f.Source, field.Name) {
+			res = append(res, field)
+		}
+	}
+	return res
+}
+
+// referenced 判断源码中是否以 .name 的形式引用了字段，name 的各级前缀均可，如 Inputs_0 可由 .Inputs 引用
+func referenced(source, fullName string) bool {
+	parts := strings.Split(fullName, "_")
+	for n := 1; n <= len(parts); n++ {
+		if referencedName(source, strings.Join(parts[:n], "_")) {
+			return true
+		}
+	}
+	return false
+}
+
+func referencedName(source, name string) bool {
+	for i := strings.Index(source, "."+name); i >= 0; {
+		end := i + 1 + len(name)
+		if end == len(source) || !isIdent(source[end]) {
+			return true
+		}
+		j := strings.Index(source[end:], "."+name)
+		if j < 0 {
+			break
+		}
+		i = end + j
+	}
+	return false
+}
+
+func isIdent(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
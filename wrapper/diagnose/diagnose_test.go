@@ -0,0 +1,132 @@
+package diagnose
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/oliverustc/gnarkabc/circuits"
+	"github.com/oliverustc/gnarkabc/hash/mimchash"
+	"github.com/oliverustc/gnarkabc/utils"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/math/bits"
+)
+
+// sliceCircuit 断言切片元素之和等于 Sum
+type sliceCircuit struct {
+	Inputs []frontend.Variable
+	Sum    frontend.Variable `gnark:",public"`
+}
+
+func (c *sliceCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(api.Add(c.Inputs[0], c.Inputs[1], c.Inputs[2:]...), c.Sum)
+	return nil
+}
+
+// bitsCircuit 将 X 分解为 4 位，X 不小于 16 时在 gnark 的分解中失败
+type bitsCircuit struct {
+	X frontend.Variable
+}
+
+func (c *bitsCircuit) Define(api frontend.API) error {
+	bits.ToBinary(api, c.X, bits.WithNbDigits(4))
+	return nil
+}
+
+func TestCheckMimcHash(t *testing.T) {
+	curve := ecc.BN254
+	preImage := big.NewInt(42).Bytes()
+	hash := mimchash.MiMCHash(mimchash.MiMCCaseMap["BN254"].Hash, [][]byte{preImage})
+
+	var circuit circuits.MimcHash
+	assignment := &circuits.MimcHash{PreImage: preImage, Hash: hash}
+	if err := Check(&circuit, assignment, curve.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+
+	assignment.Hash = 7
+	err := Check(&circuit, assignment, curve.ScalarField())
+	if !errors.Is(err, utils.ErrUnsatisfiedConstraint) {
+		t.Fatalf("expected ErrUnsatisfiedConstraint, got %v", err)
+	}
+	var failure *Failure
+	if !errors.As(err, &failure) {
+		t.Fatalf("expected *Failure, got %T", err)
+	}
+	if !strings.HasPrefix(failure.Assertion, "[assertIsEqual] 7 == ") {
+		t.Fatalf("unexpected assertion %q", failure.Assertion)
+	}
+	if !strings.HasSuffix(failure.Location, "circuits/mimchash.go:29") || failure.Function != "circuits.(*MimcHash).Define" {
+		t.Fatalf("unexpected location %s in %s", failure.Location, failure.Function)
+	}
+	if failure.Source != "api.AssertIsEqual(m.Hash, h)" {
+		t.Fatalf("unexpected source %q", failure.Source)
+	}
+	if len(failure.Involved) != 1 || failure.Involved[0].Name != "Hash" || failure.Involved[0].Value != "7" || !failure.Involved[0].Public {
+		t.Fatalf("unexpected involved fields %+v", failure.Involved)
+	}
+	if len(failure.Fields) != 2 || failure.Fields[0].Name != "PreImage" || failure.Fields[0].Value != "42" {
+		t.Fatalf("unexpected fields %+v", failure.Fields)
+	}
+	t.Log(err)
+}
+
+func TestCheckSlice(t *testing.T) {
+	field := ecc.BLS12_377.ScalarField()
+	circuit := &sliceCircuit{Inputs: make([]frontend.Variable, 3)}
+	// 值按标量域取模，-1 即 r-1
+	assignment := &sliceCircuit{Inputs: []frontend.Variable{1, 2, -1}, Sum: 5}
+	err := Check(circuit, assignment, field)
+	var failure *Failure
+	if !errors.As(err, &failure) {
+		t.Fatalf("expected *Failure, got %v", err)
+	}
+	// 源码行引用了 Inputs 和 Sum，所有字段都涉及
+	if len(failure.Involved) != 4 || failure.Involved[0].Name != "Inputs_0" {
+		t.Fatalf("unexpected involved fields %+v", failure.Involved)
+	}
+	minusOne := new(big.Int).Sub(field, big.NewInt(1)).String()
+	if failure.Fields[2].Value != minusOne {
+		t.Fatalf("expected %s, got %s", minusOne, failure.Fields[2].Value)
+	}
+	assignment.Sum = 2
+	if err := Check(circuit, assignment, field); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 断言在 gnark 组件中失败时，定位到电路中调用组件的一行
+func TestCheckGadget(t *testing.T) {
+	field := ecc.BN254.ScalarField()
+	err := Check(&bitsCircuit{}, &bitsCircuit{X: 100}, field)
+	var failure *Failure
+	if !errors.As(err, &failure) {
+		t.Fatalf("expected *Failure, got %v", err)
+	}
+	if failure.Source != "bits.ToBinary(api, c.X, bits.WithNbDigits(4))" || len(failure.Stack) < 2 {
+		t.Fatalf("unexpected source %q with stack %v", failure.Source, failure.Stack)
+	}
+	if len(failure.Involved) != 1 || failure.Involved[0].Name != "X" {
+		t.Fatalf("unexpected involved fields %+v", failure.Involved)
+	}
+	if err := Check(&bitsCircuit{}, &bitsCircuit{X: 15}, field); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckErrors(t *testing.T) {
+	field := ecc.BN254.ScalarField()
+	var circuit circuits.MimcHash
+	if err := Check(&circuit, nil, field); !errors.Is(err, utils.ErrMissingAssignment) {
+		t.Fatalf("expected ErrMissingAssignment, got %v", err)
+	}
+	if err := Check(&circuit, &circuits.MimcHash{PreImage: 1}, field); !errors.Is(err, utils.ErrMissingAssignment) {
+		t.Fatalf("expected ErrMissingAssignment, got %v", err)
+	}
+	if err := Check(nil, &circuits.MimcHash{PreImage: 1, Hash: 2}, field); !errors.Is(err, utils.ErrMissingSetup) {
+		t.Fatalf("expected ErrMissingSetup, got %v", err)
+	}
+}
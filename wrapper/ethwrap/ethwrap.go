@@ -0,0 +1,227 @@
+// Package ethwrap 将 BLS12-377 上的 Groth16 证明包装为可在以太坊上验证的 BN254 Groth16 证明。
+// BLS12-377 与 BW6-761 构成 2-chain，BW6-761 的电路原生验证内层证明，BN254 的电路再以非原生算术验证 BW6-761 的证明，
+// 最终证明的公开输入与内层证明相同，可导出 Solidity 验证合约和 verifyProof 的调用数据
+package ethwrap
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/oliverustc/gnarkabc/logger"
+	"github.com/oliverustc/gnarkabc/utils"
+	"github.com/oliverustc/gnarkabc/wrapper/cache"
+	"github.com/oliverustc/gnarkabc/wrapper/groth16wrapper"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/solidity"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_bw6761"
+	"github.com/consensys/gnark/std/algebra/native/sw_bls12377"
+	"github.com/consensys/gnark/std/math/bits"
+	"github.com/consensys/gnark/std/math/emulated"
+	recursion_groth16 "github.com/consensys/gnark/std/recursion/groth16"
+)
+
+// inputBits 是内层公开输入的位数，BLS12-377 的标量小于 BN254 的标量域，每个公开输入在各层都是一个原生变量
+var inputBits = ecc.BLS12_377.ScalarField().BitLen()
+
+// wrapCircuit 以常量验证密钥验证上一层的证明，并将上一层的公开输入转换为本层原生的公开输入
+type wrapCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT] struct {
+	Proof   recursion_groth16.Proof[G1El, G2El]
+	Witness recursion_groth16.Witness[FR]
+	Inputs  []frontend.Variable `gnark:",public"` // 与 Witness 逐个相等，即内层证明的公开输入
+
+	vk recursion_groth16.VerifyingKey[G1El, G2El, GtEl] `gnark:"-"`
+}
+
+func (c *wrapCircuit[FR, G1El, G2El, GtEl]) Define(api frontend.API) error {
+	verifier, err := recursion_groth16.NewVerifier[FR, G1El, G2El, GtEl](api)
+	if err != nil {
+		return fmt.Errorf("failed to create verifier: %w", err)
+	}
+	// 公开输入可以为 0，多标量乘法需要完整的算术
+	if err := verifier.AssertProof(c.vk, c.Proof, c.Witness, recursion_groth16.WithCompleteArithmetic()); err != nil {
+		return err
+	}
+	field, err := emulated.NewField[FR](api)
+	if err != nil {
+		return fmt.Errorf("failed to create field: %w", err)
+	}
+	for i := range c.Witness.Public {
+		b := field.ToBitsCanonical(&c.Witness.Public[i])
+		// 上一层已保证输入小于 BLS12-377 的标量域，高位为 0
+		for _, bit := range b[inputBits:] {
+			api.AssertIsEqual(bit, 0)
+		}
+		api.AssertIsEqual(bits.FromBinary(api, b[:inputBits]), c.Inputs[i])
+	}
+	return nil
+}
+
+// newWrapCircuit 创建验证 innerVK 证明的电路，内层约束系统确定公开输入的个数
+func newWrapCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](innerCCS constraint.ConstraintSystem, innerVK groth16.VerifyingKey) (*wrapCircuit[FR, G1El, G2El, GtEl], error) {
+	vk, err := recursion_groth16.ValueOfVerifyingKeyFixed[G1El, G2El, GtEl](innerVK)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert verifying key: %w", err)
+	}
+	return &wrapCircuit[FR, G1El, G2El, GtEl]{
+		Proof:   recursion_groth16.PlaceholderProof[G1El, G2El](innerCCS),
+		Witness: recursion_groth16.PlaceholderWitness[FR](innerCCS),
+		Inputs:  make([]frontend.Variable, innerCCS.GetNbPublicVariables()-1),
+		vk:      vk,
+	}, nil
+}
+
+// assignWrapCircuit 以上一层的证明和见证者为电路赋值，公开输入取自见证者
+func assignWrapCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](c *wrapCircuit[FR, G1El, G2El, GtEl], proof groth16.Proof, w witness.Witness) (*wrapCircuit[FR, G1El, G2El, GtEl], []*big.Int, error) {
+	circuitProof, err := recursion_groth16.ValueOfProof[G1El, G2El](proof)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to convert proof: %w", err)
+	}
+	circuitWitness, err := recursion_groth16.ValueOfWitness[FR](w)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to convert witness: %w", err)
+	}
+	var fr FR
+	inputs, err := utils.PublicInputs(w, fr.Modulus())
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(inputs) != len(c.Inputs) {
+		return nil, nil, fmt.Errorf("%w: witness has %d public inputs, expected %d", utils.ErrMissingAssignment, len(inputs), len(c.Inputs))
+	}
+	assignment := &wrapCircuit[FR, G1El, G2El, GtEl]{Proof: circuitProof, Witness: circuitWitness, Inputs: make([]frontend.Variable, len(inputs))}
+	for i := range inputs {
+		assignment.Inputs[i] = inputs[i]
+	}
+	return assignment, inputs, nil
+}
+
+// Pipeline 是从 BLS12-377 到 BN254 的包装流程，包含两层包装电路的约束系统和密钥，可重复用于同一内层电路的证明
+type Pipeline struct {
+	InnerVK groth16.VerifyingKey
+
+	chain *groth16wrapper.Groth16Wrapper // BW6-761 上验证内层证明的电路
+	eth   *groth16wrapper.Groth16Wrapper // BN254 上验证 BW6-761 证明的电路
+
+	chainCircuit *wrapCircuit[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT]
+	ethCircuit   *wrapCircuit[sw_bw6761.ScalarField, sw_bw6761.G1Affine, sw_bw6761.G2Affine, sw_bw6761.GTEl]
+}
+
+// Artifacts 是在以太坊上验证所需的全部产物
+type Artifacts struct {
+	Wrapper  *groth16wrapper.Groth16Wrapper // BN254 上的包装器，包含最终的验证密钥、证明和公开见证者
+	Solidity []byte                         // Verifier 合约的源码
+	Calldata []byte                         // 调用 verifyProof 的数据
+	Inputs   []*big.Int                     // 最终证明的公开输入，与内层证明的公开输入相同
+}
+
+// New 编译并设置两层包装电路，内层证明须在 BLS12-377 上以 groth16 生成。
+// c 不为空时从缓存加载参数，同一内层电路再次调用时复用上次设置的密钥；BN254 一层以非原生算术验证 BW6-761 的配对，设置非常耗时
+func New(innerCCS constraint.ConstraintSystem, innerVK groth16.VerifyingKey, c *cache.Cache) (*Pipeline, error) {
+	if innerCCS == nil || innerVK == nil {
+		return nil, fmt.Errorf("%w: inner constraint system or verifying key is nil", utils.ErrMissingSetup)
+	}
+	if innerVK.CurveID() != ecc.BLS12_377 {
+		return nil, fmt.Errorf("%w: inner proof is on %s, expected %s", utils.ErrUnsupportedCurve, innerVK.CurveID().String(), ecc.BLS12_377.String())
+	}
+	chainCircuit, err := newWrapCircuit[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT](innerCCS, innerVK)
+	if err != nil {
+		return nil, err
+	}
+	chain := groth16wrapper.NewWrapper(chainCircuit, ecc.BW6_761)
+	if _, err := c.Setup(chain); err != nil {
+		return nil, fmt.Errorf("setup %s layer: %w", ecc.BW6_761.String(), err)
+	}
+	ethCircuit, err := newWrapCircuit[sw_bw6761.ScalarField, sw_bw6761.G1Affine, sw_bw6761.G2Affine, sw_bw6761.GTEl](chain.CCS, chain.VK)
+	if err != nil {
+		return nil, err
+	}
+	eth := groth16wrapper.NewWrapper(ethCircuit, ecc.BN254)
+	if _, err := c.Setup(eth); err != nil {
+		return nil, fmt.Errorf("setup %s layer: %w", ecc.BN254.String(), err)
+	}
+	logger.Info("wrap pipeline has %d constraints on %s and %d constraints on %s",
+		chain.CCS.GetNbConstraints(), ecc.BW6_761.String(), eth.CCS.GetNbConstraints(), ecc.BN254.String())
+	return &Pipeline{InnerVK: innerVK, chain: chain, eth: eth, chainCircuit: chainCircuit, ethCircuit: ethCircuit}, nil
+}
+
+// Wrap 将内层证明依次包装为 BW6-761 和 BN254 上的证明，返回在以太坊上验证所需的产物。
+// innerWitness 可以是公开见证者或完整见证者；内层电路含有承诺时，内层证明须由 RecursionTarget 为 BW6-761 的包装器生成。
+// 同一个 Pipeline 可以并发调用 Wrap
+func (p *Pipeline) Wrap(proof groth16.Proof, innerWitness witness.Witness) (*Artifacts, error) {
+	if proof == nil {
+		return nil, fmt.Errorf("%w: inner proof is nil", utils.ErrInvalidProof)
+	}
+	if innerWitness == nil {
+		return nil, fmt.Errorf("%w: inner witness is nil", utils.ErrMissingAssignment)
+	}
+	// 先在本地验证内层证明，无效的证明不必经过耗时的两层递归证明
+	public, err := innerWitness.Public()
+	if err != nil {
+		return nil, fmt.Errorf("get public witness failed: %w", err)
+	}
+	chainField := ecc.BW6_761.ScalarField()
+	if err := groth16.Verify(proof, p.InnerVK, public, recursion_groth16.GetNativeVerifierOptions(chainField, ecc.BLS12_377.ScalarField())); err != nil {
+		return nil, fmt.Errorf("%w: inner proof: %w", utils.ErrInvalidProof, err)
+	}
+
+	chainAssignment, inputs, err := assignWrapCircuit(p.chainCircuit, proof, innerWitness)
+	if err != nil {
+		return nil, err
+	}
+	chain := fork(p.chain)
+	chain.SetAssignment(chainAssignment)
+	chain.SetRecursionTarget(ecc.BN254)
+	if err := chain.Prove(); err != nil {
+		return nil, fmt.Errorf("prove %s layer: %w", ecc.BW6_761.String(), err)
+	}
+	logger.Debug("%s layer proved, took: %s", ecc.BW6_761.String(), chain.ProveTime.String())
+
+	ethAssignment, _, err := assignWrapCircuit(p.ethCircuit, chain.Proof, chain.WitnessFull)
+	if err != nil {
+		return nil, err
+	}
+	eth := fork(p.eth)
+	eth.SetAssignment(ethAssignment)
+	// Solidity 验证合约以 keccak256 计算承诺的哈希
+	if err := eth.Prove(solidity.WithProverTargetSolidityVerifier(backend.GROTH16)); err != nil {
+		return nil, fmt.Errorf("prove %s layer: %w", ecc.BN254.String(), err)
+	}
+	if err := eth.Verify(solidity.WithVerifierTargetSolidityVerifier(backend.GROTH16)); err != nil {
+		return nil, err
+	}
+	logger.Debug("%s layer proved, took: %s", ecc.BN254.String(), eth.ProveTime.String())
+
+	var source bytes.Buffer
+	if err := eth.VK.ExportSolidity(&source); err != nil {
+		return nil, fmt.Errorf("export solidity failed: %w", err)
+	}
+	calldata, err := eth.SolidityCalldata()
+	if err != nil {
+		return nil, err
+	}
+	return &Artifacts{Wrapper: eth, Solidity: source.Bytes(), Calldata: calldata, Inputs: inputs}, nil
+}
+
+// Wrap 以一次调用完成 New 和 Pipeline.Wrap，c 不为空时跨调用复用两层包装电路的密钥
+func Wrap(innerCCS constraint.ConstraintSystem, innerVK groth16.VerifyingKey, proof groth16.Proof, innerWitness witness.Witness, c *cache.Cache) (*Artifacts, error) {
+	p, err := New(innerCCS, innerVK, c)
+	if err != nil {
+		return nil, err
+	}
+	return p.Wrap(proof, innerWitness)
+}
+
+// fork 返回共享约束系统和密钥的新包装器，各次包装的证明和见证者互不影响
+func fork(zk *groth16wrapper.Groth16Wrapper) *groth16wrapper.Groth16Wrapper {
+	forked := groth16wrapper.NewWrapper(zk.Circuit, zk.Curve)
+	forked.CCS, forked.PK, forked.VK = zk.CCS, zk.PK, zk.VK
+	return forked
+}
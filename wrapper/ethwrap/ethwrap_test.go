@@ -0,0 +1,111 @@
+package ethwrap
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+
+	"github.com/oliverustc/gnarkabc/circuits"
+	"github.com/oliverustc/gnarkabc/utils"
+	"github.com/oliverustc/gnarkabc/wrapper/groth16wrapper"
+	"github.com/oliverustc/gnarkabc/wrapper/testutil"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/std/algebra/native/sw_bls12377"
+	"github.com/consensys/gnark/test"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// proveProduct 在 curve 上证明 3 * 5 = 15
+func proveProduct(t *testing.T, curve ecc.ID) *groth16wrapper.Groth16Wrapper {
+	var circuit circuits.Product
+	zk := groth16wrapper.NewWrapper(&circuit, curve)
+	if err := zk.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	if err := zk.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	circuit.Assign(circuits.ProductAssign{P: 3, Q: 5})
+	zk.SetAssignment(&circuit)
+	if err := zk.Prove(); err != nil {
+		t.Fatal(err)
+	}
+	return zk
+}
+
+func TestNewErrors(t *testing.T) {
+	if _, err := New(nil, nil, nil); !errors.Is(err, utils.ErrMissingSetup) {
+		t.Fatalf("expected ErrMissingSetup, got %v", err)
+	}
+	inner := proveProduct(t, ecc.BN254)
+	if _, err := New(inner.CCS, inner.VK, nil); !errors.Is(err, utils.ErrUnsupportedCurve) {
+		t.Fatalf("expected ErrUnsupportedCurve, got %v", err)
+	}
+}
+
+// 在测试引擎中检查 BW6-761 一层：公开输入须与内层证明的公开输入一致
+func TestChainLayerSolved(t *testing.T) {
+	inner := proveProduct(t, ecc.BLS12_377)
+	circuit, err := newWrapCircuit[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT](inner.CCS, inner.VK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assignment, inputs, err := assignWrapCircuit(circuit, inner.Proof, inner.WitnessFull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) != 1 || inputs[0].Int64() != 15 {
+		t.Fatalf("unexpected public inputs %v", inputs)
+	}
+	field := ecc.BW6_761.ScalarField()
+	if err := test.IsSolved(circuit, assignment, field); err != nil {
+		t.Fatal(err)
+	}
+	assignment.Inputs[0] = 16
+	if err := test.IsSolved(circuit, assignment, field); err == nil {
+		t.Fatal("expected unsolved circuit with a wrong public input")
+	}
+}
+
+func TestWrapRecursion(t *testing.T) {
+	inner := proveProduct(t, ecc.BLS12_377)
+	c := testutil.ArtifactCache(t)
+	artifacts, err := Wrap(inner.CCS, inner.VK, inner.Proof, inner.WitnessFull, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(artifacts.Inputs) != 1 || artifacts.Inputs[0].Int64() != 15 {
+		t.Fatalf("unexpected public inputs %v", artifacts.Inputs)
+	}
+	if len(artifacts.Solidity) == 0 {
+		t.Fatal("expected solidity source")
+	}
+	// BN254 一层含有承诺，调用数据包含承诺及其知识证明
+	selector := crypto.Keccak256([]byte("verifyProof(uint256[8],uint256[2],uint256[2],uint256[1])"))[:4]
+	if string(artifacts.Calldata[:4]) != string(selector) {
+		t.Fatalf("unexpected selector %x", artifacts.Calldata[:4])
+	}
+
+	// 再次创建时从缓存加载密钥，其他设置下的内层证明在本地验证时即被拒绝
+	p, err := New(inner.CCS, inner.VK, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := proveProduct(t, ecc.BLS12_377)
+	if _, err := p.Wrap(other.Proof, other.WitnessFull); !errors.Is(err, utils.ErrInvalidProof) {
+		t.Fatalf("expected ErrInvalidProof for a proof of another setup, got %v", err)
+	}
+
+	if _, err := exec.LookPath("solc"); err != nil {
+		t.Skip("solc not found, skip verification with the exported verifier")
+	}
+	res, err := artifacts.Wrapper.VerifyOnEVM(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Success {
+		t.Fatal("verifier rejected a valid proof")
+	}
+	t.Logf("gas used: %d", res.GasUsed)
+}
@@ -0,0 +1,164 @@
+// Package evm 在进程内的 go-ethereum 模拟链上部署 Solidity 验证合约并调用，
+// 不需要 abigen、生成的 go.mod 和网络；提供预编译的字节码时也不需要 solc
+package evm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+
+	"github.com/oliverustc/gnarkabc/logger"
+	"github.com/oliverustc/gnarkabc/utils"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	blockGasLimit = 100_000_000 // 模拟链的区块 gas 上限
+	txGasLimit    = 10_000_000  // 部署和验证交易的 gas 上限
+)
+
+// Result 是一次链上验证的结果
+type Result struct {
+	Success bool   // 调用未回滚，且返回值为空（Groth16 验证合约）或不为 false（PLONK 验证合约）
+	GasUsed uint64 // 验证交易实际消耗的 gas，包含 21000 的交易基础费用和 calldata 费用
+}
+
+// Run 在新的模拟链上部署 bytecode，以 calldata 调用合约并发送同样的交易统计 gas。
+// 部署失败返回错误，验证失败只体现在 Result.Success 中
+func Run(bytecode, calldata []byte) (Result, error) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return Result{}, err
+	}
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	balance := new(big.Int).Lsh(big.NewInt(1), 100)
+	backend := simulated.NewBackend(types.GenesisAlloc{from: {Balance: balance}}, simulated.WithBlockGasLimit(blockGasLimit))
+	defer backend.Close()
+	client := backend.Client()
+	ctx := context.Background()
+
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return Result{}, err
+	}
+	signer := types.LatestSignerForChainID(chainID)
+	head, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return Result{}, err
+	}
+	// 出块时会忽略小费低于建议值的交易
+	tipCap, err := client.SuggestGasTipCap(ctx)
+	if err != nil {
+		return Result{}, err
+	}
+	// 模拟链的 base fee 随空块下降，两倍的当前值足以覆盖后续两个区块
+	feeCap := new(big.Int).Add(new(big.Int).Mul(head.BaseFee, big.NewInt(2)), tipCap)
+	send := func(nonce uint64, to *common.Address, data []byte) (*types.Receipt, error) {
+		tx, err := types.SignNewTx(key, signer, &types.DynamicFeeTx{
+			ChainID:   chainID,
+			Nonce:     nonce,
+			GasTipCap: tipCap,
+			GasFeeCap: feeCap,
+			Gas:       txGasLimit,
+			To:        to,
+			Data:      data,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := client.SendTransaction(ctx, tx); err != nil {
+			return nil, err
+		}
+		// 模拟链的交易索引是异步建立的，按区块读取回执以免等待索引
+		receipts, err := client.(blockReceiptsReader).BlockReceipts(ctx, rpc.BlockNumberOrHashWithHash(backend.Commit(), false))
+		if err != nil {
+			return nil, err
+		}
+		for _, receipt := range receipts {
+			if receipt.TxHash == tx.Hash() {
+				return receipt, nil
+			}
+		}
+		return nil, fmt.Errorf("transaction %s not included", tx.Hash().Hex())
+	}
+
+	receipt, err := send(0, nil, bytecode)
+	if err != nil {
+		return Result{}, fmt.Errorf("deploy verifier failed: %w", err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return Result{}, errors.New("deploy verifier failed: contract creation reverted")
+	}
+	verifier := receipt.ContractAddress
+	logger.Debug("verifier deployed at %s, gas used: %d", verifier.Hex(), receipt.GasUsed)
+
+	out, callErr := client.CallContract(ctx, ethereum.CallMsg{From: from, To: &verifier, Gas: txGasLimit, Data: calldata}, nil)
+	receipt, err = send(1, &verifier, calldata)
+	if err != nil {
+		return Result{}, fmt.Errorf("send verify transaction failed: %w", err)
+	}
+	res := Result{
+		Success: callErr == nil && receipt.Status == types.ReceiptStatusSuccessful && !returnsFalse(out),
+		GasUsed: receipt.GasUsed,
+	}
+	if callErr != nil {
+		logger.Debug("verifier call reverted: %v", callErr)
+	}
+	return res, nil
+}
+
+// blockReceiptsReader 是模拟链客户端提供但未在 simulated.Client 中声明的方法
+type blockReceiptsReader interface {
+	BlockReceipts(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]*types.Receipt, error)
+}
+
+// returnsFalse 判断返回值是否为 ABI 编码的 false
+func returnsFalse(out []byte) bool {
+	return len(out) == 32 && bytes.Equal(out, make([]byte, 32))
+}
+
+// binaryPattern 匹配 solc --bin 输出中每个合约的字节码
+var binaryPattern = regexp.MustCompile(`======= .*:(\w+) =======\s+Binary:\s+([0-9a-fA-F]*)`)
+
+// Compile 使用本地的 solc 将 Solidity 源码编译为合约 contract 的部署字节码
+func Compile(source []byte, contract string) ([]byte, error) {
+	if _, err := exec.LookPath("solc"); err != nil {
+		return nil, fmt.Errorf("solc not found, provide precompiled bytecode instead: %w", err)
+	}
+	dir, err := os.MkdirTemp("", "gnarkabc-solc-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	solPath := filepath.Join(dir, "verifier.sol")
+	if err := os.WriteFile(solPath, source, 0644); err != nil {
+		return nil, &utils.IOError{Op: "write solidity source", Path: solPath, Err: err}
+	}
+	cmd := exec.Command("solc", "--bin", "--optimize", solPath)
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("run %s: %w\n%s", cmd.String(), err, exitErr.Stderr)
+		}
+		return nil, fmt.Errorf("run %s: %w", cmd.String(), err)
+	}
+	for _, m := range binaryPattern.FindAllSubmatch(out, -1) {
+		if string(m[1]) == contract {
+			return common.FromHex(string(m[2])), nil
+		}
+	}
+	return nil, fmt.Errorf("contract %s not found in solc output", contract)
+}
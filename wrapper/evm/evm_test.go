@@ -0,0 +1,48 @@
+package evm
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// 手工汇编的合约：部署代码将运行时代码写入内存后返回
+var (
+	// 运行时代码 PUSH1 1 PUSH1 0 MSTORE PUSH1 32 PUSH1 0 RETURN，返回 true
+	returnTrue = common.FromHex("69600160005260206000f3600052600a6016f3")
+	// 运行时代码 PUSH1 32 PUSH1 0 RETURN，返回 false
+	returnFalse = common.FromHex("6460206000f36000526005601bf3")
+	// 运行时代码 PUSH1 0 PUSH1 0 REVERT
+	revert = common.FromHex("6460006000fd6000526005601bf3")
+	// 运行时代码 STOP，不返回数据
+	stop = common.FromHex("60006000526001601ff3")
+)
+
+func TestRun(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		bytecode []byte
+		success  bool
+	}{
+		{"returnTrue", returnTrue, true},
+		{"returnFalse", returnFalse, false},
+		{"revert", revert, false},
+		{"stop", stop, true},
+	} {
+		res, err := Run(tc.bytecode, []byte{1, 2, 3, 4})
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if res.Success != tc.success {
+			t.Fatalf("%s: expected success %v, got %v", tc.name, tc.success, res.Success)
+		}
+		if res.GasUsed < 21000 {
+			t.Fatalf("%s: unexpected gas used %d", tc.name, res.GasUsed)
+		}
+	}
+
+	// 部署代码回滚时返回错误
+	if _, err := Run(common.FromHex("60006000fd"), nil); err == nil {
+		t.Fatal("expected failed deployment to return an error")
+	}
+}
@@ -1,17 +1,23 @@
 package groth16wrapper
 
 import (
+	"fmt"
 	"math/big"
 	"time"
 
 	"github.com/oliverustc/gnarkabc/logger"
+	"github.com/oliverustc/gnarkabc/utils"
+	"github.com/oliverustc/gnarkabc/wrapper/diagnose"
+	"github.com/oliverustc/gnarkabc/wrapper/store"
 
 	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
 	"github.com/consensys/gnark/backend/groth16"
 	"github.com/consensys/gnark/backend/witness"
 	"github.com/consensys/gnark/constraint"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/frontend/cs/r1cs"
+	recursion_groth16 "github.com/consensys/gnark/std/recursion/groth16"
 )
 
 // Groth16Wrapper Groth16证明系统的包装器
@@ -27,6 +33,11 @@ type Groth16Wrapper struct {
 	WitnessFull   witness.Witness             // 完整见证者
 	WitnessPublic witness.Witness             // 公开见证者
 	CCS           constraint.ConstraintSystem // 约束系统
+	Store         store.ArtifactStore         // 参数、证明和见证者的存储位置，默认为 output/ 目录
+
+	// RecursionTarget 是在其电路中递归验证本证明的外层曲线，设置后 Prove 和 Verify 自动使用
+	// recursion_groth16 的原生选项，使证明中承诺的哈希与外层电路一致；为 ecc.UNKNOWN 时不使用
+	RecursionTarget ecc.ID
 
 	CompileTime time.Duration // 编译时间
 	SetupTime   time.Duration // 设置时间
@@ -41,17 +52,18 @@ func NewWrapper(circuit frontend.Circuit, curve ecc.ID) *Groth16Wrapper {
 		Circuit: circuit,
 		Curve:   curve,
 		Field:   curve.ScalarField(),
+		Store:   store.NewFS(store.DefaultDir),
 	}
 }
 
 // Compile 编译电路
-func (g *Groth16Wrapper) Compile() {
+func (g *Groth16Wrapper) Compile() error {
 	logger.Debug("compiling circuit ...")
 	var err error
 	start := time.Now()
 	g.CCS, err = frontend.Compile(g.Field, r1cs.NewBuilder, g.Circuit)
 	if err != nil {
-		logger.Fatal("compile circuit failed. %v", err)
+		return fmt.Errorf("compile circuit failed: %w", err)
 	}
 	g.CompileTime = time.Since(start)
 	logger.Debug("circuit compiled, took: %s", g.CompileTime.String())
@@ -59,140 +71,226 @@ func (g *Groth16Wrapper) Compile() {
 		g.ConstraintNum = g.CCS.GetNbConstraints()
 		logger.Debug("constraint number: %d", g.ConstraintNum)
 	}
+	return nil
 }
 
 // Setup 设置电路的证明系统
-func (g *Groth16Wrapper) Setup() {
+func (g *Groth16Wrapper) Setup() error {
 	logger.Debug("setting up circuit ...")
+	if g.CCS == nil {
+		return fmt.Errorf("%w: constraint system is nil, compile first", utils.ErrMissingSetup)
+	}
 	var err error
 	start := time.Now()
 	g.PK, g.VK, err = groth16.Setup(g.CCS)
 	if err != nil {
-		logger.Fatal("setup circuit failed. %v", err)
+		return fmt.Errorf("setup circuit failed: %w", err)
 	}
 	g.SetupTime = time.Since(start)
 	logger.Debug("circuit setup, took: %s", g.SetupTime.String())
+	return nil
 }
 
-// generateWitness 生成见证者数据
+// GenerateWitness 生成见证者数据
 // publicOnly: 是否只生成公开输入的见证者
-func (g *Groth16Wrapper) GenerateWitness(publicOnly bool) {
+func (g *Groth16Wrapper) GenerateWitness(publicOnly bool) error {
 	var err error
 	if publicOnly {
 		if g.WitnessFull != nil {
 			g.WitnessPublic, err = g.WitnessFull.Public()
 			if err != nil {
-				logger.Fatal("generate public witness from witnessfull failed. %v", err)
-			}
-		} else {
-			if g.Assignment == nil {
-				logger.Fatal("assignment is nil")
-			}
-			g.WitnessPublic, err = frontend.NewWitness(g.Assignment, g.Field, frontend.PublicOnly())
-			if err != nil {
-				logger.Fatal("generate public witness from assignment failed. %v", err)
+				return fmt.Errorf("generate public witness from witnessfull failed: %w", err)
 			}
+			return nil
 		}
-	} else {
 		if g.Assignment == nil {
-			logger.Fatal("assignment is nil")
+			return utils.ErrMissingAssignment
 		}
-		g.WitnessFull, err = frontend.NewWitness(g.Assignment, g.Field)
+		g.WitnessPublic, err = frontend.NewWitness(g.Assignment, g.Field, frontend.PublicOnly())
 		if err != nil {
-			logger.Fatal("generate full witness failed. %v", err)
+			return fmt.Errorf("generate public witness from assignment failed: %w", err)
 		}
+		return nil
+	}
+	if g.Assignment == nil {
+		return utils.ErrMissingAssignment
 	}
+	g.WitnessFull, err = frontend.NewWitness(g.Assignment, g.Field)
+	if err != nil {
+		return fmt.Errorf("generate full witness failed: %w", err)
+	}
+	return nil
+}
+
+// CheckAssignment 在测试引擎中检查当前赋值是否满足电路，不需要编译和设置。
+// 不满足时返回 *diagnose.Failure，包含失败的断言、源码位置和涉及的电路字段
+func (g *Groth16Wrapper) CheckAssignment() error {
+	if g.Assignment == nil {
+		return utils.ErrMissingAssignment
+	}
+	return diagnose.Check(g.Circuit, g.Assignment, g.Field)
 }
 
-// SetAssignment 设置电路的赋值
+// SetAssignment 设置电路的赋值，并清空由旧赋值生成的见证者
 func (g *Groth16Wrapper) SetAssignment(assignment frontend.Circuit) {
 	g.Assignment = assignment
+	g.WitnessFull = nil
+	g.WitnessPublic = nil
 }
 
-// Prove 生成零知识证明
-func (g *Groth16Wrapper) Prove() {
+// Prove 生成零知识证明，支持可选的证明者选项。
+// 设置了 RecursionTarget 时先加入递归验证所需的选项，opts 中的同类选项可以覆盖
+func (g *Groth16Wrapper) Prove(opts ...backend.ProverOption) error {
 	logger.Debug("proving ...")
+	if g.CCS == nil || g.PK == nil {
+		return fmt.Errorf("%w: constraint system or proving key is nil", utils.ErrMissingSetup)
+	}
 	var err error
 	if g.WitnessFull == nil {
-		g.GenerateWitness(false)
+		if err = g.GenerateWitness(false); err != nil {
+			return err
+		}
 	}
 	start := time.Now()
-	g.Proof, err = groth16.Prove(g.CCS, g.PK, g.WitnessFull)
+	g.Proof, err = groth16.Prove(g.CCS, g.PK, g.WitnessFull, g.proverOptions(opts)...)
+	if utils.IsUnsatisfiedConstraint(err) {
+		return fmt.Errorf("%w: %w", utils.ErrUnsatisfiedConstraint, err)
+	}
 	if err != nil {
-		logger.Fatal("prove failed. %v", err)
+		return fmt.Errorf("prove failed: %w", err)
 	}
 	g.ProveTime = time.Since(start)
 	logger.Debug("circuit proved, took: %s", g.ProveTime.String())
+	return nil
 }
 
-// Verify 验证零知识证明
-func (g *Groth16Wrapper) Verify() {
+// Verify 验证零知识证明，支持可选的验证者选项，RecursionTarget 的处理与 Prove 相同
+func (g *Groth16Wrapper) Verify(opts ...backend.VerifierOption) error {
 	logger.Debug("verifying ...")
+	if g.VK == nil {
+		return fmt.Errorf("%w: verifying key is nil", utils.ErrMissingSetup)
+	}
+	if g.Proof == nil {
+		return fmt.Errorf("%w: proof is nil", utils.ErrInvalidProof)
+	}
 	var err error
 	if g.WitnessPublic == nil {
-		g.GenerateWitness(true)
+		if err = g.GenerateWitness(true); err != nil {
+			return err
+		}
 	}
 	start := time.Now()
-	err = groth16.Verify(g.Proof, g.VK, g.WitnessPublic)
+	err = groth16.Verify(g.Proof, g.VK, g.WitnessPublic, g.verifierOptions(opts)...)
 	if err != nil {
-		logger.Fatal("verify proof failed. %v", err)
-	} else {
-		logger.Debug("circuit verified")
+		return fmt.Errorf("%w: %w", utils.ErrInvalidProof, err)
 	}
 	g.VerifyTime = time.Since(start)
 	logger.Debug("circuit verified, took: %s", g.VerifyTime.String())
+	return nil
+}
+
+// SetRecursionTarget 设置递归验证本证明的外层曲线，ecc.UNKNOWN 表示证明不用于递归
+func (g *Groth16Wrapper) SetRecursionTarget(outer ecc.ID) {
+	g.RecursionTarget = outer
+}
+
+// proverOptions 在 opts 之前加入 RecursionTarget 对应的证明者选项
+func (g *Groth16Wrapper) proverOptions(opts []backend.ProverOption) []backend.ProverOption {
+	if g.RecursionTarget == ecc.UNKNOWN {
+		return opts
+	}
+	return append([]backend.ProverOption{recursion_groth16.GetNativeProverOptions(g.RecursionTarget.ScalarField(), g.Curve.ScalarField())}, opts...)
+}
+
+// verifierOptions 在 opts 之前加入 RecursionTarget 对应的验证者选项
+func (g *Groth16Wrapper) verifierOptions(opts []backend.VerifierOption) []backend.VerifierOption {
+	if g.RecursionTarget == ecc.UNKNOWN {
+		return opts
+	}
+	return append([]backend.VerifierOption{recursion_groth16.GetNativeVerifierOptions(g.RecursionTarget.ScalarField(), g.Curve.ScalarField())}, opts...)
 }
 
 // BenchmarkCompile 对编译过程进行基准测试
-func (g *Groth16Wrapper) BenchmarkCompile(iterations int) time.Duration {
+func (g *Groth16Wrapper) BenchmarkCompile(iterations int) (time.Duration, error) {
 	logger.Debug("benchmarking compiling circuit ...")
 	var compileTime time.Duration
 	for i := 0; i < iterations; i++ {
-		g.Compile()
+		if err := g.Compile(); err != nil {
+			return 0, err
+		}
 		compileTime += g.CompileTime
 	}
 	g.CompileTime = compileTime / time.Duration(iterations)
 	logger.Debug("after %d iterations, compile time: %s", iterations, g.CompileTime.String())
-	return g.CompileTime
+	return g.CompileTime, nil
 }
 
 // BenchmarkSetup 对设置过程进行基准测试
-func (g *Groth16Wrapper) BenchmarkSetup(iterations int) time.Duration {
+func (g *Groth16Wrapper) BenchmarkSetup(iterations int) (time.Duration, error) {
 	logger.Debug("benchmarking setup circuit ...")
 	var setupTime time.Duration
 	for i := 0; i < iterations; i++ {
-		g.Setup()
+		if err := g.Setup(); err != nil {
+			return 0, err
+		}
 		setupTime += g.SetupTime
 	}
 	g.SetupTime = setupTime / time.Duration(iterations)
 	logger.Debug("after %d iterations, setup time: %s", iterations, g.SetupTime.String())
-	return g.SetupTime
+	return g.SetupTime, nil
 }
 
 // BenchmarkProve 对证明生成过程进行基准测试
-func (g *Groth16Wrapper) BenchmarkProve(iterations int) time.Duration {
+func (g *Groth16Wrapper) BenchmarkProve(iterations int) (time.Duration, error) {
 	logger.Debug("benchmarking proving circuit ...")
 	var proveTime time.Duration
 	for i := 0; i < iterations; i++ {
-		g.Prove()
+		if err := g.Prove(); err != nil {
+			return 0, err
+		}
 		proveTime += g.ProveTime
 	}
 	g.ProveTime = proveTime / time.Duration(iterations)
 	logger.Debug("after %d iterations, prove time: %s", iterations, g.ProveTime.String())
-	return g.ProveTime
+	return g.ProveTime, nil
 }
 
 // BenchmarkVerify 对验证过程进行基准测试
-func (g *Groth16Wrapper) BenchmarkVerify(iterations int) time.Duration {
+func (g *Groth16Wrapper) BenchmarkVerify(iterations int) (time.Duration, error) {
 	logger.Debug("benchmarking verifying circuit ...")
 	var verifyTime time.Duration
 	for i := 0; i < iterations; i++ {
-		g.Verify()
+		if err := g.Verify(); err != nil {
+			return 0, err
+		}
 		verifyTime += g.VerifyTime
 	}
 	g.VerifyTime = verifyTime / time.Duration(iterations)
 	logger.Debug("after %d iterations, verify time: %s", iterations, g.VerifyTime.String())
-	return g.VerifyTime
+	return g.VerifyTime, nil
+}
+
+// Scheme 返回证明系统名称
+func (g *Groth16Wrapper) Scheme() string {
+	return "groth16"
+}
+
+// CurveID 返回包装器使用的曲线
+func (g *Groth16Wrapper) CurveID() ecc.ID {
+	return g.Curve
+}
+
+// SetStore 设置 Write*/Read* 和 Solidity 导出使用的存储
+func (g *Groth16Wrapper) SetStore(s store.ArtifactStore) {
+	g.Store = s
+}
+
+// GetStore 返回当前存储，未设置时使用默认的 output/ 目录
+func (g *Groth16Wrapper) GetStore() store.ArtifactStore {
+	if g.Store == nil {
+		g.Store = store.NewFS(store.DefaultDir)
+	}
+	return g.Store
 }
 
 // 获取电路中约束数量
@@ -201,26 +299,30 @@ func (g *Groth16Wrapper) GetConstraintNum() int {
 }
 
 // 获取json格式witness
-func (g *Groth16Wrapper) GetWitnessJson(public bool) []byte {
+func (g *Groth16Wrapper) GetWitnessJson(public bool) ([]byte, error) {
+	if g.WitnessFull == nil {
+		if err := g.GenerateWitness(false); err != nil {
+			return nil, err
+		}
+	}
 	schama, err := frontend.NewSchema(g.Field, g.Assignment)
 	if err != nil {
-		logger.Fatal("get schema failed: %v", err)
+		return nil, fmt.Errorf("get schema failed: %w", err)
 	}
 	if public {
 		witness, err := g.WitnessFull.Public()
 		if err != nil {
-			logger.Fatal("get public witness failed: %v", err)
+			return nil, fmt.Errorf("get public witness failed: %w", err)
 		}
 		witnessJson, err := witness.ToJSON(schama)
 		if err != nil {
-			logger.Fatal("get public witness json failed: %v", err)
-		}
-		return witnessJson
-	} else {
-		witnessJson, err := g.WitnessFull.ToJSON(schama)
-		if err != nil {
-			logger.Fatal("get witness json failed: %v", err)
+			return nil, fmt.Errorf("get public witness json failed: %w", err)
 		}
-		return witnessJson
+		return witnessJson, nil
+	}
+	witnessJson, err := g.WitnessFull.ToJSON(schama)
+	if err != nil {
+		return nil, fmt.Errorf("get witness json failed: %w", err)
 	}
+	return witnessJson, nil
 }
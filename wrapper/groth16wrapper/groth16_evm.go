@@ -0,0 +1,74 @@
+package groth16wrapper
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/oliverustc/gnarkabc/logger"
+	"github.com/oliverustc/gnarkabc/utils"
+	"github.com/oliverustc/gnarkabc/wrapper/evm"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	fr_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// VerifyOnEVM 将 Solidity 验证合约部署到进程内的模拟链上并调用 verifyProof，返回验证结果和消耗的 gas。
+// bytecode 为预编译的 Verifier 合约部署字节码，为空时使用本地的 solc 编译 ExportSolidity 的输出。
+// 合约拒绝证明时不返回错误，结果体现在 evm.Result.Success 中
+func (g *Groth16Wrapper) VerifyOnEVM(bytecode []byte) (evm.Result, error) {
+	if g.Curve != ecc.BN254 {
+		return evm.Result{}, fmt.Errorf("%w: only BN254 curve is supported", utils.ErrUnsupportedCurve)
+	}
+	if g.VK == nil {
+		return evm.Result{}, fmt.Errorf("%w: verifying key is nil", utils.ErrMissingSetup)
+	}
+	calldata, err := g.SolidityCalldata()
+	if err != nil {
+		return evm.Result{}, err
+	}
+	if len(bytecode) == 0 {
+		var source bytes.Buffer
+		if err := g.VK.ExportSolidity(&source); err != nil {
+			return evm.Result{}, fmt.Errorf("export solidity failed: %w", err)
+		}
+		if bytecode, err = evm.Compile(source.Bytes(), "Verifier"); err != nil {
+			return evm.Result{}, err
+		}
+	}
+	res, err := evm.Run(bytecode, calldata)
+	if err != nil {
+		return evm.Result{}, err
+	}
+	logger.Info("evm verification success: %v, gas used: %d", res.Success, res.GasUsed)
+	return res, nil
+}
+
+// SolidityCalldata 按 verifyProof 的 ABI 编码调用数据，参数均为定长数组，依次拼接即可
+func (g *Groth16Wrapper) SolidityCalldata() ([]byte, error) {
+	proofBytes, err := g.solidityProof()
+	if err != nil {
+		return nil, err
+	}
+	bPublicWitness, err := g.solidityPublicWitness()
+	if err != nil {
+		return nil, err
+	}
+	nbInputs := len(bPublicWitness) / fr_bn254.Bytes
+	params := []string{"uint256[8]"}
+	calldata := append([]byte{}, proofBytes[:FpSize*8]...)
+	if len(proofBytes) > FpSize*8 {
+		// 证明中的承诺数量占 4 个字节，之后是承诺和承诺的知识证明
+		nbCommitments, err := g.GenNbCommitments()
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, fmt.Sprintf("uint256[%d]", 2*nbCommitments), "uint256[2]")
+		calldata = append(calldata, proofBytes[FpSize*8+4:]...)
+	}
+	params = append(params, fmt.Sprintf("uint256[%d]", nbInputs))
+	calldata = append(calldata, bPublicWitness...)
+	selector := crypto.Keccak256([]byte("verifyProof(" + strings.Join(params, ",") + ")"))[:4]
+	return append(selector, calldata...), nil
+}
@@ -0,0 +1,254 @@
+package groth16wrapper
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oliverustc/gnarkabc/utils"
+	"github.com/oliverustc/gnarkabc/wrapper/snarkjs"
+	"github.com/oliverustc/gnarkabc/wrapper/store"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestGroth16VerifyOnEVM(t *testing.T) {
+	g := proveProduct(t, ecc.BN254)
+	calldata, err := g.SolidityCalldata()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 乘积电路只有一个公开输入
+	if len(calldata) != 4+32*(8+1) {
+		t.Fatalf("unexpected calldata length %d", len(calldata))
+	}
+	if selector := crypto.Keccak256([]byte("verifyProof(uint256[8],uint256[1])"))[:4]; string(calldata[:4]) != string(selector) {
+		t.Fatalf("unexpected selector %x", calldata[:4])
+	}
+
+	if _, err := exec.LookPath("solc"); err != nil {
+		t.Skip("solc not found, skip verification with the exported verifier, see TestGroth16VerifyOnEVMFixture")
+	}
+	res, err := g.VerifyOnEVM(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Success {
+		t.Fatal("verifier rejected a valid proof")
+	}
+	t.Logf("gas used: %d", res.GasUsed)
+
+	// 修改公开输入后验证失败
+	public, err := snarkjs.ImportPublic(snarkjs.Public{"222"}, ecc.BN254)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.WitnessPublic = public
+	if res, err = g.VerifyOnEVM(nil); err != nil {
+		t.Fatal(err)
+	}
+	if res.Success {
+		t.Fatal("verifier accepted a wrong public input")
+	}
+}
+
+// evmFixtures 存放固定的验证密钥、证明、公开见证者和验证合约的部署字节码
+var evmFixtures = filepath.Join("testdata", "evm")
+
+// 以 testdata/evm 中预编译的验证合约验证固定的证明，不需要 solc。
+// 合约由 assembleVerifier 从验证密钥生成，与导出的 Solidity 合约一样调用 ecAdd、ecMul 和 ecPairing 预编译合约，
+// 因此检查了 SolidityCalldata 中证明和公开输入的编码；go test -update 重新生成全部文件
+func TestGroth16VerifyOnEVMFixture(t *testing.T) {
+	fixtures := store.NewFS(evmFixtures)
+	if *updateFixtures {
+		g := proveProduct(t, ecc.BN254)
+		g.SetStore(fixtures)
+		if err := g.WriteVK("vk"); err != nil {
+			t.Fatal(err)
+		}
+		if err := g.WriteProof("proof"); err != nil {
+			t.Fatal(err)
+		}
+		if err := g.WriteWitness("public", true); err != nil {
+			t.Fatal(err)
+		}
+		bytecode, err := assembleVerifier(g.VK.(*groth16_bn254.VerifyingKey))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(evmFixtures, "verifier.hex"), []byte(hex.EncodeToString(bytecode)+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	g := NewWrapper(nil, ecc.BN254)
+	g.SetStore(fixtures)
+	if err := g.ReadVK("vk"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.ReadProof("proof"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.ReadWitness("public", true); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(evmFixtures, "verifier.hex"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bytecode := common.FromHex(strings.TrimSpace(string(data)))
+	if expected, err := assembleVerifier(g.VK.(*groth16_bn254.VerifyingKey)); err != nil || !bytes.Equal(expected, bytecode) {
+		t.Fatalf("verifier.hex does not match the verifying key: %v", err)
+	}
+
+	res, err := g.VerifyOnEVM(bytecode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Success {
+		t.Fatal("verifier rejected a valid proof")
+	}
+	t.Logf("gas used: %d", res.GasUsed)
+
+	// 修改公开输入或证明后验证失败
+	public, err := snarkjs.ImportPublic(snarkjs.Public{"222"}, ecc.BN254)
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid := g.WitnessPublic
+	g.WitnessPublic = public
+	if res, err = g.VerifyOnEVM(bytecode); err != nil {
+		t.Fatal(err)
+	}
+	if res.Success {
+		t.Fatal("verifier accepted a wrong public input")
+	}
+	g.WitnessPublic = valid
+	proof := g.Proof.(*groth16_bn254.Proof)
+	proof.Ar, proof.Krs = proof.Krs, proof.Ar
+	if res, err = g.VerifyOnEVM(bytecode); err != nil {
+		t.Fatal(err)
+	}
+	if res.Success {
+		t.Fatal("verifier accepted a wrong proof")
+	}
+}
+
+// assembleVerifier 从验证密钥汇编 Groth16 验证合约的部署字节码，合约检查
+// e(A, B) · e(C, -δ) · e(α, -β) · e(L, -γ) = 1，其中 L = IC₀ + Σ xᵢ·ICᵢ，验证失败时回滚。
+// 调用数据与导出的 Solidity 合约相同：选择子之后是 uint256[8] 的证明和 uint256[n] 的公开输入，不检查选择子
+func assembleVerifier(vk *groth16_bn254.VerifyingKey) ([]byte, error) {
+	if len(vk.PublicAndCommitmentCommitted) > 0 {
+		return nil, errors.New("verifying key has commitments")
+	}
+	var code []byte
+	push := func(v []byte) {
+		code = append(code, 0x5f+byte(len(v)))
+		code = append(code, v...)
+	}
+	push2 := func(v int) { push([]byte{byte(v >> 8), byte(v)}) }
+	mstore := func(offset int, v []byte) {
+		push(v)
+		push2(offset)
+		code = append(code, 0x52) // MSTORE
+	}
+	g1 := func(offset int, p bn254.G1Affine) {
+		x, y := p.X.Bytes(), p.Y.Bytes()
+		mstore(offset, x[:])
+		mstore(offset+0x20, y[:])
+	}
+	// EIP-197 的 G2 坐标先虚部后实部
+	g2 := func(offset int, p bn254.G2Affine) {
+		for i, e := range []fp.Element{p.X.A1, p.X.A0, p.Y.A1, p.Y.A0} {
+			b := e.Bytes()
+			mstore(offset+0x20*i, b[:])
+		}
+	}
+	calldatacopy := func(dst, src, size int) {
+		push2(size)
+		push2(src)
+		push2(dst)
+		code = append(code, 0x37) // CALLDATACOPY
+	}
+	mcopy := func(dst, src int) {
+		push2(src)
+		code = append(code, 0x51) // MLOAD
+		push2(dst)
+		code = append(code, 0x52) // MSTORE
+	}
+	const revertDest = 3
+	// STATICCALL 失败时跳转到回滚
+	staticcall := func(precompile byte, in, inSize, out, outSize int) {
+		push2(outSize)
+		push2(out)
+		push2(inSize)
+		push2(in)
+		push([]byte{precompile})
+		code = append(code, 0x5a, 0xfa) // GAS STATICCALL
+		code = append(code, 0x15)       // ISZERO
+		push([]byte{revertDest})
+		code = append(code, 0x57) // JUMPI
+	}
+	// 0x00: PUSH1 8 JUMP; 0x03: JUMPDEST PUSH1 0 DUP1 REVERT; 0x08: JUMPDEST
+	code = append(code, 0x60, 0x08, 0x56, 0x5b, 0x60, 0x00, 0x80, 0xfd, 0x5b)
+
+	// 配对的输入依次为 A, B, C, -δ, α, -β, L, -γ，每对 0xc0 字节
+	calldatacopy(0x00, 0x04, 0x40)
+	calldatacopy(0x40, 0x44, 0x80)
+	calldatacopy(0xc0, 0xc4, 0x40)
+	var neg bn254.G2Affine
+	g2(0x100, *neg.Neg(&vk.G2.Delta))
+	g1(0x180, vk.G1.Alpha)
+	g2(0x1c0, *neg.Neg(&vk.G2.Beta))
+	g2(0x280, *neg.Neg(&vk.G2.Gamma))
+
+	// L 写入 0x240，ecMul 的输入为 0x300 起的 (ICᵢ, xᵢ)，结果写入 0x380 之后，与 L 一起作为 ecAdd 的输入
+	const scratch = 0x300
+	g1(0x240, vk.G1.K[0])
+	for i := 1; i < len(vk.G1.K); i++ {
+		g1(scratch, vk.G1.K[i])
+		calldatacopy(scratch+0x40, 0x104+0x20*(i-1), 0x20)
+		staticcall(0x07, scratch, 0x60, scratch+0x80, 0x40)
+		// ecAdd(L, xᵢ·ICᵢ)：将 L 复制到乘积之前
+		mcopy(scratch+0x40, 0x240)
+		mcopy(scratch+0x60, 0x260)
+		staticcall(0x06, scratch+0x40, 0x80, 0x240, 0x40)
+	}
+
+	staticcall(0x08, 0x00, 0x300, 0x00, 0x20)
+	// 配对检查的结果为 1 时结束，否则回滚
+	push2(0x00)
+	code = append(code, 0x51, 0x15) // MLOAD ISZERO
+	push([]byte{revertDest})
+	code = append(code, 0x57, 0x00) // JUMPI STOP
+
+	// 部署代码将运行时代码复制到内存并返回
+	deploy := []byte{0x61, byte(len(code) >> 8), byte(len(code)), 0x80, 0x60, 0x0c, 0x60, 0x00, 0x39, 0x60, 0x00, 0xf3}
+	return append(deploy, code...), nil
+}
+
+func TestGroth16VerifyOnEVMErrors(t *testing.T) {
+	g := proveProduct(t, ecc.BLS12_381)
+	if _, err := g.VerifyOnEVM(nil); !errors.Is(err, utils.ErrUnsupportedCurve) {
+		t.Fatalf("expected ErrUnsupportedCurve, got %v", err)
+	}
+	g = NewWrapper(nil, ecc.BN254)
+	if _, err := g.VerifyOnEVM(nil); !errors.Is(err, utils.ErrMissingSetup) {
+		t.Fatalf("expected ErrMissingSetup, got %v", err)
+	}
+	// 部署回滚的字节码返回错误
+	g = proveProduct(t, ecc.BN254)
+	if _, err := g.VerifyOnEVM(common.FromHex("60006000fd")); err == nil {
+		t.Fatal("expected failed deployment to return an error")
+	}
+}
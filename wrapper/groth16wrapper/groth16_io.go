@@ -1,7 +1,7 @@
 package groth16wrapper
 
 import (
-	"os"
+	"fmt"
 
 	"github.com/oliverustc/gnarkabc/logger"
 	"github.com/oliverustc/gnarkabc/utils"
@@ -10,81 +10,89 @@ import (
 	"github.com/consensys/gnark/backend/witness"
 )
 
-func (g *Groth16Wrapper) WriteCCS(filePath string) {
+func (g *Groth16Wrapper) WriteCCS(filePath string) error {
 	if filePath == "" {
-		logger.Debug("CCS filePath is empty, using default output/ccs")
-		filePath = "output/ccs"
+		logger.Debug("CCS filePath is empty, using default ccs")
+		filePath = "ccs"
+	}
+	if g.CCS == nil {
+		return fmt.Errorf("%w: constraint system is nil", utils.ErrMissingSetup)
 	}
 	logger.Debug("Writing ccs to %s", filePath)
-	utils.EnsureDirExists("output")
-	file, err := os.Create(filePath)
+	file, err := g.GetStore().Create(filePath)
 	if err != nil {
-		logger.Error("failed to create file: %s", err.Error())
+		return &utils.IOError{Op: "create ccs file", Path: filePath, Err: err}
 	}
-	defer file.Close()
 	size, err := g.CCS.WriteTo(file)
 	if err != nil {
-		logger.Error("failed to write ccs to file: %s", err.Error())
+		file.Close()
+		return &utils.IOError{Op: "write ccs", Path: filePath, Err: err}
+	}
+	if err := file.Close(); err != nil {
+		return &utils.IOError{Op: "close ccs file", Path: filePath, Err: err}
 	}
 	logger.Debug("write ccs to %s, size= %d", filePath, size)
+	return nil
 }
 
-func (g *Groth16Wrapper) ReadCCS(filePath string) {
+func (g *Groth16Wrapper) ReadCCS(filePath string) error {
 	if filePath == "" {
-		logger.Debug("CCS filePath is empty, using default output/ccs")
-		filePath = "output/ccs"
+		logger.Debug("CCS filePath is empty, using default ccs")
+		filePath = "ccs"
 	}
 	logger.Debug("Reading ccs from %s", filePath)
-	utils.EnsureDirExists("output")
+	file, err := g.GetStore().Open(filePath)
+	if err != nil {
+		return &utils.IOError{Op: "open ccs file", Path: filePath, Err: err}
+	}
+	defer file.Close()
 
 	// 初始化 CCS
 	if g.CCS == nil {
 		g.CCS = groth16.NewCS(g.Curve)
 	}
-
-	file, err := os.Open(filePath)
-	if err != nil {
-		logger.Error("failed to open file: %s", err.Error())
-		return
-	}
-	defer file.Close()
-
 	size, err := g.CCS.ReadFrom(file)
 	if err != nil {
-		logger.Error("failed to read ccs from file: %s", err.Error())
+		return &utils.IOError{Op: "read ccs", Path: filePath, Err: err}
 	}
 	logger.Debug("read ccs from %s, size= %d", filePath, size)
+	return nil
 }
 
-func (g *Groth16Wrapper) WritePK(filePath string) {
+func (g *Groth16Wrapper) WritePK(filePath string) error {
 	if filePath == "" {
-		logger.Debug("Proving Key filePath is empty, using default output/pk")
-		filePath = "output/pk"
+		logger.Debug("Proving Key filePath is empty, using default pk")
+		filePath = "pk"
+	}
+	if g.PK == nil {
+		return fmt.Errorf("%w: proving key is nil", utils.ErrMissingSetup)
 	}
 	logger.Debug("Writing proving key to %s", filePath)
-	utils.EnsureDirExists("output")
-	file, err := os.Create(filePath)
+	file, err := g.GetStore().Create(filePath)
 	if err != nil {
-		logger.Error("failed to create file: %s", err.Error())
+		return &utils.IOError{Op: "create pk file", Path: filePath, Err: err}
 	}
-	defer file.Close()
 	size, err := g.PK.WriteTo(file)
 	if err != nil {
-		logger.Error("failed to write pk to file: %s", err.Error())
+		file.Close()
+		return &utils.IOError{Op: "write pk", Path: filePath, Err: err}
+	}
+	if err := file.Close(); err != nil {
+		return &utils.IOError{Op: "close pk file", Path: filePath, Err: err}
 	}
 	logger.Debug("wrote proving key to %s, size= %d", filePath, size)
+	return nil
 }
 
-func (g *Groth16Wrapper) ReadPK(filePath string) {
+func (g *Groth16Wrapper) ReadPK(filePath string) error {
 	if filePath == "" {
-		logger.Debug("Proving Key filePath is empty, using default output/pk")
-		filePath = "output/pk"
+		logger.Debug("Proving Key filePath is empty, using default pk")
+		filePath = "pk"
 	}
 	logger.Debug("Reading proving key from %s", filePath)
-	utils.EnsureDirExists("output")
-	file, err := os.Open(filePath)
+	file, err := g.GetStore().Open(filePath)
 	if err != nil {
-		logger.Fatal("open file failed: %v", err)
+		return &utils.IOError{Op: "open pk file", Path: filePath, Err: err}
 	}
 	defer file.Close()
 	if g.PK == nil {
@@ -92,40 +100,46 @@ func (g *Groth16Wrapper) ReadPK(filePath string) {
 	}
 	size, err := g.PK.ReadFrom(file)
 	if err != nil {
-		logger.Error("failed to write pk to file: %s", err.Error())
+		return &utils.IOError{Op: "read pk", Path: filePath, Err: err}
 	}
 	logger.Debug("read proving key from %s, size= %d", filePath, size)
+	return nil
 }
 
-func (g *Groth16Wrapper) WriteVK(filePath string) {
+func (g *Groth16Wrapper) WriteVK(filePath string) error {
 	if filePath == "" {
-		logger.Debug("Verification Key filePath is empty, using default output/vk")
-		filePath = "output/vk"
+		logger.Debug("Verification Key filePath is empty, using default vk")
+		filePath = "vk"
+	}
+	if g.VK == nil {
+		return fmt.Errorf("%w: verifying key is nil", utils.ErrMissingSetup)
 	}
 	logger.Debug("Writing verification key to %s", filePath)
-	utils.EnsureDirExists("output")
-	file, err := os.Create(filePath)
+	file, err := g.GetStore().Create(filePath)
 	if err != nil {
-		logger.Error("failed to create file: %s", err.Error())
+		return &utils.IOError{Op: "create vk file", Path: filePath, Err: err}
 	}
-	defer file.Close()
 	size, err := g.VK.WriteTo(file)
 	if err != nil {
-		logger.Error("failed to write vk to file: %s", err.Error())
+		file.Close()
+		return &utils.IOError{Op: "write vk", Path: filePath, Err: err}
+	}
+	if err := file.Close(); err != nil {
+		return &utils.IOError{Op: "close vk file", Path: filePath, Err: err}
 	}
 	logger.Debug("wrote verification key to %s, size= %d", filePath, size)
+	return nil
 }
 
-func (g *Groth16Wrapper) ReadVK(filePath string) {
+func (g *Groth16Wrapper) ReadVK(filePath string) error {
 	if filePath == "" {
-		logger.Debug("Verification Key filePath is empty, using default output/vk")
-		filePath = "output/vk"
+		logger.Debug("Verification Key filePath is empty, using default vk")
+		filePath = "vk"
 	}
 	logger.Debug("Reading verification key from %s", filePath)
-	utils.EnsureDirExists("output")
-	file, err := os.Open(filePath)
+	file, err := g.GetStore().Open(filePath)
 	if err != nil {
-		logger.Fatal("open file failed: %v", err)
+		return &utils.IOError{Op: "open vk file", Path: filePath, Err: err}
 	}
 	defer file.Close()
 	if g.VK == nil {
@@ -133,56 +147,65 @@ func (g *Groth16Wrapper) ReadVK(filePath string) {
 	}
 	size, err := g.VK.ReadFrom(file)
 	if err != nil {
-		logger.Error("failed to write vk to file: %s", err.Error())
+		return &utils.IOError{Op: "read vk", Path: filePath, Err: err}
 	}
 	logger.Debug("read verification key from %s, size= %d", filePath, size)
+	return nil
 }
 
-func (g *Groth16Wrapper) WriteWitness(filePath string, public bool) {
+func (g *Groth16Wrapper) WriteWitness(filePath string, public bool) error {
 	if filePath == "" {
 		if public {
-			logger.Debug("Witness filePath is empty, using default output/public_witness")
-			filePath = "output/public_witness"
+			logger.Debug("Witness filePath is empty, using default public_witness")
+			filePath = "public_witness"
 		} else {
-			logger.Debug("Witness filePath is empty, using default output/witness")
-			filePath = "output/witness"
+			logger.Debug("Witness filePath is empty, using default witness")
+			filePath = "witness"
 		}
 	}
+	w := g.WitnessFull
 	if public {
+		if g.WitnessPublic == nil {
+			if err := g.GenerateWitness(true); err != nil {
+				return err
+			}
+		}
+		w = g.WitnessPublic
 		logger.Debug("Writing public witness to %s", filePath)
 	} else {
+		if w == nil {
+			return utils.ErrMissingAssignment
+		}
 		logger.Debug("Writing witness to %s", filePath)
 	}
-	utils.EnsureDirExists("output")
-	file, err := os.Create(filePath)
+	file, err := g.GetStore().Create(filePath)
 	if err != nil {
-		logger.Error("failed to create file: %s", err.Error())
-	}
-	defer file.Close()
-	var size int64
-	if public {
-		size, err = g.WitnessPublic.WriteTo(file)
-	} else {
-		size, err = g.WitnessFull.WriteTo(file)
+		return &utils.IOError{Op: "create witness file", Path: filePath, Err: err}
 	}
+	size, err := w.WriteTo(file)
 	if err != nil {
-		logger.Error("failed to write witness to file: %s", err.Error())
+		file.Close()
+		return &utils.IOError{Op: "write witness", Path: filePath, Err: err}
+	}
+	if err := file.Close(); err != nil {
+		return &utils.IOError{Op: "close witness file", Path: filePath, Err: err}
 	}
 	if public {
 		logger.Debug("wrote public witness to %s, size= %d", filePath, size)
 	} else {
 		logger.Debug("wrote witness to %s, size= %d", filePath, size)
 	}
+	return nil
 }
 
-func (g *Groth16Wrapper) ReadWitness(filePath string, public bool) {
+func (g *Groth16Wrapper) ReadWitness(filePath string, public bool) error {
 	if filePath == "" {
 		if public {
-			logger.Debug("Witness filePath is empty, using default output/public_witness")
-			filePath = "output/public_witness"
+			logger.Debug("Witness filePath is empty, using default public_witness")
+			filePath = "public_witness"
 		} else {
-			logger.Debug("Witness filePath is empty, using default output/witness")
-			filePath = "output/witness"
+			logger.Debug("Witness filePath is empty, using default witness")
+			filePath = "witness"
 		}
 	}
 	if public {
@@ -190,70 +213,63 @@ func (g *Groth16Wrapper) ReadWitness(filePath string, public bool) {
 	} else {
 		logger.Debug("Reading witness from %s", filePath)
 	}
-	utils.EnsureDirExists("output")
-	file, err := os.Open(filePath)
+	file, err := g.GetStore().Open(filePath)
 	if err != nil {
-		logger.Fatal("open file failed: %v", err)
+		return &utils.IOError{Op: "open witness file", Path: filePath, Err: err}
 	}
 	defer file.Close()
-	var size int64
-	if public {
-		if g.WitnessPublic == nil {
-			g.WitnessPublic, err = witness.New(g.Field)
-			if err != nil {
-				logger.Fatal("failed to create witness: %s", err.Error())
-			}
-		}
-		size, err = g.WitnessPublic.ReadFrom(file)
-	} else {
-		if g.WitnessFull == nil {
-			g.WitnessFull, err = witness.New(g.Field)
-			if err != nil {
-				logger.Fatal("failed to create witness: %s", err.Error())
-			}
-		}
-		size, err = g.WitnessFull.ReadFrom(file)
+	w, err := witness.New(g.Field)
+	if err != nil {
+		return fmt.Errorf("failed to create witness: %w", err)
 	}
+	size, err := w.ReadFrom(file)
 	if err != nil {
-		logger.Error("failed to read witness from file: %s", err.Error())
-		return
+		return &utils.IOError{Op: "read witness", Path: filePath, Err: err}
 	}
 	if public {
+		g.WitnessPublic = w
 		logger.Debug("read public witness from %s, size= %d", filePath, size)
 	} else {
+		g.WitnessFull = w
 		logger.Debug("read witness from %s, size= %d", filePath, size)
 	}
+	return nil
 }
 
-func (g *Groth16Wrapper) WriteProof(filePath string) {
+func (g *Groth16Wrapper) WriteProof(filePath string) error {
 	if filePath == "" {
-		logger.Debug("Proof filePath is empty, using default output/proof")
-		filePath = "output/proof"
+		logger.Debug("Proof filePath is empty, using default proof")
+		filePath = "proof"
+	}
+	if g.Proof == nil {
+		return fmt.Errorf("%w: proof is nil", utils.ErrInvalidProof)
 	}
 	logger.Debug("Writing proof to %s", filePath)
-	utils.EnsureDirExists("output")
-	file, err := os.Create(filePath)
+	file, err := g.GetStore().Create(filePath)
 	if err != nil {
-		logger.Error("failed to create file: %s", err.Error())
+		return &utils.IOError{Op: "create proof file", Path: filePath, Err: err}
 	}
-	defer file.Close()
 	size, err := g.Proof.WriteTo(file)
 	if err != nil {
-		logger.Error("failed to write proof to file: %s", err.Error())
+		file.Close()
+		return &utils.IOError{Op: "write proof", Path: filePath, Err: err}
+	}
+	if err := file.Close(); err != nil {
+		return &utils.IOError{Op: "close proof file", Path: filePath, Err: err}
 	}
 	logger.Debug("wrote proof to %s, size= %d", filePath, size)
+	return nil
 }
 
-func (g *Groth16Wrapper) ReadProof(filePath string) {
+func (g *Groth16Wrapper) ReadProof(filePath string) error {
 	if filePath == "" {
-		logger.Debug("Proof filePath is empty, using default output/proof")
-		filePath = "output/proof"
+		logger.Debug("Proof filePath is empty, using default proof")
+		filePath = "proof"
 	}
 	logger.Debug("Reading proof from %s", filePath)
-	utils.EnsureDirExists("output")
-	file, err := os.Open(filePath)
+	file, err := g.GetStore().Open(filePath)
 	if err != nil {
-		logger.Fatal("open file failed: %v", err)
+		return &utils.IOError{Op: "open proof file", Path: filePath, Err: err}
 	}
 	defer file.Close()
 	if g.Proof == nil {
@@ -261,7 +277,8 @@ func (g *Groth16Wrapper) ReadProof(filePath string) {
 	}
 	size, err := g.Proof.ReadFrom(file)
 	if err != nil {
-		logger.Error("failed to write proof to file: %s", err.Error())
+		return &utils.IOError{Op: "read proof", Path: filePath, Err: err}
 	}
 	logger.Debug("read proof from %s, size= %d", filePath, size)
+	return nil
 }
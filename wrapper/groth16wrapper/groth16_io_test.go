@@ -1,11 +1,17 @@
 package groth16wrapper
 
 import (
+	"errors"
+	"io/fs"
+	"slices"
 	"testing"
 
 	"github.com/oliverustc/gnarkabc/circuits"
 	"github.com/oliverustc/gnarkabc/logger"
 	"github.com/oliverustc/gnarkabc/utils"
+	"github.com/oliverustc/gnarkabc/wrapper/store"
+
+	"github.com/consensys/gnark-crypto/ecc"
 )
 
 func TestGroth16Write(t *testing.T) {
@@ -13,21 +19,41 @@ func TestGroth16Write(t *testing.T) {
 	for _, curveName := range utils.CurveNameList {
 		curve := utils.CurveMap[curveName]
 		var circuit circuits.Product
-		circuit.PreCompile(nil)
+		circuit.PreCompile(circuits.NoParams{})
 		zk := NewWrapper(&circuit, curve)
-		zk.Compile()
-		zk.Setup()
-		assignParams := []any{13, 17}
+		if err := zk.Compile(); err != nil {
+			t.Fatal(err)
+		}
+		if err := zk.Setup(); err != nil {
+			t.Fatal(err)
+		}
+		assignParams := circuits.ProductAssign{P: 13, Q: 17}
 		circuit.Assign(assignParams)
 		zk.SetAssignment(&circuit)
-		zk.Prove()
-		zk.Verify()
-		zk.WriteCCS("output/ccs_" + curveName)
-		zk.WritePK("output/pk_" + curveName)
-		zk.WriteVK("output/vk_" + curveName)
-		zk.WriteWitness("output/witness_"+curveName, false)
-		zk.WriteWitness("output/public_witness_"+curveName, true)
-		zk.WriteProof("output/proof_" + curveName)
+		if err := zk.Prove(); err != nil {
+			t.Fatal(err)
+		}
+		if err := zk.Verify(); err != nil {
+			t.Fatal(err)
+		}
+		if err := zk.WriteCCS("ccs_" + curveName); err != nil {
+			t.Fatal(err)
+		}
+		if err := zk.WritePK("pk_" + curveName); err != nil {
+			t.Fatal(err)
+		}
+		if err := zk.WriteVK("vk_" + curveName); err != nil {
+			t.Fatal(err)
+		}
+		if err := zk.WriteWitness("witness_"+curveName, false); err != nil {
+			t.Fatal(err)
+		}
+		if err := zk.WriteWitness("public_witness_"+curveName, true); err != nil {
+			t.Fatal(err)
+		}
+		if err := zk.WriteProof("proof_" + curveName); err != nil {
+			t.Fatal(err)
+		}
 		logger.Info("write params success on [ %s ]", curveName)
 	}
 }
@@ -36,19 +62,96 @@ func TestGroth16Read(t *testing.T) {
 	for _, curveName := range utils.CurveNameList {
 		curve := utils.CurveMap[curveName]
 		var circuit circuits.Product
-		circuit.PreCompile(nil)
+		circuit.PreCompile(circuits.NoParams{})
 		zk := NewWrapper(&circuit, curve)
-		zk.ReadCCS("output/ccs_" + curveName)
-		zk.ReadPK("output/pk_" + curveName)
-		zk.ReadVK("output/vk_" + curveName)
-		zk.ReadWitness("output/witness_"+curveName, false)
+		if err := zk.ReadCCS("ccs_" + curveName); err != nil {
+			t.Fatal(err)
+		}
+		if err := zk.ReadPK("pk_" + curveName); err != nil {
+			t.Fatal(err)
+		}
+		if err := zk.ReadVK("vk_" + curveName); err != nil {
+			t.Fatal(err)
+		}
+		if err := zk.ReadWitness("witness_"+curveName, false); err != nil {
+			t.Fatal(err)
+		}
 		// 首先基于已有参数自行prove和verify
-		zk.Prove()
-		zk.ReadWitness("output/public_witness_"+curveName, true)
-		zk.Verify()
+		if err := zk.Prove(); err != nil {
+			t.Fatal(err)
+		}
+		if err := zk.ReadWitness("public_witness_"+curveName, true); err != nil {
+			t.Fatal(err)
+		}
+		if err := zk.Verify(); err != nil {
+			t.Fatal(err)
+		}
 		// 然后读取已有的proof仅进行验证
-		zk.ReadProof("output/proof_" + curveName)
-		zk.Verify()
+		if err := zk.ReadProof("proof_" + curveName); err != nil {
+			t.Fatal(err)
+		}
+		if err := zk.Verify(); err != nil {
+			t.Fatal(err)
+		}
 		logger.Info("prove and verify success on [ %s ] after read params", curveName)
 	}
 }
+
+func TestGroth16MemoryStore(t *testing.T) {
+	mem := store.NewMemory()
+	var circuit circuits.Product
+	circuit.PreCompile(circuits.NoParams{})
+	zk := NewWrapper(&circuit, ecc.BN254)
+	zk.SetStore(mem)
+	if err := zk.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	if err := zk.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	circuit.Assign(circuits.ProductAssign{P: 13, Q: 17})
+	zk.SetAssignment(&circuit)
+	if err := zk.Prove(); err != nil {
+		t.Fatal(err)
+	}
+	// 文件名为空时使用默认名称
+	for _, write := range []func(string) error{zk.WriteCCS, zk.WriteVK, zk.WriteProof} {
+		if err := write(""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zk.WriteWitness("", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := zk.ExportSolidity(""); err != nil {
+		t.Fatal(err)
+	}
+	names, err := mem.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(names, []string{"Groth16Verifier.sol", "ccs", "proof", "public_witness", "vk"}) {
+		t.Fatalf("unexpected artifacts %v", names)
+	}
+
+	verifier := NewWrapper(&circuit, ecc.BN254)
+	verifier.SetStore(mem)
+	if err := verifier.ReadCCS(""); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifier.ReadVK(""); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifier.ReadProof(""); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifier.ReadWitness("", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifier.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifier.ReadPK(""); !errors.Is(err, utils.ErrIO) || !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected missing pk, got %v", err)
+	}
+}
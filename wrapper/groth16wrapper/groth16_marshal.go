@@ -3,13 +3,18 @@ package groth16wrapper
 import (
 	"bytes"
 	"encoding/base64"
+	"fmt"
 
 	"github.com/consensys/gnark/backend/groth16"
 	"github.com/consensys/gnark/backend/witness"
 	"github.com/oliverustc/gnarkabc/logger"
+	"github.com/oliverustc/gnarkabc/utils"
 )
 
 func (g *Groth16Wrapper) MarshalCCS() ([]byte, error) {
+	if g.CCS == nil {
+		return nil, fmt.Errorf("%w: constraint system is nil", utils.ErrMissingSetup)
+	}
 	var buf bytes.Buffer
 	size, err := g.CCS.WriteTo(&buf)
 	if err != nil {
@@ -46,6 +51,9 @@ func (g *Groth16Wrapper) UnmarshalCCSFromStr(str string) error {
 }
 
 func (g *Groth16Wrapper) MarshalPK() ([]byte, error) {
+	if g.PK == nil {
+		return nil, fmt.Errorf("%w: proving key is nil", utils.ErrMissingSetup)
+	}
 	var buf bytes.Buffer
 	size, err := g.PK.WriteTo(&buf)
 	if err != nil {
@@ -82,6 +90,9 @@ func (g *Groth16Wrapper) UnmarshalPKFromStr(str string) error {
 }
 
 func (g *Groth16Wrapper) MarshalVK() ([]byte, error) {
+	if g.VK == nil {
+		return nil, fmt.Errorf("%w: verifying key is nil", utils.ErrMissingSetup)
+	}
 	var buf bytes.Buffer
 	size, err := g.VK.WriteTo(&buf)
 	if err != nil {
@@ -178,6 +189,9 @@ func (g *Groth16Wrapper) UnmarshalWitnessFromStr(str string, public bool) error
 }
 
 func (g *Groth16Wrapper) MarshalProof() ([]byte, error) {
+	if g.Proof == nil {
+		return nil, fmt.Errorf("%w: proof is nil", utils.ErrInvalidProof)
+	}
 	var buf bytes.Buffer
 	size, err := g.Proof.WriteTo(&buf)
 	if err != nil {
@@ -24,15 +24,23 @@ func TestGroth16Marshal(t *testing.T) {
 		logger.Info("marshal params on curve: %s", curveName)
 		curve := utils.CurveMap[curveName]
 		var circuit circuits.Product
-		circuit.PreCompile(nil)
+		circuit.PreCompile(circuits.NoParams{})
 		zk := NewWrapper(&circuit, curve)
-		zk.Compile()
-		zk.Setup()
-		assignParams := []any{13, 17}
+		if err := zk.Compile(); err != nil {
+			t.Fatal(err)
+		}
+		if err := zk.Setup(); err != nil {
+			t.Fatal(err)
+		}
+		assignParams := circuits.ProductAssign{P: 13, Q: 17}
 		circuit.Assign(assignParams)
 		zk.SetAssignment(&circuit)
-		zk.Prove()
-		zk.Verify()
+		if err := zk.Prove(); err != nil {
+			t.Fatal(err)
+		}
+		if err := zk.Verify(); err != nil {
+			t.Fatal(err)
+		}
 
 		ccsStr, _ := zk.MarshalCCSToStr()
 		pkStr, _ := zk.MarshalPKToStr()
@@ -41,7 +49,7 @@ func TestGroth16Marshal(t *testing.T) {
 		witnessPublicStr, _ := zk.MarshalWitnessToStr(true)
 		proofStr, _ := zk.MarshalProofToStr()
 		// write str to a json file
-		jsonFile, _ := os.Create("output/groth16_params_" + curveName + ".json")
+		jsonFile, _ := zk.GetStore().Create("groth16_params_" + curveName + ".json")
 		groth16Params := Groth16Params{
 			CCS:           ccsStr,
 			PK:            pkStr,
@@ -61,7 +69,7 @@ func TestGroth16Unmarshal(t *testing.T) {
 		logger.Info("unmarshal params on curve: %s", curveName)
 		curve := utils.CurveMap[curveName]
 		var circuit circuits.Product
-		circuit.PreCompile(nil)
+		circuit.PreCompile(circuits.NoParams{})
 		zk := NewWrapper(&circuit, curve)
 		jsonData, err := os.ReadFile("output/groth16_params_" + curveName + ".json")
 		if err != nil {
@@ -80,7 +88,9 @@ func TestGroth16Unmarshal(t *testing.T) {
 		zk.UnmarshalWitnessFromStr(groth16Params.Witness, false)
 		zk.UnmarshalWitnessFromStr(groth16Params.WitnessPublic, true)
 		zk.UnmarshalProofFromStr(groth16Params.Proof)
-		zk.Verify()
+		if err := zk.Verify(); err != nil {
+			t.Fatal(err)
+		}
 		logger.Info("verify success on curve: %s", curveName)
 	}
 }
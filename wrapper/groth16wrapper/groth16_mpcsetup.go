@@ -0,0 +1,357 @@
+package groth16wrapper
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/oliverustc/gnarkabc/logger"
+	"github.com/oliverustc/gnarkabc/utils"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	bls12_377mpc "github.com/consensys/gnark/backend/groth16/bls12-377/mpcsetup"
+	bls12_381mpc "github.com/consensys/gnark/backend/groth16/bls12-381/mpcsetup"
+	bls24_315mpc "github.com/consensys/gnark/backend/groth16/bls24-315/mpcsetup"
+	bls24_317mpc "github.com/consensys/gnark/backend/groth16/bls24-317/mpcsetup"
+	bn254mpc "github.com/consensys/gnark/backend/groth16/bn254/mpcsetup"
+	bw6_633mpc "github.com/consensys/gnark/backend/groth16/bw6-633/mpcsetup"
+	bw6_761mpc "github.com/consensys/gnark/backend/groth16/bw6-761/mpcsetup"
+	"github.com/consensys/gnark/constraint"
+)
+
+// Groth16 多方可信设置仪式，基于 gnark 的 backend/groth16/<curve>/mpcsetup。
+// 流程如下，所有中间结果均以文件交换，参与者可以离线传递：
+//  1. 协调者调用 InitPhase1 生成初始的 phase1 文件
+//  2. 参与者依次调用 ContributePhase1，读取上一份文件并写出自己的贡献
+//  3. 协调者调用 VerifyPhase1 校验贡献链，并以 beacon 封装出与电路无关的 SRS 公共参数
+//  4. 协调者调用 Groth16Wrapper.InitPhase2，基于电路和公共参数生成初始的 phase2 文件
+//  5. 参与者依次调用 ContributePhase2
+//  6. 协调者调用 Groth16Wrapper.SealCeremony 校验贡献链，并封装得到 PK/VK
+
+// contribution 是各曲线 Phase1/Phase2 的公共方法
+type contribution interface {
+	io.ReaderFrom
+	io.WriterTo
+	Contribute()
+}
+
+// CeremonyDomainSize 返回电路所需的 phase1 规模，即不小于约束数量（且至少为 2）的最小 2 的幂
+func (g *Groth16Wrapper) CeremonyDomainSize() (uint64, error) {
+	if g.CCS == nil {
+		return 0, fmt.Errorf("%w: constraint system is nil, compile first", utils.ErrMissingSetup)
+	}
+	return ecc.NextPowerOfTwo(uint64(max(g.CCS.GetNbConstraints(), 2))), nil
+}
+
+// InitPhase1 生成规模为 domainSize 的初始 phase1 文件，domainSize 必须是不小于 2 的 2 的幂
+func InitPhase1(curve ecc.ID, domainSize uint64, outPath string) error {
+	if domainSize < 2 || ecc.NextPowerOfTwo(domainSize) != domainSize {
+		return fmt.Errorf("phase1 domain size %d is not a power of 2 greater than 1", domainSize)
+	}
+	var p io.WriterTo
+	switch curve {
+	case ecc.BN254:
+		p = bn254mpc.NewPhase1(domainSize)
+	case ecc.BLS12_377:
+		p = bls12_377mpc.NewPhase1(domainSize)
+	case ecc.BLS12_381:
+		p = bls12_381mpc.NewPhase1(domainSize)
+	case ecc.BW6_761:
+		p = bw6_761mpc.NewPhase1(domainSize)
+	case ecc.BLS24_315:
+		p = bls24_315mpc.NewPhase1(domainSize)
+	case ecc.BW6_633:
+		p = bw6_633mpc.NewPhase1(domainSize)
+	case ecc.BLS24_317:
+		p = bls24_317mpc.NewPhase1(domainSize)
+	default:
+		return fmt.Errorf("%w: %s", utils.ErrUnsupportedCurve, curve.String())
+	}
+	return writeObject(outPath, "write phase1", p)
+}
+
+// ContributePhase1 读取上一份 phase1 文件，加入本地随机数后写出新的贡献
+func ContributePhase1(curve ecc.ID, inPath, outPath string) error {
+	var p contribution
+	switch curve {
+	case ecc.BN254:
+		p = new(bn254mpc.Phase1)
+	case ecc.BLS12_377:
+		p = new(bls12_377mpc.Phase1)
+	case ecc.BLS12_381:
+		p = new(bls12_381mpc.Phase1)
+	case ecc.BW6_761:
+		p = new(bw6_761mpc.Phase1)
+	case ecc.BLS24_315:
+		p = new(bls24_315mpc.Phase1)
+	case ecc.BW6_633:
+		p = new(bw6_633mpc.Phase1)
+	case ecc.BLS24_317:
+		p = new(bls24_317mpc.Phase1)
+	default:
+		return fmt.Errorf("%w: %s", utils.ErrUnsupportedCurve, curve.String())
+	}
+	return contribute(p, "phase1", inPath, outPath)
+}
+
+// VerifyPhase1 按顺序校验 phase1 贡献链，以 beacon 封装后将 SRS 公共参数写入 commonsPath
+func VerifyPhase1(curve ecc.ID, domainSize uint64, beacon []byte, commonsPath string, contributionPaths ...string) error {
+	switch curve {
+	case ecc.BN254:
+		return sealPhase1(domainSize, beacon, commonsPath, contributionPaths, bn254mpc.VerifyPhase1)
+	case ecc.BLS12_377:
+		return sealPhase1(domainSize, beacon, commonsPath, contributionPaths, bls12_377mpc.VerifyPhase1)
+	case ecc.BLS12_381:
+		return sealPhase1(domainSize, beacon, commonsPath, contributionPaths, bls12_381mpc.VerifyPhase1)
+	case ecc.BW6_761:
+		return sealPhase1(domainSize, beacon, commonsPath, contributionPaths, bw6_761mpc.VerifyPhase1)
+	case ecc.BLS24_315:
+		return sealPhase1(domainSize, beacon, commonsPath, contributionPaths, bls24_315mpc.VerifyPhase1)
+	case ecc.BW6_633:
+		return sealPhase1(domainSize, beacon, commonsPath, contributionPaths, bw6_633mpc.VerifyPhase1)
+	case ecc.BLS24_317:
+		return sealPhase1(domainSize, beacon, commonsPath, contributionPaths, bls24_317mpc.VerifyPhase1)
+	}
+	return fmt.Errorf("%w: %s", utils.ErrUnsupportedCurve, curve.String())
+}
+
+// InitPhase2 基于已编译的电路和 SRS 公共参数生成初始的 phase2 文件
+func (g *Groth16Wrapper) InitPhase2(commonsPath, outPath string) error {
+	if g.CCS == nil {
+		return fmt.Errorf("%w: constraint system is nil, compile first", utils.ErrMissingSetup)
+	}
+	var p io.WriterTo
+	var err error
+	switch g.Curve {
+	case ecc.BN254:
+		p, err = initPhase2(g.CCS, g.Curve, commonsPath, func(c *bn254mpc.SrsCommons) int { return len(c.G1.AlphaTau) }, (*bn254mpc.Phase2).Initialize)
+	case ecc.BLS12_377:
+		p, err = initPhase2(g.CCS, g.Curve, commonsPath, func(c *bls12_377mpc.SrsCommons) int { return len(c.G1.AlphaTau) }, (*bls12_377mpc.Phase2).Initialize)
+	case ecc.BLS12_381:
+		p, err = initPhase2(g.CCS, g.Curve, commonsPath, func(c *bls12_381mpc.SrsCommons) int { return len(c.G1.AlphaTau) }, (*bls12_381mpc.Phase2).Initialize)
+	case ecc.BW6_761:
+		p, err = initPhase2(g.CCS, g.Curve, commonsPath, func(c *bw6_761mpc.SrsCommons) int { return len(c.G1.AlphaTau) }, (*bw6_761mpc.Phase2).Initialize)
+	case ecc.BLS24_315:
+		p, err = initPhase2(g.CCS, g.Curve, commonsPath, func(c *bls24_315mpc.SrsCommons) int { return len(c.G1.AlphaTau) }, (*bls24_315mpc.Phase2).Initialize)
+	case ecc.BW6_633:
+		p, err = initPhase2(g.CCS, g.Curve, commonsPath, func(c *bw6_633mpc.SrsCommons) int { return len(c.G1.AlphaTau) }, (*bw6_633mpc.Phase2).Initialize)
+	case ecc.BLS24_317:
+		p, err = initPhase2(g.CCS, g.Curve, commonsPath, func(c *bls24_317mpc.SrsCommons) int { return len(c.G1.AlphaTau) }, (*bls24_317mpc.Phase2).Initialize)
+	default:
+		return fmt.Errorf("%w: %s", utils.ErrUnsupportedCurve, g.Curve.String())
+	}
+	if err != nil {
+		return err
+	}
+	return writeObject(outPath, "write phase2", p)
+}
+
+// ContributePhase2 读取上一份 phase2 文件，加入本地随机数后写出新的贡献
+func ContributePhase2(curve ecc.ID, inPath, outPath string) error {
+	var p contribution
+	switch curve {
+	case ecc.BN254:
+		p = new(bn254mpc.Phase2)
+	case ecc.BLS12_377:
+		p = new(bls12_377mpc.Phase2)
+	case ecc.BLS12_381:
+		p = new(bls12_381mpc.Phase2)
+	case ecc.BW6_761:
+		p = new(bw6_761mpc.Phase2)
+	case ecc.BLS24_315:
+		p = new(bls24_315mpc.Phase2)
+	case ecc.BW6_633:
+		p = new(bw6_633mpc.Phase2)
+	case ecc.BLS24_317:
+		p = new(bls24_317mpc.Phase2)
+	default:
+		return fmt.Errorf("%w: %s", utils.ErrUnsupportedCurve, curve.String())
+	}
+	return contribute(p, "phase2", inPath, outPath)
+}
+
+// SealCeremony 按顺序校验 phase2 贡献链，以 beacon 封装得到 PK/VK 并保存到包装器中
+func (g *Groth16Wrapper) SealCeremony(commonsPath string, beacon []byte, contributionPaths ...string) error {
+	logger.Debug("sealing groth16 ceremony ...")
+	if g.CCS == nil {
+		return fmt.Errorf("%w: constraint system is nil, compile first", utils.ErrMissingSetup)
+	}
+	var pk groth16.ProvingKey
+	var vk groth16.VerifyingKey
+	var err error
+	start := time.Now()
+	switch g.Curve {
+	case ecc.BN254:
+		pk, vk, err = sealPhase2(g.CCS, g.Curve, commonsPath, beacon, contributionPaths, bn254mpc.VerifyPhase2)
+	case ecc.BLS12_377:
+		pk, vk, err = sealPhase2(g.CCS, g.Curve, commonsPath, beacon, contributionPaths, bls12_377mpc.VerifyPhase2)
+	case ecc.BLS12_381:
+		pk, vk, err = sealPhase2(g.CCS, g.Curve, commonsPath, beacon, contributionPaths, bls12_381mpc.VerifyPhase2)
+	case ecc.BW6_761:
+		pk, vk, err = sealPhase2(g.CCS, g.Curve, commonsPath, beacon, contributionPaths, bw6_761mpc.VerifyPhase2)
+	case ecc.BLS24_315:
+		pk, vk, err = sealPhase2(g.CCS, g.Curve, commonsPath, beacon, contributionPaths, bls24_315mpc.VerifyPhase2)
+	case ecc.BW6_633:
+		pk, vk, err = sealPhase2(g.CCS, g.Curve, commonsPath, beacon, contributionPaths, bw6_633mpc.VerifyPhase2)
+	case ecc.BLS24_317:
+		pk, vk, err = sealPhase2(g.CCS, g.Curve, commonsPath, beacon, contributionPaths, bls24_317mpc.VerifyPhase2)
+	default:
+		return fmt.Errorf("%w: %s", utils.ErrUnsupportedCurve, g.Curve.String())
+	}
+	if err != nil {
+		return err
+	}
+	g.PK, g.VK = pk, vk
+	g.SetupTime = time.Since(start)
+	logger.Debug("groth16 ceremony sealed, took: %s", g.SetupTime.String())
+	return nil
+}
+
+// contribute 读取上一份贡献，加入本地随机数后写出
+func contribute(p contribution, phase, inPath, outPath string) error {
+	if err := readObject(inPath, "read "+phase, p); err != nil {
+		return err
+	}
+	start := time.Now()
+	p.Contribute()
+	logger.Debug("%s contribution computed, took: %s", phase, time.Since(start).String())
+	return writeObject(outPath, "write "+phase, p)
+}
+
+// readContributions 按顺序读取贡献文件
+func readContributions[T any, PT interface {
+	*T
+	io.ReaderFrom
+}](paths []string) ([]*T, error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("%w: no contribution provided", utils.ErrMissingSetup)
+	}
+	res := make([]*T, len(paths))
+	for i, path := range paths {
+		res[i] = new(T)
+		if err := readObject(path, "read contribution", PT(res[i])); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// sealPhase1 校验 phase1 贡献链并写出 SRS 公共参数
+func sealPhase1[T, C any, PT interface {
+	*T
+	io.ReaderFrom
+}, PC interface {
+	*C
+	io.WriterTo
+}](domainSize uint64, beacon []byte, commonsPath string, contributionPaths []string, verify func(uint64, []byte, ...*T) (C, error)) error {
+	contributions, err := readContributions[T, PT](contributionPaths)
+	if err != nil {
+		return err
+	}
+	commons, err := verify(domainSize, beacon, contributions...)
+	if err != nil {
+		return fmt.Errorf("verify phase1 contributions failed: %w", err)
+	}
+	logger.Debug("verified %d phase1 contributions", len(contributions))
+	return writeObject(commonsPath, "write srs commons", PC(&commons))
+}
+
+// initPhase2 读取 SRS 公共参数并以约束系统初始化 phase2，domainSize 返回公共参数支持的最大约束数
+func initPhase2[R, C, P, E any, PC interface {
+	*C
+	io.ReaderFrom
+}, PP interface {
+	*P
+	io.WriterTo
+}](ccs constraint.ConstraintSystem, curve ecc.ID, commonsPath string, domainSize func(*C) int, initialize func(PP, *R, *C) E) (io.WriterTo, error) {
+	var commons C
+	if err := readObject(commonsPath, "read srs commons", PC(&commons)); err != nil {
+		return nil, err
+	}
+	// 公共参数不足时 Initialize 会 panic，提前返回错误
+	if n, nbConstraints := domainSize(&commons), ccs.GetNbConstraints(); n < nbConstraints {
+		return nil, fmt.Errorf("%w: phase1 domain size %d, circuit has %d constraints", utils.ErrSRSTooSmall, n, nbConstraints)
+	}
+	r1cs, err := asR1CS[R](ccs, curve)
+	if err != nil {
+		return nil, err
+	}
+	p := PP(new(P))
+	initialize(p, r1cs, &commons)
+	return p, nil
+}
+
+// sealPhase2 校验 phase2 贡献链并封装出 PK/VK
+func sealPhase2[R, C, T any, PC interface {
+	*C
+	io.ReaderFrom
+}, PT interface {
+	*T
+	io.ReaderFrom
+}](ccs constraint.ConstraintSystem, curve ecc.ID, commonsPath string, beacon []byte, contributionPaths []string, verify func(*R, *C, []byte, ...*T) (groth16.ProvingKey, groth16.VerifyingKey, error)) (groth16.ProvingKey, groth16.VerifyingKey, error) {
+	r1cs, err := asR1CS[R](ccs, curve)
+	if err != nil {
+		return nil, nil, err
+	}
+	var commons C
+	if err := readObject(commonsPath, "read srs commons", PC(&commons)); err != nil {
+		return nil, nil, err
+	}
+	contributions, err := readContributions[T, PT](contributionPaths)
+	if err != nil {
+		return nil, nil, err
+	}
+	pk, vk, err := verify(r1cs, &commons, beacon, contributions...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("verify phase2 contributions failed: %w", err)
+	}
+	logger.Debug("verified %d phase2 contributions", len(contributions))
+	return pk, vk, nil
+}
+
+// asR1CS 检查约束系统是否为指定曲线上的 R1CS，例如 PLONK 编译的约束系统无法用于 Groth16 仪式
+func asR1CS[R any](ccs constraint.ConstraintSystem, curve ecc.ID) (*R, error) {
+	r1cs, ok := any(ccs).(*R)
+	if !ok {
+		return nil, fmt.Errorf("%w: constraint system %T is not a %s R1CS", utils.ErrUnsupportedCurve, ccs, curve.String())
+	}
+	return r1cs, nil
+}
+
+func writeObject(filePath, op string, v io.WriterTo) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return &utils.IOError{Op: "create file", Path: filePath, Err: err}
+	}
+	w := bufio.NewWriter(file)
+	size, err := v.WriteTo(w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		file.Close()
+		return &utils.IOError{Op: op, Path: filePath, Err: err}
+	}
+	if err := file.Close(); err != nil {
+		return &utils.IOError{Op: "close file", Path: filePath, Err: err}
+	}
+	logger.Debug("%s to %s, size= %d", op, filePath, size)
+	return nil
+}
+
+func readObject(filePath, op string, v io.ReaderFrom) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return &utils.IOError{Op: "open file", Path: filePath, Err: err}
+	}
+	defer file.Close()
+	size, err := v.ReadFrom(bufio.NewReader(file))
+	if err != nil {
+		return &utils.IOError{Op: op, Path: filePath, Err: err}
+	}
+	logger.Debug("%s from %s, size= %d", op, filePath, size)
+	return nil
+}
@@ -0,0 +1,131 @@
+package groth16wrapper
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/oliverustc/gnarkabc/circuits"
+	"github.com/oliverustc/gnarkabc/logger"
+	"github.com/oliverustc/gnarkabc/utils"
+
+	"github.com/consensys/gnark-crypto/ecc"
+)
+
+// runPhase 依次执行 nb 次贡献，返回各贡献文件路径
+func runPhase(t *testing.T, contributeFn func(curve ecc.ID, inPath, outPath string) error, curve ecc.ID, initPath string, nb int) []string {
+	t.Helper()
+	paths := make([]string, nb)
+	prev := initPath
+	for i := range paths {
+		paths[i] = fmt.Sprintf("%s_%d", initPath, i)
+		if err := contributeFn(curve, prev, paths[i]); err != nil {
+			t.Fatal(err)
+		}
+		prev = paths[i]
+	}
+	return paths
+}
+
+func TestGroth16Ceremony(t *testing.T) {
+	var circuit circuits.Product
+	circuit.PreCompile(circuits.NoParams{})
+	for _, curveName := range utils.CurveNameList {
+		dir := t.TempDir()
+		curve := utils.CurveMap[curveName]
+		g := NewWrapper(&circuit, curve)
+		if err := g.Compile(); err != nil {
+			t.Fatal(err)
+		}
+		domainSize, err := g.CeremonyDomainSize()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		phase1Path := filepath.Join(dir, "phase1")
+		if err := InitPhase1(curve, domainSize, phase1Path); err != nil {
+			t.Fatal(err)
+		}
+		phase1 := runPhase(t, ContributePhase1, curve, phase1Path, 3)
+		commonsPath := filepath.Join(dir, "commons")
+		if err := VerifyPhase1(curve, domainSize, []byte("phase1 beacon"), commonsPath, phase1...); err != nil {
+			t.Fatal(err)
+		}
+
+		phase2Path := filepath.Join(dir, "phase2")
+		if err := g.InitPhase2(commonsPath, phase2Path); err != nil {
+			t.Fatal(err)
+		}
+		phase2 := runPhase(t, ContributePhase2, curve, phase2Path, 2)
+		if err := g.SealCeremony(commonsPath, []byte("phase2 beacon"), phase2...); err != nil {
+			t.Fatal(err)
+		}
+
+		circuit.Assign(circuits.ProductAssign{P: 13, Q: 17})
+		g.SetAssignment(&circuit)
+		if err := g.Prove(); err != nil {
+			t.Fatal(err)
+		}
+		if err := g.Verify(); err != nil {
+			t.Fatal(err)
+		}
+		logger.Info("groth16 ceremony on curve [ %s ] success", curveName)
+
+		// 贡献顺序错误时校验失败
+		reversed := []string{phase1[1], phase1[0], phase1[2]}
+		if err := VerifyPhase1(curve, domainSize, []byte("phase1 beacon"), commonsPath+"_bad", reversed...); err == nil {
+			t.Fatal("expected out-of-order phase1 contributions to be rejected")
+		}
+		if err := g.SealCeremony(commonsPath, []byte("phase2 beacon"), phase2[1:]...); err == nil {
+			t.Fatal("expected incomplete phase2 chain to be rejected")
+		}
+	}
+}
+
+func TestGroth16CeremonyErrors(t *testing.T) {
+	dir := t.TempDir()
+	var circuit circuits.MimcHash
+	circuit.PreCompile(circuits.NoParams{})
+	g := NewWrapper(&circuit, ecc.BN254)
+	if err := g.InitPhase2("", ""); !errors.Is(err, utils.ErrMissingSetup) {
+		t.Fatalf("expected ErrMissingSetup, got %v", err)
+	}
+	if err := g.Compile(); err != nil {
+		t.Fatal(err)
+	}
+
+	// phase1 规模小于约束数量时无法进入 phase2
+	phase1Path := filepath.Join(dir, "phase1")
+	if err := InitPhase1(ecc.BN254, 4, phase1Path); err != nil {
+		t.Fatal(err)
+	}
+	phase1 := runPhase(t, ContributePhase1, ecc.BN254, phase1Path, 1)
+	commonsPath := filepath.Join(dir, "commons")
+	if err := VerifyPhase1(ecc.BN254, 4, []byte("beacon"), commonsPath, phase1...); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.InitPhase2(commonsPath, filepath.Join(dir, "phase2")); !errors.Is(err, utils.ErrSRSTooSmall) {
+		t.Fatalf("expected ErrSRSTooSmall, got %v", err)
+	}
+
+	if err := InitPhase1(ecc.BN254, 3, phase1Path); err == nil {
+		t.Fatal("expected non power of 2 domain size to be rejected")
+	}
+	if err := ContributePhase1(ecc.BN254, filepath.Join(dir, "missing"), phase1Path); !errors.Is(err, utils.ErrIO) {
+		t.Fatalf("expected ErrIO, got %v", err)
+	}
+	if err := g.SealCeremony(commonsPath, []byte("beacon")); !errors.Is(err, utils.ErrMissingSetup) {
+		t.Fatalf("expected ErrMissingSetup, got %v", err)
+	}
+
+	// 约束系统与包装器曲线不一致时返回错误而不是 panic
+	other := NewWrapper(&circuit, ecc.BLS12_381)
+	if err := other.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	g.CCS = other.CCS
+	if err := g.SealCeremony(commonsPath, []byte("beacon"), phase1...); !errors.Is(err, utils.ErrUnsupportedCurve) {
+		t.Fatalf("expected ErrUnsupportedCurve, got %v", err)
+	}
+}
@@ -3,8 +3,10 @@ package groth16wrapper
 import (
 	"fmt"
 
-	"github.com/oliverustc/gnarkabc/logger"
+	"github.com/oliverustc/gnarkabc/hash/witnesshash"
+	"github.com/oliverustc/gnarkabc/utils"
 
+	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/backend/groth16"
 	"github.com/consensys/gnark/backend/witness"
 	"github.com/consensys/gnark/constraint"
@@ -14,6 +16,30 @@ import (
 	recursion_groth16 "github.com/consensys/gnark/std/recursion/groth16"
 )
 
+// OuterCompileParams OuterCircuit的编译参数
+type OuterCompileParams struct {
+	InnerCCS constraint.ConstraintSystem // 内层电路的约束系统
+}
+
+// OuterAssignParams OuterCircuit的赋值参数
+type OuterAssignParams struct {
+	InnerVK      groth16.VerifyingKey // 内层验证密钥
+	InnerWitness witness.Witness      // 内层见证者
+	InnerProof   groth16.Proof        // 内层证明
+}
+
+// OuterConstantCompileParams OuterCircuitConstant的编译参数，内层验证密钥作为常量编译进电路
+type OuterConstantCompileParams struct {
+	InnerCCS constraint.ConstraintSystem
+	InnerVK  groth16.VerifyingKey
+}
+
+// OuterConstantAssignParams OuterCircuitConstant的赋值参数
+type OuterConstantAssignParams struct {
+	InnerWitness witness.Witness
+	InnerProof   groth16.Proof
+}
+
 type OuterCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT] struct {
 	Proof        recursion_groth16.Proof[G1El, G2El]
 	VerifyingKey recursion_groth16.VerifyingKey[G1El, G2El, GtEl]
@@ -28,40 +54,34 @@ func (oc *OuterCircuit[FR, G1El, G2El, GtEl]) Define(api frontend.API) error {
 	return verifier.AssertProof(oc.VerifyingKey, oc.Proof, oc.InnerWitness)
 }
 
-func (oc *OuterCircuit[FR, G1El, G2El, GtEl]) PreCompile(params any) {
-	innerCCS := params.([]any)[0].(constraint.ConstraintSystem)
-	oc.Proof = recursion_groth16.PlaceholderProof[G1El, G2El](innerCCS)
-	oc.VerifyingKey = recursion_groth16.PlaceholderVerifyingKey[G1El, G2El, GtEl](innerCCS)
-	oc.InnerWitness = recursion_groth16.PlaceholderWitness[FR](innerCCS)
-}
-
-func (oc *OuterCircuit[FR, G1El, G2El, GtEl]) Assign(params any) {
-	// 将params转换为切片以处理多个参数
-	args := params.([]any)
-	if len(args) != 3 {
-		panic("Assign params must be []any with length 3")
+func (oc *OuterCircuit[FR, G1El, G2El, GtEl]) PreCompile(params OuterCompileParams) error {
+	if params.InnerCCS == nil {
+		return fmt.Errorf("%w: inner constraint system is nil", utils.ErrMissingSetup)
 	}
+	oc.Proof = recursion_groth16.PlaceholderProof[G1El, G2El](params.InnerCCS)
+	oc.VerifyingKey = recursion_groth16.PlaceholderVerifyingKey[G1El, G2El, GtEl](params.InnerCCS)
+	oc.InnerWitness = recursion_groth16.PlaceholderWitness[FR](params.InnerCCS)
+	return nil
+}
 
-	innerVK := args[0].(groth16.VerifyingKey)
-	innerWitness := args[1].(witness.Witness)
-	innerProof := args[2].(groth16.Proof)
-
-	circuitVK, err := recursion_groth16.ValueOfVerifyingKey[G1El, G2El, GtEl](innerVK)
+func (oc *OuterCircuit[FR, G1El, G2El, GtEl]) Assign(params OuterAssignParams) error {
+	circuitVK, err := recursion_groth16.ValueOfVerifyingKey[G1El, G2El, GtEl](params.InnerVK)
 	if err != nil {
-		logger.Fatal("failed to convert verifying key: %v", err)
+		return fmt.Errorf("failed to convert verifying key: %w", err)
 	}
-	circuitWitness, err := recursion_groth16.ValueOfWitness[FR](innerWitness)
+	circuitWitness, err := recursion_groth16.ValueOfWitness[FR](params.InnerWitness)
 	if err != nil {
-		logger.Fatal("failed to convert witness: %v", err)
+		return fmt.Errorf("failed to convert witness: %w", err)
 	}
-	circuitProof, err := recursion_groth16.ValueOfProof[G1El, G2El](innerProof)
+	circuitProof, err := recursion_groth16.ValueOfProof[G1El, G2El](params.InnerProof)
 	if err != nil {
-		logger.Fatal("failed to convert proof: %v", err)
+		return fmt.Errorf("failed to convert proof: %w", err)
 	}
 
 	oc.VerifyingKey = circuitVK
 	oc.InnerWitness = circuitWitness
 	oc.Proof = circuitProof
+	return nil
 }
 
 type OuterCircuitConstant[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT] struct {
@@ -78,28 +98,107 @@ func (oc *OuterCircuitConstant[FR, G1El, G2El, GtEl]) Define(api frontend.API) e
 	return verifier.AssertProof(oc.vk, oc.Proof, oc.InnerWitness)
 }
 
-func (oc *OuterCircuitConstant[FR, G1El, G2El, GtEl]) PreCompile(params any) {
-	innerCCS := params.([]any)[0].(constraint.ConstraintSystem)
-	oc.InnerWitness = recursion_groth16.PlaceholderWitness[FR](innerCCS)
-	innerVK := params.([]any)[1].(groth16.VerifyingKey)
-	circuitVK, err := recursion_groth16.ValueOfVerifyingKeyFixed[G1El, G2El, GtEl](innerVK)
+func (oc *OuterCircuitConstant[FR, G1El, G2El, GtEl]) PreCompile(params OuterConstantCompileParams) error {
+	if params.InnerCCS == nil || params.InnerVK == nil {
+		return fmt.Errorf("%w: inner constraint system or verifying key is nil", utils.ErrMissingSetup)
+	}
+	oc.InnerWitness = recursion_groth16.PlaceholderWitness[FR](params.InnerCCS)
+	circuitVK, err := recursion_groth16.ValueOfVerifyingKeyFixed[G1El, G2El, GtEl](params.InnerVK)
+	if err != nil {
+		return fmt.Errorf("failed to convert verifying key: %w", err)
+	}
+	oc.vk = circuitVK
+	return nil
+}
+
+func (oc *OuterCircuitConstant[FR, G1El, G2El, GtEl]) Assign(params OuterConstantAssignParams) error {
+	circuitWitness, err := recursion_groth16.ValueOfWitness[FR](params.InnerWitness)
+	if err != nil {
+		return fmt.Errorf("failed to convert witness: %w", err)
+	}
+	circuitProof, err := recursion_groth16.ValueOfProof[G1El, G2El](params.InnerProof)
+	if err != nil {
+		return fmt.Errorf("failed to convert proof: %w", err)
+	}
+	oc.InnerWitness = circuitWitness
+	oc.Proof = circuitProof
+	return nil
+}
+
+// OuterDigestCompileParams OuterCircuitDigest的编译参数，内层验证密钥作为常量编译进电路
+type OuterDigestCompileParams struct {
+	InnerCCS   constraint.ConstraintSystem
+	InnerVK    groth16.VerifyingKey
+	Hash       witnesshash.Kind // 计算公开输入摘要的哈希函数
+	OuterCurve ecc.ID           // 外层电路所在的曲线
+}
+
+// OuterDigestAssignParams OuterCircuitDigest的赋值参数，摘要的参数与编译参数一致，
+// 赋值对象不必调用 PreCompile
+type OuterDigestAssignParams struct {
+	InnerWitness witness.Witness
+	InnerProof   groth16.Proof
+	Hash         witnesshash.Kind // 计算公开输入摘要的哈希函数
+	InnerCurve   ecc.ID           // 内层电路所在的曲线
+	OuterCurve   ecc.ID           // 外层电路所在的曲线
+}
+
+// OuterCircuitDigest 与 OuterCircuitConstant 相同，但内层见证者作为私有输入，
+// 外层电路只公开其摘要 Digest，公开输入的个数不再随内层电路增长，摘要与 witnesshash.DigestWitness 的结果一致
+type OuterCircuitDigest[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT] struct {
+	Proof        recursion_groth16.Proof[G1El, G2El]
+	vk           recursion_groth16.VerifyingKey[G1El, G2El, GtEl] `gnark:"-"`
+	InnerWitness recursion_groth16.Witness[FR]
+	Digest       frontend.Variable `gnark:",public"`
+
+	kind witnesshash.Kind `gnark:"-"`
+}
+
+func (oc *OuterCircuitDigest[FR, G1El, G2El, GtEl]) Define(api frontend.API) error {
+	verifier, err := recursion_groth16.NewVerifier[FR, G1El, G2El, GtEl](api)
+	if err != nil {
+		return fmt.Errorf("failed to create verifier: %w", err)
+	}
+	if err := verifier.AssertProof(oc.vk, oc.Proof, oc.InnerWitness); err != nil {
+		return err
+	}
+	digest, err := witnesshash.Define(api, oc.kind, oc.InnerWitness.Public)
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(digest, oc.Digest)
+	return nil
+}
+
+func (oc *OuterCircuitDigest[FR, G1El, G2El, GtEl]) PreCompile(params OuterDigestCompileParams) error {
+	if params.InnerCCS == nil || params.InnerVK == nil {
+		return fmt.Errorf("%w: inner constraint system or verifying key is nil", utils.ErrMissingSetup)
+	}
+	oc.InnerWitness = recursion_groth16.PlaceholderWitness[FR](params.InnerCCS)
+	circuitVK, err := recursion_groth16.ValueOfVerifyingKeyFixed[G1El, G2El, GtEl](params.InnerVK)
 	if err != nil {
-		logger.Fatal("failed to convert verifying key: %v", err)
+		return fmt.Errorf("failed to convert verifying key: %w", err)
 	}
 	oc.vk = circuitVK
+	oc.kind = params.Hash
+	return nil
 }
 
-func (oc *OuterCircuitConstant[FR, G1El, G2El, GtEl]) Assign(params any) {
-	innerWitnes := params.([]any)[0].(witness.Witness)
-	innerProof := params.([]any)[1].(groth16.Proof)
-	circuitWitness, err := recursion_groth16.ValueOfWitness[FR](innerWitnes)
+func (oc *OuterCircuitDigest[FR, G1El, G2El, GtEl]) Assign(params OuterDigestAssignParams) error {
+	circuitWitness, err := recursion_groth16.ValueOfWitness[FR](params.InnerWitness)
+	if err != nil {
+		return fmt.Errorf("failed to convert witness: %w", err)
+	}
+	circuitProof, err := recursion_groth16.ValueOfProof[G1El, G2El](params.InnerProof)
 	if err != nil {
-		logger.Fatal("failed to convert witness: %v", err)
+		return fmt.Errorf("failed to convert proof: %w", err)
 	}
-	circuitProof, err := recursion_groth16.ValueOfProof[G1El, G2El](innerProof)
+	digest, err := witnesshash.DigestWitness(params.Hash, params.OuterCurve, params.InnerCurve, params.InnerWitness)
 	if err != nil {
-		logger.Fatal("failed to convert proof: %v", err)
+		return fmt.Errorf("failed to compute input digest: %w", err)
 	}
 	oc.InnerWitness = circuitWitness
 	oc.Proof = circuitProof
+	oc.Digest = digest
+	return nil
 }
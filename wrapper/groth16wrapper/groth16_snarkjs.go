@@ -0,0 +1,85 @@
+package groth16wrapper
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/oliverustc/gnarkabc/logger"
+	"github.com/oliverustc/gnarkabc/utils"
+	"github.com/oliverustc/gnarkabc/wrapper/snarkjs"
+)
+
+// WriteSnarkjs 将证明、验证密钥和公开见证者以 snarkjs 的 proof.json、verification_key.json、public.json 写入存储的 dir 目录，
+// 仅支持 BN254 和 BLS12-381
+func (g *Groth16Wrapper) WriteSnarkjs(dir string) error {
+	if g.Proof == nil {
+		return fmt.Errorf("%w: proof is nil, prove first", utils.ErrInvalidProof)
+	}
+	if g.VK == nil {
+		return fmt.Errorf("%w: verifying key is nil", utils.ErrMissingSetup)
+	}
+	if g.WitnessPublic == nil {
+		if err := g.GenerateWitness(true); err != nil {
+			return err
+		}
+	}
+	proof, err := snarkjs.ExportGroth16Proof(g.Proof)
+	if err != nil {
+		return err
+	}
+	vk, err := snarkjs.ExportGroth16VerifyingKey(g.VK)
+	if err != nil {
+		return err
+	}
+	public, err := snarkjs.ExportPublic(g.WitnessPublic, g.Curve)
+	if err != nil {
+		return err
+	}
+	logger.Debug("Writing snarkjs files to %s", dir)
+	s := g.GetStore()
+	if err := snarkjs.WriteJSON(s, path.Join(dir, snarkjs.ProofFile), proof); err != nil {
+		return err
+	}
+	if err := snarkjs.WriteJSON(s, path.Join(dir, snarkjs.VKFile), vk); err != nil {
+		return err
+	}
+	return snarkjs.WriteJSON(s, path.Join(dir, snarkjs.PublicFile), public)
+}
+
+// ReadSnarkjs 从存储的 dir 目录读取 snarkjs 的 proof.json、verification_key.json、public.json，
+// 文件记录的曲线必须与包装器一致
+func (g *Groth16Wrapper) ReadSnarkjs(dir string) error {
+	logger.Debug("Reading snarkjs files from %s", dir)
+	s := g.GetStore()
+	var proofJSON snarkjs.Groth16Proof
+	if err := snarkjs.ReadJSON(s, path.Join(dir, snarkjs.ProofFile), &proofJSON); err != nil {
+		return err
+	}
+	var vkJSON snarkjs.Groth16VerifyingKey
+	if err := snarkjs.ReadJSON(s, path.Join(dir, snarkjs.VKFile), &vkJSON); err != nil {
+		return err
+	}
+	var publicJSON snarkjs.Public
+	if err := snarkjs.ReadJSON(s, path.Join(dir, snarkjs.PublicFile), &publicJSON); err != nil {
+		return err
+	}
+	for _, name := range []string{proofJSON.Curve, vkJSON.Curve} {
+		if curve, err := snarkjs.CurveID(name); err != nil || curve != g.Curve {
+			return fmt.Errorf("%w: snarkjs curve %s, wrapper curve %s", utils.ErrUnsupportedCurve, name, g.Curve.String())
+		}
+	}
+	proof, err := snarkjs.ImportGroth16Proof(&proofJSON)
+	if err != nil {
+		return err
+	}
+	vk, err := snarkjs.ImportGroth16VerifyingKey(&vkJSON)
+	if err != nil {
+		return err
+	}
+	public, err := snarkjs.ImportPublic(publicJSON, g.Curve)
+	if err != nil {
+		return err
+	}
+	g.Proof, g.VK, g.WitnessPublic = proof, vk, public
+	return nil
+}
@@ -0,0 +1,164 @@
+package groth16wrapper
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/oliverustc/gnarkabc/circuits"
+	"github.com/oliverustc/gnarkabc/utils"
+	"github.com/oliverustc/gnarkabc/wrapper/snarkjs"
+	"github.com/oliverustc/gnarkabc/wrapper/store"
+
+	"github.com/consensys/gnark-crypto/ecc"
+)
+
+var updateFixtures = flag.Bool("update", false, "regenerate snarkjs fixtures in testdata")
+
+// snarkjsFixtures 是本包导出的 snarkjs 格式文件，只用于检查导出和导入互为逆运算
+var snarkjsFixtures = map[string]ecc.ID{
+	"groth16_bn254":    ecc.BN254,
+	"groth16_bls12381": ecc.BLS12_381,
+}
+
+// proveProduct 完成乘积电路的证明，N = 13 * 17
+func proveProduct(t *testing.T, curve ecc.ID) *Groth16Wrapper {
+	t.Helper()
+	var circuit circuits.Product
+	circuit.PreCompile(circuits.NoParams{})
+	g := NewWrapper(&circuit, curve)
+	if err := g.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	if err := circuit.Assign(circuits.ProductAssign{P: 13, Q: 17}); err != nil {
+		t.Fatal(err)
+	}
+	g.SetAssignment(&circuit)
+	if err := g.Prove(); err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func readAll(t *testing.T, s store.ArtifactStore, name string) []byte {
+	t.Helper()
+	r, err := s.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// generatedFixtures 是 snarkjs 生成的文件，由 testdata/snarkjs/circom/generate.sh 写入
+var generatedFixtures = map[string]ecc.ID{
+	"snarkjs_bn254":    ecc.BN254,
+	"snarkjs_bls12381": ecc.BLS12_381,
+}
+
+// 导入 snarkjs 为 circom 乘积电路生成的证明并用 gnark 验证
+func TestGroth16SnarkjsGenerated(t *testing.T) {
+	for name, curve := range generatedFixtures {
+		dir := filepath.Join("testdata", "snarkjs", name)
+		if !utils.CheckDirExists(dir) {
+			t.Skipf("%s not found, run testdata/snarkjs/circom/generate.sh with circom and snarkjs installed", dir)
+		}
+		g := NewWrapper(nil, curve)
+		g.SetStore(store.NewFS(dir))
+		if err := g.ReadSnarkjs(""); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if err := g.Verify(); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		public, err := snarkjs.ImportPublic(snarkjs.Public{"222"}, curve)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g.WitnessPublic = public
+		if err := g.Verify(); !errors.Is(err, utils.ErrInvalidProof) {
+			t.Fatalf("%s: expected ErrInvalidProof, got %v", name, err)
+		}
+	}
+}
+
+// 本包导出的文件可以导入并验证，再次导出与原文件逐字节一致
+func TestGroth16SnarkjsFixtures(t *testing.T) {
+	for name, curve := range snarkjsFixtures {
+		fixtures := store.NewFS(filepath.Join("testdata", "snarkjs", name))
+		if *updateFixtures {
+			g := proveProduct(t, curve)
+			g.SetStore(fixtures)
+			if err := g.WriteSnarkjs(""); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// 导入的证明可以直接用 gnark 验证
+		g := NewWrapper(nil, curve)
+		g.SetStore(fixtures)
+		if err := g.ReadSnarkjs(""); err != nil {
+			t.Fatal(err)
+		}
+		if err := g.Verify(); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		// 再次导出与夹具逐字节一致
+		exported := store.NewMemory()
+		g.SetStore(exported)
+		if err := g.WriteSnarkjs(""); err != nil {
+			t.Fatal(err)
+		}
+		for _, file := range []string{snarkjs.ProofFile, snarkjs.VKFile, snarkjs.PublicFile} {
+			if !bytes.Equal(readAll(t, exported, file), readAll(t, fixtures, file)) {
+				t.Fatalf("%s: exported %s differs from fixture", name, file)
+			}
+		}
+
+		// 修改公开输入后验证失败
+		public, err := snarkjs.ImportPublic(snarkjs.Public{"222"}, curve)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g.WitnessPublic = public
+		if err := g.Verify(); !errors.Is(err, utils.ErrInvalidProof) {
+			t.Fatalf("%s: expected ErrInvalidProof, got %v", name, err)
+		}
+	}
+}
+
+func TestGroth16SnarkjsErrors(t *testing.T) {
+	g := proveProduct(t, ecc.BLS12_377)
+	g.SetStore(store.NewMemory())
+	if err := g.WriteSnarkjs(""); !errors.Is(err, utils.ErrUnsupportedCurve) {
+		t.Fatalf("expected ErrUnsupportedCurve, got %v", err)
+	}
+
+	// 曲线与包装器不一致时拒绝导入
+	other := NewWrapper(nil, ecc.BN254)
+	other.SetStore(store.NewFS(filepath.Join("testdata", "snarkjs", "groth16_bls12381")))
+	if err := other.ReadSnarkjs(""); !errors.Is(err, utils.ErrUnsupportedCurve) {
+		t.Fatalf("expected ErrUnsupportedCurve, got %v", err)
+	}
+
+	// 不在曲线上的点和超出域的元素被拒绝
+	if _, err := snarkjs.ImportGroth16Proof(&snarkjs.Groth16Proof{
+		PiA: snarkjs.G1{"1", "3", "1"}, Protocol: "groth16", Curve: "bn128",
+	}); !errors.Is(err, utils.ErrCorruptedArtifact) {
+		t.Fatalf("expected ErrCorruptedArtifact, got %v", err)
+	}
+	if _, err := snarkjs.ImportPublic(snarkjs.Public{ecc.BN254.ScalarField().String()}, ecc.BN254); !errors.Is(err, utils.ErrCorruptedArtifact) {
+		t.Fatalf("expected ErrCorruptedArtifact, got %v", err)
+	}
+}
@@ -4,84 +4,111 @@ import (
 	"encoding/hex"
 	"fmt"
 	"math/big"
-	"os"
 	"os/exec"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"text/template"
 
 	"github.com/oliverustc/gnarkabc/logger"
 	"github.com/oliverustc/gnarkabc/utils"
+	"github.com/oliverustc/gnarkabc/wrapper/store"
 
 	"github.com/consensys/gnark-crypto/ecc"
 	fr_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/fr"
 )
 
-func (g *Groth16Wrapper) ExportSolidity(filePath string) {
+func (g *Groth16Wrapper) ExportSolidity(filePath string) error {
 	if filePath == "" {
-		logger.Info("filePath is empty, using default path: output/Groth16Verifier.sol")
-		filePath = "output/Groth16Verifier.sol"
+		logger.Info("filePath is empty, using default path: Groth16Verifier.sol")
+		filePath = "Groth16Verifier.sol"
 	}
 	if g.Curve != ecc.BN254 {
-		logger.Fatal("only BN254 curve is supported")
+		return fmt.Errorf("%w: only BN254 curve is supported", utils.ErrUnsupportedCurve)
 	}
-	utils.EnsureDirExists("output")
-	solFile, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY, 0666)
+	if g.VK == nil {
+		return fmt.Errorf("%w: verifying key is nil", utils.ErrMissingSetup)
+	}
+	solFile, err := g.GetStore().Create(filePath)
 	if err != nil {
-		logger.Fatal("open file failed: %v", err)
+		return &utils.IOError{Op: "create solidity file", Path: filePath, Err: err}
+	}
+	if err := g.VK.ExportSolidity(solFile); err != nil {
+		solFile.Close()
+		return &utils.IOError{Op: "export solidity", Path: filePath, Err: err}
+	}
+	if err := solFile.Close(); err != nil {
+		return &utils.IOError{Op: "close solidity file", Path: filePath, Err: err}
 	}
-	defer solFile.Close()
-	g.VK.ExportSolidity(solFile)
 	logger.Info("export solidity to %s", filePath)
+	return nil
 }
 
-// 参与gnark-solidity-checker验证流程的准备工作，将proof序列化
-func (g *Groth16Wrapper) ProofMarshall() (proofStr string) {
+// solidityProof 返回 Solidity 验证合约使用的证明编码，仅支持 BN254
+func (g *Groth16Wrapper) solidityProof() ([]byte, error) {
 	if g.Curve != ecc.BN254 {
-		logger.Fatal("only BN254 curve is supported")
+		return nil, fmt.Errorf("%w: only BN254 curve is supported", utils.ErrUnsupportedCurve)
+	}
+	if g.Proof == nil {
+		return nil, fmt.Errorf("%w: proof is nil", utils.ErrInvalidProof)
 	}
-
 	_proof, ok := g.Proof.(interface{ MarshalSolidity() []byte })
 	if !ok {
-		logger.Fatal("marshal proof failed")
+		return nil, fmt.Errorf("proof of type %T cannot be marshaled for solidity", g.Proof)
 	}
-	proofStr = hex.EncodeToString(_proof.MarshalSolidity())
-	return
+	return _proof.MarshalSolidity(), nil
 }
 
-// 参与gnark-solidity-checker验证流程的准备工作，将public witness序列化
-func (g *Groth16Wrapper) PublicWitnessMarshall() (publicWitnessStr string) {
+// solidityPublicWitness 返回去掉头部后的公开见证者编码，仅支持 BN254
+func (g *Groth16Wrapper) solidityPublicWitness() ([]byte, error) {
 	if g.Curve != ecc.BN254 {
-		logger.Fatal("only BN254 curve is supported")
+		return nil, fmt.Errorf("%w: only BN254 curve is supported", utils.ErrUnsupportedCurve)
+	}
+	if g.WitnessPublic == nil {
+		if err := g.GenerateWitness(true); err != nil {
+			return nil, err
+		}
 	}
 	bPublicWitness, err := g.WitnessPublic.MarshalBinary()
 	if err != nil {
-		logger.Fatal("marshal public witness failed: %v", err)
+		return nil, fmt.Errorf("marshal public witness failed: %w", err)
 	}
-	bPublicWitness = bPublicWitness[12:]
-	publicWitnessStr = hex.EncodeToString(bPublicWitness)
-	return
+	return bPublicWitness[12:], nil
 }
 
-// 参与gnark-solidity-checker验证流程的准备工作，获取public input的长度
-func (g *Groth16Wrapper) GetPublicInputNum() string {
-	if g.Curve != ecc.BN254 {
-		logger.Fatal("only BN254 curve is supported")
+// 参与gnark-solidity-checker验证流程的准备工作，将proof序列化
+func (g *Groth16Wrapper) ProofMarshall() (string, error) {
+	proofBytes, err := g.solidityProof()
+	if err != nil {
+		return "", err
 	}
-	return strconv.Itoa(len(g.WitnessPublic.Vector().(fr_bn254.Vector)))
+	return hex.EncodeToString(proofBytes), nil
 }
 
-// 阅读gnark-solidity-checker的源码，简化下的proof的处理流程
-func (g *Groth16Wrapper) GenSolProofParams() string {
-	if g.Curve != ecc.BN254 {
-		logger.Fatal("only BN254 curve is supported")
+// 参与gnark-solidity-checker验证流程的准备工作，将public witness序列化
+func (g *Groth16Wrapper) PublicWitnessMarshall() (string, error) {
+	bPublicWitness, err := g.solidityPublicWitness()
+	if err != nil {
+		return "", err
 	}
+	return hex.EncodeToString(bPublicWitness), nil
+}
 
-	_proof, ok := g.Proof.(interface{ MarshalSolidity() []byte })
-	if !ok {
-		logger.Fatal("marshal proof failed")
+// 参与gnark-solidity-checker验证流程的准备工作，获取public input的长度
+func (g *Groth16Wrapper) GetPublicInputNum() (string, error) {
+	bPublicWitness, err := g.solidityPublicWitness()
+	if err != nil {
+		return "", err
+	}
+	return strconv.Itoa(len(bPublicWitness) / fr_bn254.Bytes), nil
+}
+
+// 阅读gnark-solidity-checker的源码，简化下的proof的处理流程
+func (g *Groth16Wrapper) GenSolProofParams() (string, error) {
+	proofBytes, err := g.solidityProof()
+	if err != nil {
+		return "", err
 	}
-	proofBytes := _proof.MarshalSolidity()
 	logger.Info("length of proof: %d", len(proofBytes))
 	var proof [8]*big.Int
 	for i := range 8 {
@@ -94,7 +121,10 @@ func (g *Groth16Wrapper) GenSolProofParams() string {
 	}
 	proofStr = proofStr[:len(proofStr)-1]
 	proofStr += "]"
-	commitmentCount := g.GenNbCommitments()
+	commitmentCount, err := g.GenNbCommitments()
+	if err != nil {
+		return "", err
+	}
 	// 判断proof中是否有commitments
 	if commitmentCount > 0 {
 		logger.Info("proof has commitments")
@@ -117,22 +147,21 @@ func (g *Groth16Wrapper) GenSolProofParams() string {
 		proofStr += fmt.Sprintf("[%s,%s]", commitmentPok[0].String(), commitmentPok[1].String())
 	}
 
-	inputStr := g.GenSolInputParams()
+	inputStr, err := g.GenSolInputParams()
+	if err != nil {
+		return "", err
+	}
 	proofStr += "," + inputStr
-	return proofStr
+	return proofStr, nil
 }
 
 // 阅读gnark-solidity-checker的源码，简化下的input的处理流程
-func (g *Groth16Wrapper) GenSolInputParams() string {
-	if g.Curve != ecc.BN254 {
-		logger.Fatal("only BN254 curve is supported")
-	}
-	bPublicWitness, err := g.WitnessPublic.MarshalBinary()
+func (g *Groth16Wrapper) GenSolInputParams() (string, error) {
+	bPublicWitness, err := g.solidityPublicWitness()
 	if err != nil {
-		logger.Fatal("marshal public witness failed: %v", err)
+		return "", err
 	}
-	bPublicWitness = bPublicWitness[12:]
-	nbInputs := len(bPublicWitness) / 32
+	nbInputs := len(bPublicWitness) / fr_bn254.Bytes
 	input := make([]*big.Int, nbInputs)
 	for i := range input {
 		var e fr_bn254.Element
@@ -144,48 +173,44 @@ func (g *Groth16Wrapper) GenSolInputParams() string {
 	for i := range input {
 		inputStr += input[i].String() + ","
 	}
-	inputStr = inputStr[:len(inputStr)-1]
+	inputStr = strings.TrimSuffix(inputStr, ",")
 	inputStr += "]"
-	return inputStr
+	return inputStr, nil
 }
 
 // 编译和ABI生成
 // groth16和plonk在这部分除solidity路径外，没有任何不同，但为了方便后续调用，分别在两个文件中添加了此函数
-func (g *Groth16Wrapper) SolCompileAndABIgen(solPath string) {
+func (g *Groth16Wrapper) SolCompileAndABIgen(solPath string) error {
+	dir, err := g.solWorkDir()
+	if err != nil {
+		return err
+	}
 	if solPath == "" {
-		logger.Info("solPath is empty, use default path: output/Groth16Verifier.sol")
-		solPath = "output/Groth16Verifier.sol"
-	}
-	compileCmd := exec.Command("solc", "--evm-version", "paris", "--combined-json", "abi,bin", solPath, "-o", "output", "--overwrite")
-	logger.Debug("compile command: %v", compileCmd.String())
-	if out, err := compileCmd.CombinedOutput(); err != nil {
-		logger.Error("failed to compile: %s", err.Error())
-		logger.Error("output : %s", string(out))
-	} else {
-		logger.Debug("compile success: %s", string(out))
-	}
-	abiGenCmd := exec.Command("abigen", "--combined-json", filepath.Join("output", "combined.json"), "--pkg", "main", "--out", filepath.Join("output", "gnark_solidity.go"))
-	logger.Debug("abiGen command: %v", abiGenCmd.String())
-	if out, err := abiGenCmd.CombinedOutput(); err != nil {
-		logger.Error("failed to generate abi: %s", err.Error())
-		logger.Error("output : %s", string(out))
-	} else {
-		logger.Debug("abiGen success: %s", string(out))
+		logger.Info("solPath is empty, use default path: Groth16Verifier.sol")
+		solPath = "Groth16Verifier.sol"
+	}
+	solPath = g.GetStore().(*store.FS).Path(solPath)
+	compileCmd := exec.Command("solc", "--evm-version", "paris", "--combined-json", "abi,bin", solPath, "-o", dir, "--overwrite")
+	if err := utils.RunCommand(compileCmd); err != nil {
+		return fmt.Errorf("failed to compile: %w", err)
+	}
+	abiGenCmd := exec.Command("abigen", "--combined-json", filepath.Join(dir, "combined.json"), "--pkg", "main", "--out", filepath.Join(dir, "gnark_solidity.go"))
+	if err := utils.RunCommand(abiGenCmd); err != nil {
+		return fmt.Errorf("failed to generate abi: %w", err)
 	}
+	return nil
 }
 
-func (g *Groth16Wrapper) GenNbCommitments() int {
-	_proof, ok := g.Proof.(interface{ MarshalSolidity() []byte })
-	if !ok {
-		logger.Fatal("marshal proof failed")
+func (g *Groth16Wrapper) GenNbCommitments() (int, error) {
+	proofBytes, err := g.solidityProof()
+	if err != nil {
+		return 0, err
 	}
-	proofBytes := _proof.MarshalSolidity()
 	c := new(big.Int).SetBytes(proofBytes[FpSize*8 : FpSize*8+4])
-	commitmentCount := int(c.Int64())
-	return commitmentCount
+	return int(c.Int64()), nil
 }
 
-func (g *Groth16Wrapper) SolGenMain() {
+func (g *Groth16Wrapper) SolGenMain() error {
 	helpers := template.FuncMap{
 		"mul": func(a, b int) int {
 			return a * b
@@ -193,11 +218,20 @@ func (g *Groth16Wrapper) SolGenMain() {
 	}
 	tmpl, err := template.New("").Funcs(helpers).Parse(Groth16Template)
 	if err != nil {
-		logger.Error("failed to parse template: %s", err.Error())
+		return fmt.Errorf("failed to parse template: %w", err)
+	}
+	prootStr, err := g.ProofMarshall()
+	if err != nil {
+		return err
+	}
+	bPublicWitness, err := g.solidityPublicWitness()
+	if err != nil {
+		return err
+	}
+	nbCommitments, err := g.GenNbCommitments()
+	if err != nil {
+		return err
 	}
-	prootStr := g.ProofMarshall()
-	inputStr := g.PublicWitnessMarshall()
-	nbCommitments := g.GenNbCommitments()
 	logger.Info("nbCommitments: %d", nbCommitments)
 
 	data := struct {
@@ -207,47 +241,65 @@ func (g *Groth16Wrapper) SolGenMain() {
 		NbCommitments  int
 	}{
 		Proof:          prootStr,
-		PublicInputs:   inputStr,
-		NbPublicInputs: len(g.WitnessPublic.Vector().(fr_bn254.Vector)),
+		PublicInputs:   hex.EncodeToString(bPublicWitness),
+		NbPublicInputs: len(bPublicWitness) / fr_bn254.Bytes,
 		NbCommitments:  nbCommitments,
 	}
-	file, err := os.Create("output/main.go")
+	return writeTemplate(g.GetStore(), "main.go", tmpl, data)
+}
+
+func (g *Groth16Wrapper) SolGenGoMod() error {
+	tmpl, err := template.New("").Parse(GoModTemplate)
 	if err != nil {
-		logger.Error("failed to create file: %s", err.Error())
+		return fmt.Errorf("failed to parse template: %w", err)
 	}
-	defer file.Close()
-	tmpl.Execute(file, data)
+	return writeTemplate(g.GetStore(), "go.mod", tmpl, nil)
 }
 
-func (g *Groth16Wrapper) SolGenGoMod() {
-	file, err := os.Create("output/go.mod")
+// writeTemplate 将模板渲染结果写入存储中的 name
+func writeTemplate(s store.ArtifactStore, name string, tmpl *template.Template, data any) error {
+	file, err := s.Create(name)
 	if err != nil {
-		logger.Error("failed to create file: %s", err.Error())
+		return &utils.IOError{Op: "create file", Path: name, Err: err}
 	}
-	defer file.Close()
-	tmpl, err := template.New("").Parse(GoModTemplate)
-	if err != nil {
-		logger.Error("failed to parse template: %s", err.Error())
+	if err := tmpl.Execute(file, data); err != nil {
+		file.Close()
+		return &utils.IOError{Op: "execute template", Path: name, Err: err}
+	}
+	if err := file.Close(); err != nil {
+		return &utils.IOError{Op: "close file", Path: name, Err: err}
 	}
-	tmpl.Execute(file, nil)
+	return nil
 }
 
-func (g *Groth16Wrapper) SolVerify() {
-	cmdGoModTidy := exec.Command("sh", "-c", "cd output && go mod tidy")
-	logger.Info("running go mod tidy: %s", cmdGoModTidy.String())
-	if out, err := cmdGoModTidy.CombinedOutput(); err != nil {
-		logger.Error("failed to run go mod tidy: %s", err.Error())
-		logger.Error("output : %s", string(out))
-	} else {
-		logger.Info("go mod tidy success: %s", string(out))
+func (g *Groth16Wrapper) SolVerify() error {
+	dir, err := g.solWorkDir()
+	if err != nil {
+		return err
+	}
+	cmdGoModTidy := exec.Command("go", "mod", "tidy")
+	cmdGoModTidy.Dir = dir
+	if err := utils.RunCommand(cmdGoModTidy); err != nil {
+		return fmt.Errorf("failed to run go mod tidy: %w", err)
 	}
 
-	cmdGoRun := exec.Command("sh", "-c", "cd output && go run main.go gnark_solidity.go")
-	logger.Info("running go run main.go gnark_solidity.go: %s", cmdGoRun.String())
-	if out, err := cmdGoRun.CombinedOutput(); err != nil {
-		logger.Error("failed to run go run main.go gnark_solidity.go: %s", err.Error())
-		logger.Error("output : %s", string(out))
-	} else {
-		logger.Info("go run main.go gnark_solidity.go success: %s", string(out))
+	cmdGoRun := exec.Command("go", "run", "main.go", "gnark_solidity.go")
+	cmdGoRun.Dir = dir
+	if err := utils.RunCommand(cmdGoRun); err != nil {
+		return fmt.Errorf("%w: solidity verifier rejected the proof: %w", utils.ErrInvalidProof, err)
+	}
+	logger.Info("solidity verifier accepted the proof")
+	return nil
+}
+
+// solWorkDir 返回 solc、abigen 和 go run 使用的目录，这些外部工具要求存储位于文件系统上
+func (g *Groth16Wrapper) solWorkDir() (string, error) {
+	fsStore, ok := g.GetStore().(*store.FS)
+	if !ok {
+		return "", fmt.Errorf("solidity toolchain requires a filesystem store, got %T", g.GetStore())
+	}
+	if fsStore.Root == "" {
+		return ".", nil
 	}
+	return fsStore.Root, nil
 }
@@ -1,6 +1,8 @@
 package groth16wrapper
 
 import (
+	"errors"
+	"os/exec"
 	"testing"
 
 	"github.com/oliverustc/gnarkabc/circuits"
@@ -20,22 +22,64 @@ func TestRemoveFileGroth16(t *testing.T) {
 func TestGroth16GenSolParams(t *testing.T) {
 	TestRemoveFileGroth16(t)
 	var circuit circuits.Product
-	circuit.PreCompile(nil)
+	circuit.PreCompile(circuits.NoParams{})
 	zk := NewWrapper(&circuit, ecc.BN254)
-	zk.Compile()
-	zk.Setup()
-	assignParams := []any{13, 17}
+	if err := zk.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	if err := zk.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	assignParams := circuits.ProductAssign{P: 13, Q: 17}
 	circuit.Assign(assignParams)
 	zk.SetAssignment(&circuit)
-	zk.Prove()
-	zk.Verify()
-	zk.ExportSolidity("")
-	prootStr := zk.GenSolProofParams()
+	if err := zk.Prove(); err != nil {
+		t.Fatal(err)
+	}
+	if err := zk.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	if err := zk.ExportSolidity(""); err != nil {
+		t.Fatal(err)
+	}
+	prootStr, err := zk.GenSolProofParams()
+	if err != nil {
+		t.Fatal(err)
+	}
 	t.Logf("proofStr:\n%s", prootStr)
-	inputStr := zk.GenSolInputParams()
+	inputStr, err := zk.GenSolInputParams()
+	if err != nil {
+		t.Fatal(err)
+	}
 	t.Logf("inputStr:\n%s", inputStr)
-	zk.SolCompileAndABIgen("")
-	zk.SolGenMain()
-	zk.SolGenGoMod()
-	zk.SolVerify()
+	if err := zk.SolGenMain(); err != nil {
+		t.Fatal(err)
+	}
+	if err := zk.SolGenGoMod(); err != nil {
+		t.Fatal(err)
+	}
+	for _, tool := range []string{"solc", "abigen"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skipf("%s not found, skip solidity verification", tool)
+		}
+	}
+	if err := zk.SolCompileAndABIgen(""); err != nil {
+		t.Fatal(err)
+	}
+	if err := zk.SolVerify(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGroth16SolidityErrors(t *testing.T) {
+	var circuit circuits.Product
+	circuit.PreCompile(circuits.NoParams{})
+	zk := NewWrapper(&circuit, ecc.BLS12_381)
+	if _, err := zk.GenSolProofParams(); !errors.Is(err, utils.ErrUnsupportedCurve) {
+		t.Fatalf("expected ErrUnsupportedCurve, got %v", err)
+	}
+	zk = NewWrapper(&circuit, ecc.BN254)
+	if _, err := zk.ProofMarshall(); !errors.Is(err, utils.ErrInvalidProof) {
+		t.Fatalf("expected ErrInvalidProof, got %v", err)
+	}
 }
@@ -1,35 +1,91 @@
 package groth16wrapper
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/oliverustc/gnarkabc/circuits"
 	"github.com/oliverustc/gnarkabc/logger"
 	"github.com/oliverustc/gnarkabc/utils"
+	"github.com/oliverustc/gnarkabc/wrapper/diagnose"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	recursion_groth16 "github.com/consensys/gnark/std/recursion/groth16"
 )
 
+// onePublic 只有一个公开变量，用于构造与 Product 不匹配的见证者
+type onePublic struct {
+	A frontend.Variable `gnark:",public"`
+}
+
+func (c *onePublic) Define(api frontend.API) error {
+	api.AssertIsEqual(c.A, c.A)
+	return nil
+}
+
+// commitCircuit 使用 BSB22 承诺，Groth16 证明中承诺的哈希受 RecursionTarget 对应的选项影响
+type commitCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (c *commitCircuit) Define(api frontend.API) error {
+	committer, ok := api.Compiler().(frontend.Committer)
+	if !ok {
+		return errors.New("compiler does not support commitments")
+	}
+	r, err := committer.Commit(c.X)
+	if err != nil {
+		return err
+	}
+	api.AssertIsDifferent(r, 0)
+	api.AssertIsEqual(api.Mul(c.X, c.X), c.Y)
+	return nil
+}
+
 func TestGroth16(t *testing.T) {
 	var circuit circuits.Product
-	circuit.PreCompile(nil)
+	circuit.PreCompile(circuits.NoParams{})
 	for _, curveName := range utils.CurveNameList {
 		curve := utils.CurveMap[curveName]
 		zk := NewWrapper(&circuit, curve)
-		zk.Compile()
-		zk.Setup()
-		compileTime := zk.BenchmarkCompile(10)
-		setupTime := zk.BenchmarkSetup(10)
+		if err := zk.Compile(); err != nil {
+			t.Fatal(err)
+		}
+		if err := zk.Setup(); err != nil {
+			t.Fatal(err)
+		}
+		compileTime, err := zk.BenchmarkCompile(10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		setupTime, err := zk.BenchmarkSetup(10)
+		if err != nil {
+			t.Fatal(err)
+		}
 
 		p := utils.RandInt(0, 1000)
 		q := utils.RandInt(0, 1000)
-		assignParams := []any{p, q}
+		assignParams := circuits.ProductAssign{P: p, Q: q}
 		circuit.Assign(assignParams)
 		zk.SetAssignment(&circuit)
-		zk.Prove()
-		zk.Verify()
+		if err := zk.Prove(); err != nil {
+			t.Fatal(err)
+		}
+		if err := zk.Verify(); err != nil {
+			t.Fatal(err)
+		}
 		logger.Info("groth16 on curve [ %s ] success", curveName)
 
-		proveTime := zk.BenchmarkProve(10)
-		verifyTime := zk.BenchmarkVerify(10)
+		proveTime, err := zk.BenchmarkProve(10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		verifyTime, err := zk.BenchmarkVerify(10)
+		if err != nil {
+			t.Fatal(err)
+		}
 
 		logger.Info("benchmark on compile : %s", compileTime.String())
 		logger.Info("benchmark on setup : %s", setupTime.String())
@@ -37,3 +93,114 @@ func TestGroth16(t *testing.T) {
 		logger.Info("benchmark on verify : %s", verifyTime.String())
 	}
 }
+
+func TestGroth16Errors(t *testing.T) {
+	var circuit circuits.Product
+	circuit.PreCompile(circuits.NoParams{})
+	zk := NewWrapper(&circuit, ecc.BN254)
+	if err := zk.Setup(); !errors.Is(err, utils.ErrMissingSetup) {
+		t.Fatalf("setup before compile: expected ErrMissingSetup, got %v", err)
+	}
+	if err := zk.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	if err := zk.Prove(); !errors.Is(err, utils.ErrMissingSetup) {
+		t.Fatalf("prove before setup: expected ErrMissingSetup, got %v", err)
+	}
+	if err := zk.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	if err := zk.Prove(); !errors.Is(err, utils.ErrMissingAssignment) {
+		t.Fatalf("prove without assignment: expected ErrMissingAssignment, got %v", err)
+	}
+
+	// N != P * Q
+	zk.SetAssignment(&circuits.Product{P: 3, Q: 4, N: 13})
+	if err := zk.Prove(); !errors.Is(err, utils.ErrUnsatisfiedConstraint) {
+		t.Fatalf("prove with wrong assignment: expected ErrUnsatisfiedConstraint, got %v", err)
+	}
+	// 见证者与约束系统不匹配属于输入错误，不应报告为约束不满足
+	w, err := frontend.NewWitness(&onePublic{A: 1}, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	zk.WitnessFull = w
+	if err := zk.Prove(); err == nil || errors.Is(err, utils.ErrUnsatisfiedConstraint) {
+		t.Fatalf("prove with mismatched witness: expected a plain error, got %v", err)
+	}
+
+	zk.SetAssignment(&circuits.Product{P: 3, Q: 4, N: 12})
+	if err := zk.Prove(); err != nil {
+		t.Fatal(err)
+	}
+	// 公开输入与证明不匹配
+	zk.SetAssignment(&circuits.Product{P: 3, Q: 4, N: 13})
+	if err := zk.Verify(); !errors.Is(err, utils.ErrInvalidProof) {
+		t.Fatalf("verify with wrong public input: expected ErrInvalidProof, got %v", err)
+	}
+
+	if err := zk.ReadProof("not_exist_proof"); !errors.Is(err, utils.ErrIO) {
+		t.Fatalf("read missing file: expected ErrIO, got %v", err)
+	}
+}
+
+// CheckAssignment 不需要编译和设置，失败时指出断言所在的源码行和涉及的字段
+// 设置递归目标后 Prove 和 Verify 使用相同的选项，选项不一致时验证失败
+func TestGroth16TargetOptions(t *testing.T) {
+	var circuit commitCircuit
+	zk := NewWrapper(&circuit, ecc.BLS12_377)
+	if err := zk.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	if err := zk.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	zk.SetAssignment(&commitCircuit{X: 13, Y: 169})
+	zk.SetRecursionTarget(ecc.BW6_761)
+	if err := zk.Prove(); err != nil {
+		t.Fatal(err)
+	}
+	if err := zk.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	if err := zk.Verify(recursion_groth16.GetNativeVerifierOptions(ecc.BW6_761.ScalarField(), ecc.BLS12_377.ScalarField())); err != nil {
+		t.Fatal(err)
+	}
+	zk.SetRecursionTarget(ecc.UNKNOWN)
+	if err := zk.Verify(); !errors.Is(err, utils.ErrInvalidProof) {
+		t.Fatalf("verify without recursion target: expected ErrInvalidProof, got %v", err)
+	}
+
+	// 不设置递归目标生成的证明不能以递归验证的选项验证
+	if err := zk.Prove(); err != nil {
+		t.Fatal(err)
+	}
+	if err := zk.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	zk.SetRecursionTarget(ecc.BW6_761)
+	if err := zk.Verify(); !errors.Is(err, utils.ErrInvalidProof) {
+		t.Fatalf("verify with recursion target: expected ErrInvalidProof, got %v", err)
+	}
+}
+
+func TestGroth16CheckAssignment(t *testing.T) {
+	var circuit circuits.Product
+	zk := NewWrapper(&circuit, ecc.BLS12_381)
+	if err := zk.CheckAssignment(); !errors.Is(err, utils.ErrMissingAssignment) {
+		t.Fatalf("check without assignment: expected ErrMissingAssignment, got %v", err)
+	}
+	zk.SetAssignment(&circuits.Product{P: 3, Q: 4, N: 12})
+	if err := zk.CheckAssignment(); err != nil {
+		t.Fatal(err)
+	}
+	zk.SetAssignment(&circuits.Product{P: 3, Q: 4, N: 13})
+	err := zk.CheckAssignment()
+	var failure *diagnose.Failure
+	if !errors.Is(err, utils.ErrUnsatisfiedConstraint) || !errors.As(err, &failure) {
+		t.Fatalf("check with wrong assignment: expected *diagnose.Failure, got %v", err)
+	}
+	if failure.Source != "api.AssertIsEqual(tc.N, api.Mul(tc.P, tc.Q))" || len(failure.Involved) != 3 {
+		t.Fatalf("unexpected failure %v", failure)
+	}
+}
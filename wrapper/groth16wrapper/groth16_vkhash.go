@@ -0,0 +1,123 @@
+package groth16wrapper
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/oliverustc/gnarkabc/utils"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	gchash "github.com/consensys/gnark-crypto/hash"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/schema"
+	"github.com/consensys/gnark/std/algebra"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_bls12381"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_bn254"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_bw6761"
+	"github.com/consensys/gnark/std/hash/mimc"
+	recursion_groth16 "github.com/consensys/gnark/std/recursion/groth16"
+
+	// 导入MiMC哈希函数包以注册它们
+	_ "github.com/consensys/gnark-crypto/ecc/bls12-381/fr/mimc"
+	_ "github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+	_ "github.com/consensys/gnark-crypto/ecc/bw6-761/fr/mimc"
+)
+
+// vkHashes 与 HashVerifyingKey 中 MiMC 对应的原生哈希，验证密钥在同一曲线的电路中以非原生算术表示
+var vkHashes = map[ecc.ID]gchash.Hash{
+	ecc.BN254:     gchash.MIMC_BN254,
+	ecc.BLS12_381: gchash.MIMC_BLS12_381,
+	ecc.BW6_761:   gchash.MIMC_BW6_761,
+}
+
+// CircuitVKHash 计算验证密钥的摘要，与同一曲线的电路中 HashVerifyingKey 的结果相同，支持 BN254、BLS12-381 和 BW6-761。
+// 摘要为验证密钥在电路中全部分量的 MiMC 哈希，电路可以以此检查作为见证者输入的验证密钥；
+// 与 wrapper.VKDigest 不同，后者是验证密钥序列化字节的 sha256 摘要，只用于电路外的完整性校验
+func CircuitVKHash(vk groth16.VerifyingKey) (*big.Int, error) {
+	if vk == nil {
+		return nil, fmt.Errorf("%w: verifying key is nil", utils.ErrMissingSetup)
+	}
+	curve := vk.CurveID()
+	var elements []*big.Int
+	var err error
+	switch curve {
+	case ecc.BN254:
+		elements, err = vkElements[sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](curve, vk)
+	case ecc.BLS12_381:
+		elements, err = vkElements[sw_bls12381.G1Affine, sw_bls12381.G2Affine, sw_bls12381.GTEl](curve, vk)
+	case ecc.BW6_761:
+		elements, err = vkElements[sw_bw6761.G1Affine, sw_bw6761.G2Affine, sw_bw6761.GTEl](curve, vk)
+	default:
+		return nil, fmt.Errorf("%w: verifying key digest on %s is not supported", utils.ErrUnsupportedCurve, curve.String())
+	}
+	if err != nil {
+		return nil, err
+	}
+	h := vkHashes[curve].New()
+	buf := make([]byte, (curve.ScalarField().BitLen()+7)/8)
+	for _, e := range elements {
+		e.FillBytes(buf)
+		h.Write(buf)
+	}
+	return new(big.Int).SetBytes(h.Sum(nil)), nil
+}
+
+// HashVerifyingKey 在电路中计算验证密钥的 MiMC 摘要，与 CircuitVKHash 相同
+func HashVerifyingKey[G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](api frontend.API, vk *recursion_groth16.VerifyingKey[G1El, G2El, GtEl]) (frontend.Variable, error) {
+	h, err := mimc.NewMiMC(api)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create mimc: %w", err)
+	}
+	if err := walkVariables(api.Compiler().Field(), vk, func(v frontend.Variable) error {
+		h.Write(v)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return h.Sum(), nil
+}
+
+// vkElements 按电路中的顺序取出验证密钥的全部分量
+func vkElements[G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](curve ecc.ID, vk groth16.VerifyingKey) ([]*big.Int, error) {
+	value, err := recursion_groth16.ValueOfVerifyingKey[G1El, G2El, GtEl](vk)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert verifying key: %w", err)
+	}
+	var elements []*big.Int
+	err = walkVariables(curve.ScalarField(), &value, func(v frontend.Variable) error {
+		e, err := bigInt(v)
+		if err != nil {
+			return err
+		}
+		elements = append(elements, e)
+		return nil
+	})
+	return elements, err
+}
+
+// walkVariables 按结构体字段的顺序对 v 中的每个 frontend.Variable 调用 fn，电路内外的顺序相同
+func walkVariables(field *big.Int, v any, fn func(frontend.Variable) error) error {
+	tVariable := reflect.TypeOf((*frontend.Variable)(nil)).Elem()
+	_, err := schema.Walk(field, v, tVariable, func(_ schema.LeafInfo, value reflect.Value) error {
+		return fn(value.Interface())
+	})
+	return err
+}
+
+// bigInt 将赋值中的常量转换为 *big.Int
+func bigInt(v frontend.Variable) (*big.Int, error) {
+	switch e := v.(type) {
+	case *big.Int:
+		return e, nil
+	case big.Int:
+		return &e, nil
+	case int:
+		return big.NewInt(int64(e)), nil
+	case interface{ BigInt(*big.Int) *big.Int }:
+		return e.BigInt(new(big.Int)), nil
+	default:
+		return nil, fmt.Errorf("unexpected verifying key element of type %T", v)
+	}
+}
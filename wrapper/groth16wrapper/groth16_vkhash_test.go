@@ -0,0 +1,52 @@
+package groth16wrapper
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/oliverustc/gnarkabc/circuits"
+	"github.com/oliverustc/gnarkabc/utils"
+
+	"github.com/consensys/gnark-crypto/ecc"
+)
+
+func TestCircuitVKHash(t *testing.T) {
+	product := NewWrapper(&circuits.Product{}, ecc.BN254)
+	hash := NewWrapper(&circuits.MimcHash{}, ecc.BN254)
+	for _, zk := range []*Groth16Wrapper{product, hash} {
+		if err := zk.Compile(); err != nil {
+			t.Fatal(err)
+		}
+		if err := zk.Setup(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a, err := CircuitVKHash(product.VK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := CircuitVKHash(hash.VK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Cmp(b) == 0 {
+		t.Fatal("expected different digests for different verifying keys")
+	}
+	if again, err := CircuitVKHash(product.VK); err != nil || again.Cmp(a) != 0 {
+		t.Fatalf("expected deterministic digest, got %v, %v", again, err)
+	}
+
+	native := NewWrapper(&circuits.Product{}, ecc.BLS12_377)
+	if err := native.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	if err := native.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CircuitVKHash(native.VK); !errors.Is(err, utils.ErrUnsupportedCurve) {
+		t.Fatalf("expected ErrUnsupportedCurve, got %v", err)
+	}
+	if _, err := CircuitVKHash(nil); !errors.Is(err, utils.ErrMissingSetup) {
+		t.Fatalf("expected ErrMissingSetup, got %v", err)
+	}
+}
@@ -0,0 +1,26 @@
+#!/bin/sh
+# 以 circom 和 snarkjs 生成 TestGroth16SnarkjsGenerated 使用的夹具，写入 ../snarkjs_bn254 和 ../snarkjs_bls12381。
+# 需要 circom 2 和 snarkjs（npm install -g snarkjs），p = 13, q = 17，公开输入为 221
+set -e
+cd "$(dirname "$0")"
+work=$(mktemp -d)
+trap 'rm -rf "$work"' EXIT
+echo '{"p": "13", "q": "17"}' > "$work/input.json"
+
+generate() {
+    curve=$1 prime=$2 out=../snarkjs_$3
+    circom product.circom --r1cs --wasm --prime "$prime" -o "$work"
+    snarkjs powersoftau new "$curve" 8 "$work/pot_0.ptau"
+    snarkjs powersoftau contribute "$work/pot_0.ptau" "$work/pot_1.ptau" -e="gnarkabc fixtures"
+    snarkjs powersoftau prepare phase2 "$work/pot_1.ptau" "$work/pot_final.ptau"
+    snarkjs groth16 setup "$work/product.r1cs" "$work/pot_final.ptau" "$work/product_0.zkey"
+    snarkjs zkey contribute "$work/product_0.zkey" "$work/product.zkey" -e="gnarkabc fixtures"
+    snarkjs wtns calculate "$work/product_js/product.wasm" "$work/input.json" "$work/witness.wtns"
+    mkdir -p "$out"
+    snarkjs zkey export verificationkey "$work/product.zkey" "$out/verification_key.json"
+    snarkjs groth16 prove "$work/product.zkey" "$work/witness.wtns" "$out/proof.json" "$out/public.json"
+    snarkjs groth16 verify "$out/verification_key.json" "$out/public.json" "$out/proof.json"
+}
+
+generate bn128 bn128 bn254
+generate bls12381 bls12381 bls12381
@@ -0,0 +1,11 @@
+pragma circom 2.0.0;
+
+// 与 circuits.Product 相同：公开输出 n = p * q
+template Product() {
+    signal input p;
+    signal input q;
+    signal output n;
+    n <== p * q;
+}
+
+component main = Product();
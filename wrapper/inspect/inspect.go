@@ -0,0 +1,320 @@
+// Package inspect 统计编译后的约束系统：变量个数、承诺、提示函数，以及按源码位置和 gnark 剖析标签划分的约束个数，
+// 报告可以输出为 JSON 或表格，用于查找电路中开销大的组件。
+// Analyze 在合法见证者上检查约束系统是否约束不足，列出可疑的变量
+package inspect
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/oliverustc/gnarkabc/utils"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/constraint"
+	bls12377cs "github.com/consensys/gnark/constraint/bls12-377"
+	bls12381cs "github.com/consensys/gnark/constraint/bls12-381"
+	bls24315cs "github.com/consensys/gnark/constraint/bls24-315"
+	bls24317cs "github.com/consensys/gnark/constraint/bls24-317"
+	bn254cs "github.com/consensys/gnark/constraint/bn254"
+	bw6633cs "github.com/consensys/gnark/constraint/bw6-633"
+	bw6761cs "github.com/consensys/gnark/constraint/bw6-761"
+	"github.com/consensys/gnark/constraint/solver"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/profile"
+	pprof "github.com/google/pprof/profile"
+)
+
+// gnarkModule 是 gnark 的模块路径，按源码位置统计时跳过其中的栈帧，定位到调用 gnark 的电路代码
+const gnarkModule = "github.com/consensys/gnark/"
+
+// Report 是约束系统的统计报告
+type Report struct {
+	Curve        string `json:"curve"`
+	System       string `json:"system"` // R1CS 或 SparseR1CS
+	Constraints  int    `json:"constraints"`
+	Instructions int    `json:"instructions"`
+	Coefficients int    `json:"coefficients"`
+
+	Public   int `json:"public"` // 公开变量个数，R1CS 中包含常数 1
+	Secret   int `json:"secret"`
+	Internal int `json:"internal"`
+
+	Commitments []Commitment `json:"commitments"`
+
+	Hints   int    `json:"hints"` // 提示函数的调用次数
+	HintIDs []Hint `json:"hintIDs"`
+
+	// 以下两项只在通过 Compile 编译时统计，约束系统本身不记录约束的来源
+	Locations []Entry `json:"locations,omitempty"` // 按调用 gnark 的源码位置划分
+	Labels    []Entry `json:"labels,omitempty"`    // 按 gnark 剖析标签，即产生约束的函数划分
+}
+
+// Commitment 是一个承诺及其承诺的变量个数
+type Commitment struct {
+	Index     int `json:"index"`     // Groth16 中为承诺的变量编号，PLONK 中为定义承诺的约束编号
+	Committed int `json:"committed"` // 被承诺的变量个数
+	Public    int `json:"public"`    // 被承诺的公开变量个数，仅 Groth16
+}
+
+// Hint 是一个提示函数及其调用次数
+type Hint struct {
+	ID    uint32 `json:"id"`
+	Name  string `json:"name"`
+	Calls int    `json:"calls"`
+}
+
+// Entry 是一个来源及其产生的约束个数，按约束个数降序排列
+type Entry struct {
+	Label       string `json:"label"`
+	Constraints int    `json:"constraints"`
+}
+
+// New 统计约束系统中的变量、承诺和提示函数，不包含约束的来源
+func New(ccs constraint.ConstraintSystem) (*Report, error) {
+	if ccs == nil {
+		return nil, fmt.Errorf("%w: constraint system is nil", utils.ErrMissingSetup)
+	}
+	system := coreSystem(ccs)
+	if system == nil {
+		return nil, fmt.Errorf("%w: constraint system of type %T", utils.ErrUnsupportedCurve, ccs)
+	}
+	curve, _ := utils.CurveOfField(ccs.Field())
+	name, _ := utils.CurveName(curve)
+	r := &Report{
+		Curve:        name,
+		System:       "R1CS",
+		Constraints:  ccs.GetNbConstraints(),
+		Instructions: ccs.GetNbInstructions(),
+		Coefficients: ccs.GetNbCoefficients(),
+		Public:       ccs.GetNbPublicVariables(),
+		Secret:       ccs.GetNbSecretVariables(),
+		Internal:     ccs.GetNbInternalVariables(),
+		Commitments:  commitments(ccs.GetCommitments()),
+		HintIDs:      []Hint{},
+	}
+	if system.Type == constraint.SystemSparseR1CS {
+		r.System = "SparseR1CS"
+	}
+
+	calls := make(map[uint32]int)
+	var hm constraint.HintMapping
+	for i, inst := range system.Instructions {
+		bp, ok := system.Blueprints[inst.BlueprintID].(constraint.BlueprintHint)
+		if !ok {
+			continue
+		}
+		bp.DecompressHint(&hm, ccs.GetInstruction(i))
+		calls[uint32(hm.HintID)]++
+		r.Hints++
+	}
+	for id, n := range calls {
+		r.HintIDs = append(r.HintIDs, Hint{ID: id, Name: system.MHintsDependencies[solver.HintID(id)], Calls: n})
+	}
+	sort.Slice(r.HintIDs, func(i, j int) bool {
+		if r.HintIDs[i].Calls != r.HintIDs[j].Calls {
+			return r.HintIDs[i].Calls > r.HintIDs[j].Calls
+		}
+		return r.HintIDs[i].Name < r.HintIDs[j].Name
+	})
+	return r, nil
+}
+
+// Compile 与 frontend.Compile 相同，编译时记录 gnark 剖析数据，返回的报告包含按源码位置和剖析标签划分的约束个数
+func Compile(curve ecc.ID, newBuilder frontend.NewBuilder, circuit frontend.Circuit, opts ...frontend.CompileOption) (constraint.ConstraintSystem, *Report, error) {
+	dir, err := os.MkdirTemp("", "gnarkabc-inspect")
+	if err != nil {
+		return nil, nil, &utils.IOError{Op: "create temp dir", Path: os.TempDir(), Err: err}
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "gnark.pprof")
+
+	p := profile.Start(profile.WithPath(path))
+	ccs, err := frontend.Compile(curve.ScalarField(), newBuilder, circuit, opts...)
+	p.Stop()
+	if err != nil {
+		return nil, nil, fmt.Errorf("compile circuit failed: %w", err)
+	}
+	r, err := New(ccs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, &utils.IOError{Op: "open profile", Path: path, Err: err}
+	}
+	defer f.Close()
+	prof, err := pprof.Parse(f)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse profile failed: %w", err)
+	}
+	r.Locations, r.Labels = breakdown(prof)
+	return ccs, r, nil
+}
+
+// JSON 返回缩进的 JSON 编码
+func (r *Report) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// WriteTable 将报告以表格形式写入 w，top 为每个划分最多列出的条目数，不大于 0 时全部列出
+func (r *Report) WriteTable(w io.Writer, top int) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "curve\t%s\t\n", r.Curve)
+	fmt.Fprintf(tw, "system\t%s\t\n", r.System)
+	fmt.Fprintf(tw, "constraints\t%d\t\n", r.Constraints)
+	fmt.Fprintf(tw, "instructions\t%d\t\n", r.Instructions)
+	fmt.Fprintf(tw, "coefficients\t%d\t\n", r.Coefficients)
+	fmt.Fprintf(tw, "public\t%d\t\n", r.Public)
+	fmt.Fprintf(tw, "secret\t%d\t\n", r.Secret)
+	fmt.Fprintf(tw, "internal\t%d\t\n", r.Internal)
+	fmt.Fprintf(tw, "commitments\t%d\t\n", len(r.Commitments))
+	fmt.Fprintf(tw, "hints\t%d\t\n", r.Hints)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(r.Commitments) > 0 {
+		fmt.Fprintln(w)
+		tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintf(tw, "commitment\tindex\tcommitted\tpublic\t\n")
+		for i, c := range r.Commitments {
+			fmt.Fprintf(tw, "%d\t%d\t%d\t%d\t\n", i, c.Index, c.Committed, c.Public)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	if len(r.HintIDs) > 0 {
+		fmt.Fprintln(w)
+		tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(tw, "calls\thint id\tname\n")
+		for _, h := range r.HintIDs {
+			fmt.Fprintf(tw, "%d\t%d\t%s\n", h.Calls, h.ID, h.Name)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	for _, part := range []struct {
+		name    string
+		entries []Entry
+	}{{"location", r.Locations}, {"label", r.Labels}} {
+		if len(part.entries) == 0 {
+			continue
+		}
+		fmt.Fprintln(w)
+		tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(tw, "constraints\tshare\t%s\n", part.name)
+		entries := part.entries
+		if top > 0 && len(entries) > top {
+			entries = entries[:top]
+		}
+		for _, e := range entries {
+			fmt.Fprintf(tw, "%d\t%.1f%%\t%s\n", e.Constraints, 100*float64(e.Constraints)/float64(max(r.Constraints, 1)), e.Label)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Table 返回报告的表格形式，列出所有条目
+func (r *Report) Table() string {
+	var sb strings.Builder
+	_ = r.WriteTable(&sb, 0)
+	return sb.String()
+}
+
+// breakdown 统计每个样本（即一个约束）的来源：剖析标签取最内层的栈帧，源码位置取最内层的 gnark 之外的栈帧
+func breakdown(prof *pprof.Profile) (locations, labels []Entry) {
+	byLocation := make(map[string]int)
+	byLabel := make(map[string]int)
+	for _, s := range prof.Sample {
+		var label, location string
+		for _, loc := range s.Location {
+			for _, line := range loc.Line {
+				if line.Function == nil {
+					continue
+				}
+				if label == "" {
+					label = line.Function.Name
+				}
+				if location == "" && !strings.HasPrefix(line.Function.SystemName, gnarkModule) {
+					location = fmt.Sprintf("%s:%d", line.Function.Filename, line.Line)
+				}
+			}
+		}
+		if label == "" {
+			label = "unknown"
+		}
+		if location == "" {
+			location = "unknown"
+		}
+		byLabel[label] += int(s.Value[0])
+		byLocation[location] += int(s.Value[0])
+	}
+	return entries(byLocation), entries(byLabel)
+}
+
+func entries(m map[string]int) []Entry {
+	res := make([]Entry, 0, len(m))
+	for label, n := range m {
+		res = append(res, Entry{Label: label, Constraints: n})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Constraints != res[j].Constraints {
+			return res[i].Constraints > res[j].Constraints
+		}
+		return res[i].Label < res[j].Label
+	})
+	return res
+}
+
+func commitments(c constraint.Commitments) []Commitment {
+	res := []Commitment{}
+	switch c := c.(type) {
+	case constraint.Groth16Commitments:
+		for _, gc := range c {
+			res = append(res, Commitment{
+				Index:     gc.CommitmentIndex,
+				Committed: len(gc.PublicAndCommitmentCommitted) + len(gc.PrivateCommitted),
+				Public:    gc.NbPublicCommitted,
+			})
+		}
+	case constraint.PlonkCommitments:
+		for _, pc := range c {
+			res = append(res, Commitment{Index: pc.CommitmentIndex, Committed: len(pc.Committed)})
+		}
+	}
+	return res
+}
+
+// coreSystem 返回各曲线约束系统中内嵌的 constraint.System，各曲线的 R1CS 和 SparseR1CS 是同一类型
+func coreSystem(ccs constraint.ConstraintSystem) *constraint.System {
+	switch cs := ccs.(type) {
+	case *bn254cs.R1CS:
+		return &cs.System
+	case *bls12377cs.R1CS:
+		return &cs.System
+	case *bls12381cs.R1CS:
+		return &cs.System
+	case *bls24315cs.R1CS:
+		return &cs.System
+	case *bls24317cs.R1CS:
+		return &cs.System
+	case *bw6633cs.R1CS:
+		return &cs.System
+	case *bw6761cs.R1CS:
+		return &cs.System
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,125 @@
+package inspect
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/oliverustc/gnarkabc/circuits"
+	"github.com/oliverustc/gnarkabc/utils"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/std/rangecheck"
+)
+
+// rangeCircuit 对 X 做范围检查，范围检查使用提示函数和承诺
+type rangeCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (c *rangeCircuit) Define(api frontend.API) error {
+	rangecheck.New(api).Check(c.X, 8)
+	api.AssertIsEqual(api.Add(c.X, 1), c.Y)
+	return nil
+}
+
+// builders 是两种约束系统的构造函数，泛型函数须显式实例化后才能放入 map
+var builders = map[string]frontend.NewBuilder{
+	"R1CS":       r1cs.NewBuilder[constraint.U64],
+	"SparseR1CS": scs.NewBuilder[constraint.U64],
+}
+
+func sum(entries []Entry) int {
+	n := 0
+	for _, e := range entries {
+		n += e.Constraints
+	}
+	return n
+}
+
+func TestCompile(t *testing.T) {
+	for system, builder := range builders {
+		_, r, err := Compile(ecc.BN254, builder, &circuits.MimcHash{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Curve != "BN254" || r.System != system {
+			t.Fatalf("unexpected curve %s and system %s", r.Curve, r.System)
+		}
+		// R1CS 的公开变量包含常数 1
+		public := map[string]int{"R1CS": 2, "SparseR1CS": 1}[system]
+		if r.Public != public || r.Secret != 1 || r.Internal == 0 {
+			t.Fatalf("%s: unexpected variables %d/%d/%d", system, r.Public, r.Secret, r.Internal)
+		}
+		if sum(r.Locations) != r.Constraints || sum(r.Labels) != r.Constraints {
+			t.Fatalf("%s: breakdown does not cover %d constraints", system, r.Constraints)
+		}
+		if !strings.Contains(r.Locations[0].Label, "circuits/mimchash.go") {
+			t.Fatalf("%s: expected the mimc circuit as the top location, got %s", system, r.Locations[0].Label)
+		}
+		t.Logf("%s\n%s", system, r.Table())
+	}
+}
+
+func TestHintsAndCommitments(t *testing.T) {
+	for system, builder := range builders {
+		_, r, err := Compile(ecc.BLS12_377, builder, &rangeCircuit{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(r.Commitments) != 1 || r.Commitments[0].Committed == 0 {
+			t.Fatalf("%s: expected one commitment, got %+v", system, r.Commitments)
+		}
+		if r.Hints == 0 || len(r.HintIDs) == 0 {
+			t.Fatalf("%s: expected hints", system)
+		}
+		calls := 0
+		for _, h := range r.HintIDs {
+			if h.Name == "" {
+				t.Fatalf("%s: hint %d has no name", system, h.ID)
+			}
+			calls += h.Calls
+		}
+		if calls != r.Hints {
+			t.Fatalf("%s: hint calls %d, expected %d", system, calls, r.Hints)
+		}
+
+		data, err := r.JSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded Report
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if decoded.Constraints != r.Constraints || len(decoded.HintIDs) != len(r.HintIDs) || len(decoded.Labels) != len(r.Labels) {
+			t.Fatalf("%s: report changed after json round trip", system)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(nil); !errors.Is(err, utils.ErrMissingSetup) {
+		t.Fatalf("expected ErrMissingSetup, got %v", err)
+	}
+	ccs, err := frontend.Compile(ecc.BW6_761.ScalarField(), r1cs.NewBuilder, &circuits.Product{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := New(ccs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Curve != "BW6-761" || r.Constraints != ccs.GetNbConstraints() || r.Locations != nil || r.Labels != nil {
+		t.Fatalf("unexpected report %+v", r)
+	}
+	if strings.Contains(r.Table(), "location") {
+		t.Fatal("table lists a breakdown without profiling data")
+	}
+}
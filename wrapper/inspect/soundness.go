@@ -0,0 +1,360 @@
+package inspect
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/oliverustc/gnarkabc/utils"
+
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/constraint/solver"
+	fcs "github.com/consensys/gnark/frontend/cs"
+	gnarklogger "github.com/consensys/gnark/logger"
+	"github.com/rs/zerolog"
+)
+
+// maskHint 生成 Groth16 承诺中的随机掩码，其输出本就可以任取
+const maskHint = "github.com/consensys/gnark/internal/hints.Randomize"
+
+// Wire 是约束系统中的一个变量，ID 为变量编号，R1CS 中 0 号变量为常数 1
+type Wire struct {
+	ID         int    `json:"id"`
+	Visibility string `json:"visibility"`     // public、secret 或 internal
+	Name       string `json:"name,omitempty"` // 公开和私有变量为字段名，提示函数的输出为 "函数名[输出下标]"
+}
+
+func (w Wire) String() string {
+	if w.Name == "" {
+		return fmt.Sprintf("%s wire %d", w.Visibility, w.ID)
+	}
+	return fmt.Sprintf("%s wire %d (%s)", w.Visibility, w.ID, w.Name)
+}
+
+// Alternative 是在公开输入不变时，使约束系统仍然可解的另一个变量值
+type Alternative struct {
+	Wire  Wire   `json:"wire"`
+	Value string `json:"value"` // 合法见证者中的值
+	Other string `json:"other"` // 另一个可解的值
+}
+
+// Soundness 是约束不足检查的结果，Unused 和 Alternatives 中的变量都是可疑的
+type Soundness struct {
+	Curve        string        `json:"curve"`
+	System       string        `json:"system"`
+	Unused       []Wire        `json:"unused"`       // 不出现在任何约束中的变量
+	Alternatives []Alternative `json:"alternatives"` // 改变后仍然可解的私有变量和提示函数输出
+	Solves       int           `json:"solves"`       // 重新求解的次数
+}
+
+// Suspicious 返回是否找到可疑的变量
+func (s *Soundness) Suspicious() bool {
+	return len(s.Unused) > 0 || len(s.Alternatives) > 0
+}
+
+// JSON 返回缩进的 JSON 编码
+func (s *Soundness) JSON() ([]byte, error) {
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// WriteTable 将可疑的变量以表格形式写入 w
+func (s *Soundness) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "curve\t%s\n", s.Curve)
+	fmt.Fprintf(tw, "system\t%s\n", s.System)
+	fmt.Fprintf(tw, "solves\t%d\n", s.Solves)
+	if !s.Suspicious() {
+		fmt.Fprintln(tw, "no suspicious wires")
+		return tw.Flush()
+	}
+	fmt.Fprintln(tw, "\nsuspicious wire\treason")
+	for _, wire := range s.Unused {
+		fmt.Fprintf(tw, "%s\tappears in no constraint\n", wire)
+	}
+	for _, a := range s.Alternatives {
+		fmt.Fprintf(tw, "%s\talso solved with %s instead of %s\n", a.Wire, a.Other, a.Value)
+	}
+	return tw.Flush()
+}
+
+// Table 返回可疑变量的表格形式
+func (s *Soundness) Table() string {
+	var sb strings.Builder
+	s.WriteTable(&sb)
+	return sb.String()
+}
+
+// hintCall 是求解时一次提示函数的调用，按求解的顺序排列
+type hintCall struct {
+	id      solver.HintID
+	name    string
+	outputs []int // 输出变量的编号
+}
+
+// Analyze 在合法的完整见证者 full 上检查约束系统是否约束不足：
+// 列出不出现在任何约束中的变量，并保持公开输入不变，逐个改变私有输入和提示函数的输出后重新求解（跳过承诺和随机掩码），
+// 仍然可解时说明存在另一个合法见证者。未在 gnark 中注册的提示函数须通过 hints 传入
+func Analyze(ccs constraint.ConstraintSystem, full witness.Witness, hints ...solver.Hint) (*Soundness, error) {
+	if ccs == nil {
+		return nil, fmt.Errorf("%w: constraint system is nil", utils.ErrMissingSetup)
+	}
+	if full == nil {
+		return nil, utils.ErrMissingAssignment
+	}
+	system := coreSystem(ccs)
+	if system == nil {
+		return nil, fmt.Errorf("%w: constraint system of type %T", utils.ErrUnsupportedCurve, ccs)
+	}
+	curve, _ := utils.CurveOfField(ccs.Field())
+	name, _ := utils.CurveName(curve)
+	s := &Soundness{Curve: name, System: "R1CS", Unused: []Wire{}, Alternatives: []Alternative{}}
+	if system.Type == constraint.SystemSparseR1CS {
+		s.System = "SparseR1CS"
+	}
+
+	calls := hintCalls(system, ccs)
+	wire := wireNamer(system, calls)
+	for _, id := range unusedWires(ccs, system) {
+		s.Unused = append(s.Unused, wire(id))
+	}
+
+	values, nbPublic, err := utils.WitnessValues(full, ccs.Field())
+	if err != nil {
+		return nil, err
+	}
+	// R1CS 的公开变量包含常数 1，见证者中没有
+	secretOffset := ccs.GetNbPublicVariables()
+	expected := secretOffset
+	if system.Type == constraint.SystemR1CS {
+		expected--
+	}
+	if nbPublic != expected || len(values)-nbPublic != ccs.GetNbSecretVariables() {
+		return nil, fmt.Errorf("%w: witness has %d public and %d secret values, expected %d and %d",
+			utils.ErrMissingAssignment, nbPublic, len(values)-nbPublic, expected, ccs.GetNbSecretVariables())
+	}
+	fns := make(map[solver.HintID]solver.Hint)
+	for id := range system.MHintsDependencies {
+		fns[id] = solver.GetRegisteredHint(id)
+	}
+	for _, h := range hints {
+		fns[solver.GetHintID(h)] = h
+	}
+	// 承诺由证明者计算，求解时以输入的哈希代替
+	fns[solver.GetHintID(fcs.Bsb22CommitmentComputePlaceholder)] = commitmentHint
+	for id, fn := range fns {
+		if fn == nil {
+			return nil, fmt.Errorf("hint %s is not registered", system.MHintsDependencies[id])
+		}
+	}
+
+	// 改变后的见证者大多不可解，求解失败时 gnark 以全局日志记录错误，检查期间关闭 gnark 的日志
+	gnarkLog := gnarklogger.Logger()
+	gnarklogger.Set(zerolog.Nop())
+	defer gnarklogger.Set(gnarkLog)
+
+	run := &solveRun{ccs: ccs, calls: calls, fns: fns, nbPublic: nbPublic}
+	// 合法见证者须可解，同时记录每次提示函数调用的输出
+	run.record = make([][]*big.Int, len(calls))
+	if err := run.solve(values, -1, 0, nil); err != nil {
+		return nil, fmt.Errorf("%w: %w", utils.ErrUnsatisfiedConstraint, err)
+	}
+	honest := run.record
+	run.record = nil
+
+	for i := nbPublic; i < len(values); i++ {
+		for _, other := range candidates(values[i], ccs.Field()) {
+			perturbed := append([]*big.Int(nil), values...)
+			perturbed[i] = other
+			s.Solves++
+			if run.solve(perturbed, -1, 0, nil) == nil {
+				s.Alternatives = append(s.Alternatives, Alternative{
+					Wire: wire(secretOffset + i - nbPublic), Value: values[i].String(), Other: other.String(),
+				})
+				break
+			}
+		}
+	}
+	bsb22 := solver.GetHintID(fcs.Bsb22CommitmentComputePlaceholder)
+	for k, call := range calls {
+		if call.id == bsb22 || call.name == maskHint {
+			continue
+		}
+		for j, v := range honest[k] {
+			for _, other := range candidates(v, ccs.Field()) {
+				s.Solves++
+				if run.solve(values, k, j, other) == nil {
+					s.Alternatives = append(s.Alternatives, Alternative{
+						Wire: wire(call.outputs[j]), Value: v.String(), Other: other.String(),
+					})
+					break
+				}
+			}
+		}
+	}
+	return s, nil
+}
+
+// solveRun 以单个任务求解约束系统，使提示函数按 Levels 的顺序调用，从而能用调用次序定位输出变量
+type solveRun struct {
+	ccs      constraint.ConstraintSystem
+	calls    []hintCall
+	fns      map[solver.HintID]solver.Hint
+	nbPublic int
+	record   [][]*big.Int // 不为 nil 时记录每次调用的输出
+}
+
+// solve 用 values 作为见证者求解，将第 call 次提示函数调用的第 output 个输出替换为 value，call 为负数时不替换
+func (r *solveRun) solve(values []*big.Int, call, output int, value *big.Int) error {
+	w, err := witness.New(r.ccs.Field())
+	if err != nil {
+		return err
+	}
+	ch := make(chan any, len(values))
+	for _, v := range values {
+		ch <- v
+	}
+	close(ch)
+	if err := w.Fill(r.nbPublic, len(values)-r.nbPublic, ch); err != nil {
+		return err
+	}
+
+	next := 0
+	opts := []solver.Option{solver.WithNbTasks(1)}
+	for id, fn := range r.fns {
+		opts = append(opts, solver.OverrideHint(id, func(mod *big.Int, in, out []*big.Int) error {
+			k := next
+			next++
+			if k >= len(r.calls) || r.calls[k].id != id {
+				return fmt.Errorf("unexpected call %d of hint %d", k, id)
+			}
+			if err := fn(mod, in, out); err != nil {
+				return err
+			}
+			if r.record != nil {
+				r.record[k] = make([]*big.Int, len(out))
+				for i := range out {
+					r.record[k][i] = new(big.Int).Set(out[i])
+				}
+			}
+			if k == call {
+				out[output].Set(value)
+			}
+			return nil
+		}))
+	}
+	return r.ccs.IsSolved(w, opts...)
+}
+
+// hintCalls 按求解顺序列出所有提示函数调用
+func hintCalls(system *constraint.System, ccs constraint.ConstraintSystem) []hintCall {
+	var calls []hintCall
+	var hm constraint.HintMapping
+	for _, level := range system.Levels {
+		for _, i := range level {
+			bp, ok := system.Blueprints[system.Instructions[i].BlueprintID].(constraint.BlueprintHint)
+			if !ok {
+				continue
+			}
+			bp.DecompressHint(&hm, ccs.GetInstruction(int(i)))
+			call := hintCall{id: hm.HintID, name: system.MHintsDependencies[hm.HintID]}
+			for o := hm.OutputRange.Start; o < hm.OutputRange.End; o++ {
+				call.outputs = append(call.outputs, int(o))
+			}
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// wireNamer 返回根据变量编号构造 Wire 的函数
+func wireNamer(system *constraint.System, calls []hintCall) func(id int) Wire {
+	hintOutputs := make(map[int]string)
+	for _, call := range calls {
+		for j, o := range call.outputs {
+			hintOutputs[o] = fmt.Sprintf("%s[%d]", call.name, j)
+		}
+	}
+	nbPublic, nbSecret := len(system.Public), len(system.Secret)
+	return func(id int) Wire {
+		switch {
+		case id < nbPublic:
+			return Wire{ID: id, Visibility: "public", Name: system.Public[id]}
+		case id < nbPublic+nbSecret:
+			return Wire{ID: id, Visibility: "secret", Name: system.Secret[id-nbPublic]}
+		default:
+			return Wire{ID: id, Visibility: "internal", Name: hintOutputs[id]}
+		}
+	}
+}
+
+// unusedWires 返回不以非零系数出现在任何约束中的变量，跳过 R1CS 中的常数 1
+func unusedWires(ccs constraint.ConstraintSystem, system *constraint.System) []int {
+	used := make([]bool, ccs.GetNbPublicVariables()+ccs.GetNbSecretVariables()+ccs.GetNbInternalVariables())
+	mark := func(id uint32, coeffs ...uint32) {
+		for _, c := range coeffs {
+			if c != constraint.CoeffIdZero {
+				used[id] = true
+				return
+			}
+		}
+	}
+	first := 0
+	if system.Type == constraint.SystemSparseR1CS {
+		for _, c := range ccs.(interface{ GetSparseR1Cs() []constraint.SparseR1C }).GetSparseR1Cs() {
+			mark(c.XA, c.QL, c.QM)
+			mark(c.XB, c.QR, c.QM)
+			mark(c.XC, c.QO)
+		}
+	} else {
+		first = 1
+		for _, c := range ccs.(interface{ GetR1Cs() []constraint.R1C }).GetR1Cs() {
+			for _, l := range []constraint.LinearExpression{c.L, c.R, c.O} {
+				for _, t := range l {
+					mark(t.VID, t.CID)
+				}
+			}
+		}
+	}
+	var res []int
+	for id := first; id < len(used); id++ {
+		if !used[id] {
+			res = append(res, id)
+		}
+	}
+	return res
+}
+
+// candidates 返回替换 v 的候选值：v 加一、减一，以及 0 和 1，跳过与 v 相同的值
+func candidates(v, field *big.Int) []*big.Int {
+	var res []*big.Int
+	seen := map[string]bool{v.String(): true}
+	for _, c := range []*big.Int{
+		new(big.Int).Add(v, big.NewInt(1)),
+		new(big.Int).Sub(v, big.NewInt(1)),
+		big.NewInt(0),
+		big.NewInt(1),
+	} {
+		c.Mod(c, field)
+		if !seen[c.String()] {
+			seen[c.String()] = true
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// commitmentHint 以输入的 SHA-256 哈希代替证明者计算的承诺
+func commitmentHint(mod *big.Int, in, out []*big.Int) error {
+	h := sha256.New()
+	for _, v := range in {
+		h.Write(v.Bytes())
+	}
+	out[0].SetBytes(h.Sum(nil))
+	out[0].Mod(out[0], mod)
+	return nil
+}
@@ -0,0 +1,163 @@
+package inspect
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/oliverustc/gnarkabc/circuits"
+	"github.com/oliverustc/gnarkabc/hash/mimchash"
+	"github.com/oliverustc/gnarkabc/hash/poseidon2hash"
+	"github.com/oliverustc/gnarkabc/utils"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+)
+
+// invHint 计算 X 的逆元，X 为 0 时输出 0；第二个输出不被使用
+func invHint(mod *big.Int, in, out []*big.Int) error {
+	if in[0].Sign() != 0 {
+		out[0].ModInverse(in[0], mod)
+	}
+	out[1].SetUint64(0)
+	return nil
+}
+
+// looseCircuit 是缺少约束的 IsZero：只约束了 X * Inv == NonZero，
+// X 为 0 时 Inv 可以取任意值，NonZero 也没有被约束为布尔值
+type looseCircuit struct {
+	X       frontend.Variable
+	NonZero frontend.Variable `gnark:",public"`
+}
+
+func (c *looseCircuit) Define(api frontend.API) error {
+	res, err := api.Compiler().NewHint(invHint, 2, c.X)
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(api.Mul(c.X, res[0]), c.NonZero)
+	return nil
+}
+
+func TestAnalyzeLoose(t *testing.T) {
+	for system, builder := range builders {
+		ccs, err := frontend.Compile(ecc.BN254.ScalarField(), builder, &looseCircuit{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		w, err := frontend.NewWitness(&looseCircuit{X: 0, NonZero: 0}, ecc.BN254.ScalarField())
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := Analyze(ccs, w, invHint)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !s.Suspicious() || s.System != system {
+			t.Fatalf("%s: expected suspicious wires", system)
+		}
+		// 第二个输出不出现在任何约束中
+		if len(s.Unused) != 1 || s.Unused[0].Visibility != "internal" || !strings.HasSuffix(s.Unused[0].Name, "invHint[1]") {
+			t.Fatalf("%s: unexpected unused wires %+v", system, s.Unused)
+		}
+		// X 为 0 时逆元可以任取，未使用的输出也可以任取
+		free := make(map[string]bool)
+		for _, a := range s.Alternatives {
+			free[a.Wire.Name] = true
+		}
+		if len(free) != 2 || !free[s.Unused[0].Name] || !strings.Contains(s.Table(), "invHint[0]") {
+			t.Fatalf("%s: unexpected alternatives %+v", system, s.Alternatives)
+		}
+		t.Logf("%s\n%s", system, s.Table())
+
+		// X 不为 0 时逆元被约束，但 NonZero 只要求 X 不为 0，X 本身可以任取
+		w, err = frontend.NewWitness(&looseCircuit{X: 5, NonZero: 1}, ecc.BN254.ScalarField())
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err = Analyze(ccs, w, invHint)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(s.Alternatives) != 2 || s.Alternatives[0].Wire.Name != "X" || s.Alternatives[1].Wire.Name != s.Unused[0].Name {
+			t.Fatalf("%s: unexpected alternatives %+v", system, s.Alternatives)
+		}
+	}
+}
+
+// circuits 中的电路没有可疑的变量
+func TestAnalyzeCircuits(t *testing.T) {
+	preImage := big.NewInt(42).Bytes()
+	hash := mimchash.MiMCHash(mimchash.MiMCCaseMap["BLS12-381"].Hash, [][]byte{preImage})
+	// gnark 的 Poseidon2 电路不支持 BLS12-381，在 BLS12-377 上检查
+	poseidon2 := poseidon2hash.Poseidon2Hash(poseidon2hash.Poseidon2CaseMap["BLS12-377"].Hash, [][]byte{preImage})
+	cases := []struct {
+		circuit, assignment frontend.Circuit
+		curve               ecc.ID
+	}{
+		{&circuits.Product{}, &circuits.Product{P: 3, Q: 4, N: 12}, ecc.BLS12_381},
+		{&circuits.MimcHash{}, &circuits.MimcHash{PreImage: preImage, Hash: hash}, ecc.BLS12_381},
+		{&circuits.Poseidon2Hash{}, &circuits.Poseidon2Hash{PreImage: preImage, Hash: poseidon2}, ecc.BLS12_377},
+		{&rangeCircuit{}, &rangeCircuit{X: 200, Y: 201}, ecc.BLS12_381},
+	}
+	for system, builder := range builders {
+		for _, c := range cases {
+			field := c.curve.ScalarField()
+			ccs, err := frontend.Compile(field, builder, c.circuit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w, err := frontend.NewWitness(c.assignment, field)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s, err := Analyze(ccs, w)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.Suspicious() || s.Solves == 0 {
+				t.Fatalf("%s %T: unexpected findings\n%s", system, c.circuit, s.Table())
+			}
+			data, err := s.JSON()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var decoded Soundness
+			if err := json.Unmarshal(data, &decoded); err != nil || decoded.Solves != s.Solves {
+				t.Fatalf("%s: soundness changed after json round trip: %v", system, err)
+			}
+		}
+	}
+}
+
+func TestAnalyzeErrors(t *testing.T) {
+	field := ecc.BN254.ScalarField()
+	ccs, err := frontend.Compile(field, builders["R1CS"], &circuits.Product{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Analyze(nil, nil); !errors.Is(err, utils.ErrMissingSetup) {
+		t.Fatalf("expected ErrMissingSetup, got %v", err)
+	}
+	w, err := frontend.NewWitness(&circuits.Product{P: 3, Q: 4, N: 13}, field)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Analyze(ccs, w); !errors.Is(err, utils.ErrUnsatisfiedConstraint) {
+		t.Fatalf("expected ErrUnsatisfiedConstraint, got %v", err)
+	}
+	// 未注册的提示函数
+	loose, err := frontend.Compile(field, builders["R1CS"], &looseCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err = frontend.NewWitness(&looseCircuit{X: 0, NonZero: 0}, field)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Analyze(loose, w); err == nil || !strings.Contains(err.Error(), "not registered") {
+		t.Fatalf("expected unregistered hint error, got %v", err)
+	}
+}
@@ -0,0 +1,349 @@
+// Package ivc 在 groth16wrapper 的递归电路之上实现增量可验证计算（IVC），
+// 每一步的证明验证上一步的证明并执行一次状态转移，最终证明说明了全部步骤的正确执行。
+//
+// 与最初的需求相比有两处范围上的变化：
+//   - 只支持 Groth16。plonkwrapper.OuterCircuit 将内层验证密钥作为常量编译进电路，
+//     而步骤电路须验证以自身验证密钥生成的上一步证明，验证密钥只能作为见证者输入，
+//     PLONK 的步骤电路需要另一种外层电路，尚未实现；
+//   - 不支持 BLS12-377 和 BW6-761 之间的原生递归。两条曲线只构成 2-chain 而不是循环，
+//     BW6-761 的证明无法在 BLS12-377 的电路中原生验证，步骤电路因此在同一曲线上以非原生算术验证上一步证明，
+//     支持 BN254、BLS12-381 和 BW6-761
+package ivc
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/oliverustc/gnarkabc/logger"
+	"github.com/oliverustc/gnarkabc/utils"
+	"github.com/oliverustc/gnarkabc/wrapper/cache"
+	"github.com/oliverustc/gnarkabc/wrapper/groth16wrapper"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_bls12381"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_bn254"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_bw6761"
+	"github.com/consensys/gnark/std/math/bits"
+	"github.com/consensys/gnark/std/math/emulated"
+	recursion_groth16 "github.com/consensys/gnark/std/recursion/groth16"
+)
+
+// Transition 是 IVC 每一步执行的状态转移，实现者的导出字段为每一步的私有输入
+type Transition interface {
+	// Apply 在电路中由当前状态计算下一个状态
+	Apply(api frontend.API, state []frontend.Variable) ([]frontend.Variable, error)
+	// Next 在电路外由当前状态计算下一个状态，结果须与 Apply 一致
+	Next(state []*big.Int) ([]*big.Int, error)
+}
+
+// Circuit 是 IVC 的步骤电路，以 OuterCircuit 的形式输入上一步的证明，验证后执行一次 Transition。
+// 公开输入依次为步骤电路验证密钥的摘要、已执行的步数、初始状态和当前状态；
+// 第一步的上一步证明为占位电路的证明，只检查其有效，不检查其验证密钥和公开输入
+type Circuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT] struct {
+	Prev       groth16wrapper.OuterCircuit[FR, G1El, G2El, GtEl] // 上一步的证明，验证密钥作为见证者输入
+	Transition Transition
+	VKDigest   frontend.Variable   `gnark:",public"`
+	Steps      frontend.Variable   `gnark:",public"`
+	Z0         []frontend.Variable `gnark:",public"`
+	Z          []frontend.Variable `gnark:",public"`
+}
+
+func (c *Circuit[FR, G1El, G2El, GtEl]) Define(api frontend.API) error {
+	verifier, err := recursion_groth16.NewVerifier[FR, G1El, G2El, GtEl](api)
+	if err != nil {
+		return fmt.Errorf("failed to create verifier: %w", err)
+	}
+	// 状态和占位证明的公开输入可以为 0，需要完全的点加法
+	if err := verifier.AssertProof(c.Prev.VerifyingKey, c.Prev.Proof, c.Prev.InnerWitness, recursion_groth16.WithCompleteArithmetic()); err != nil {
+		return err
+	}
+	n := len(c.Z)
+	if len(c.Z0) != n || len(c.Prev.InnerWitness.Public) != 2+2*n {
+		return fmt.Errorf("previous step has %d public inputs, expected %d", len(c.Prev.InnerWitness.Public), 2+2*n)
+	}
+	field, err := emulated.NewField[FR](api)
+	if err != nil {
+		return fmt.Errorf("failed to create field: %w", err)
+	}
+	// 上一步与本步在同一曲线上，规范表示的比特可以直接组合为原生变量
+	prev := make([]frontend.Variable, len(c.Prev.InnerWitness.Public))
+	for i := range prev {
+		prev[i] = bits.FromBinary(api, field.ToBitsCanonical(&c.Prev.InnerWitness.Public[i]))
+	}
+	vkHash, err := groth16wrapper.HashVerifyingKey(api, &c.Prev.VerifyingKey)
+	if err != nil {
+		return err
+	}
+
+	first := api.IsZero(api.Sub(c.Steps, 1))
+	assertLinked := func(a, b frontend.Variable) {
+		api.AssertIsEqual(api.Select(first, b, a), b)
+	}
+	assertLinked(vkHash, c.VKDigest)
+	assertLinked(prev[0], c.VKDigest)
+	assertLinked(prev[1], api.Sub(c.Steps, 1))
+	state := make([]frontend.Variable, n)
+	for i := range n {
+		assertLinked(prev[2+i], c.Z0[i])
+		state[i] = api.Select(first, c.Z0[i], prev[2+n+i])
+	}
+	next, err := c.Transition.Apply(api, state)
+	if err != nil {
+		return err
+	}
+	if len(next) != n {
+		return fmt.Errorf("transition returned %d state elements, expected %d", len(next), n)
+	}
+	for i := range n {
+		api.AssertIsEqual(next[i], c.Z[i])
+	}
+	return nil
+}
+
+// ivcBase 是第一步中被验证的占位电路，公开输入和承诺的个数与步骤电路相同
+type ivcBase struct {
+	Inputs []frontend.Variable `gnark:",public"`
+	X      frontend.Variable
+}
+
+func (b *ivcBase) Define(api frontend.API) error {
+	committer, ok := api.(frontend.Committer)
+	if !ok {
+		return fmt.Errorf("builder does not support commitments")
+	}
+	commitment, err := committer.Commit(b.X)
+	if err != nil {
+		return err
+	}
+	// X 为 0，约束只用于保留承诺和公开输入
+	api.AssertIsEqual(api.Mul(b.X, commitment), 0)
+	for _, input := range b.Inputs {
+		api.AssertIsEqual(api.Mul(b.X, input), 0)
+	}
+	return nil
+}
+
+// ivcGadget 按曲线创建步骤电路的占位和赋值
+type ivcGadget struct {
+	placeholder func(baseCCS constraint.ConstraintSystem, transition Transition, n int) (frontend.Circuit, error)
+	assign      func(prev *groth16wrapper.Groth16Wrapper, transition Transition, digest *big.Int, steps int, z0, z []*big.Int) (frontend.Circuit, error)
+}
+
+// ivcGadgets 列出支持 IVC 的曲线，步骤电路以非原生算术验证同一曲线上的上一步证明。
+// BLS12-377 与 BW6-761 只构成 2-chain 而不是循环，BW6-761 的证明无法在 BLS12-377 的电路中原生验证，因此不能用于 IVC
+var ivcGadgets = map[ecc.ID]ivcGadget{
+	ecc.BN254:     newIVCGadget[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](),
+	ecc.BLS12_381: newIVCGadget[sw_bls12381.ScalarField, sw_bls12381.G1Affine, sw_bls12381.G2Affine, sw_bls12381.GTEl](),
+	ecc.BW6_761:   newIVCGadget[sw_bw6761.ScalarField, sw_bw6761.G1Affine, sw_bw6761.G2Affine, sw_bw6761.GTEl](),
+}
+
+func newIVCGadget[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT]() ivcGadget {
+	return ivcGadget{
+		placeholder: func(baseCCS constraint.ConstraintSystem, transition Transition, n int) (frontend.Circuit, error) {
+			c := &Circuit[FR, G1El, G2El, GtEl]{
+				Transition: transition,
+				Z0:         make([]frontend.Variable, n),
+				Z:          make([]frontend.Variable, n),
+			}
+			if err := c.Prev.PreCompile(groth16wrapper.OuterCompileParams{InnerCCS: baseCCS}); err != nil {
+				return nil, err
+			}
+			return c, nil
+		},
+		assign: func(prev *groth16wrapper.Groth16Wrapper, transition Transition, digest *big.Int, steps int, z0, z []*big.Int) (frontend.Circuit, error) {
+			c := &Circuit[FR, G1El, G2El, GtEl]{
+				Transition: transition,
+				VKDigest:   digest,
+				Steps:      steps,
+				Z0:         make([]frontend.Variable, len(z0)),
+				Z:          make([]frontend.Variable, len(z)),
+			}
+			for i := range z0 {
+				c.Z0[i] = z0[i]
+				c.Z[i] = z[i]
+			}
+			if err := c.Prev.Assign(groth16wrapper.OuterAssignParams{InnerVK: prev.VK, InnerWitness: prev.WitnessFull, InnerProof: prev.Proof}); err != nil {
+				return nil, err
+			}
+			return c, nil
+		},
+	}
+}
+
+// Chain 以 Groth16 递归证明顺序执行的状态转移，每一步的证明验证上一步的证明并执行一次 Transition，
+// 最终证明说明从初始状态 Z0 经过 Steps 步到达当前状态，见 Verify
+type Chain struct {
+	Curve ecc.ID
+	Z0    []*big.Int
+
+	gadget ivcGadget
+	zk     *groth16wrapper.Groth16Wrapper // 步骤电路
+	digest *big.Int                       // 步骤电路验证密钥的摘要
+	prev   *groth16wrapper.Groth16Wrapper // 上一步的证明，尚未执行时为占位电路的证明
+	steps  int
+	state  []*big.Int
+}
+
+// New 在 curve 上编译并设置以 transition 为状态转移的步骤电路，支持 BN254、BLS12-381 和 BW6-761。
+// transition 只用于确定电路的形状，c 不为空时从缓存加载参数；步骤电路含有非原生的配对运算，设置和每一步的证明都较慢
+func New(curve ecc.ID, transition Transition, z0 []*big.Int, c *cache.Cache) (*Chain, error) {
+	gadget, ok := ivcGadgets[curve]
+	if !ok {
+		return nil, fmt.Errorf("%w: IVC on %s is not supported", utils.ErrUnsupportedCurve, curve.String())
+	}
+	if transition == nil {
+		return nil, fmt.Errorf("%w: transition is nil", utils.ErrMissingSetup)
+	}
+	if len(z0) == 0 {
+		return nil, fmt.Errorf("%w: initial state is empty", utils.ErrMissingAssignment)
+	}
+	n := len(z0)
+	base, err := proveIVCBase(curve, n, c)
+	if err != nil {
+		return nil, err
+	}
+	circuit, err := gadget.placeholder(base.CCS, transition, n)
+	if err != nil {
+		return nil, err
+	}
+	zk := groth16wrapper.NewWrapper(circuit, curve)
+	if _, err := c.Setup(zk); err != nil {
+		return nil, fmt.Errorf("setup step circuit: %w", err)
+	}
+	if err := checkIVCShape(zk.CCS, base.CCS); err != nil {
+		return nil, err
+	}
+	digest, err := groth16wrapper.CircuitVKHash(zk.VK)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("IVC step circuit on %s has %d constraints", curve.String(), zk.CCS.GetNbConstraints())
+	return &Chain{Curve: curve, Z0: z0, gadget: gadget, zk: zk, digest: digest, prev: base, state: z0}, nil
+}
+
+// Step 以 transition 中的私有输入执行一步状态转移，并证明该步验证了上一步的证明
+func (ch *Chain) Step(transition Transition) error {
+	if transition == nil {
+		return fmt.Errorf("%w: transition is nil", utils.ErrMissingAssignment)
+	}
+	next, err := transition.Next(ch.state)
+	if err != nil {
+		return err
+	}
+	if len(next) != len(ch.state) {
+		return fmt.Errorf("transition returned %d state elements, expected %d", len(next), len(ch.state))
+	}
+	assignment, err := ch.gadget.assign(ch.prev, transition, ch.digest, ch.steps+1, ch.Z0, next)
+	if err != nil {
+		return err
+	}
+	// 每一步使用独立的包装器保存证明，约束系统和密钥在各步之间共享
+	zk := groth16wrapper.NewWrapper(ch.zk.Circuit, ch.Curve)
+	zk.CCS, zk.PK, zk.VK = ch.zk.CCS, ch.zk.PK, ch.zk.VK
+	zk.SetAssignment(assignment)
+	zk.SetRecursionTarget(ch.Curve)
+	if err := zk.Prove(); err != nil {
+		return fmt.Errorf("step %d: %w", ch.steps+1, err)
+	}
+	ch.prev, ch.state = zk, next
+	ch.steps++
+	logger.Debug("IVC step %d proved, took: %s", ch.steps, zk.ProveTime.String())
+	return nil
+}
+
+// Steps 返回已执行的步数
+func (ch *Chain) Steps() int {
+	return ch.steps
+}
+
+// State 返回当前状态
+func (ch *Chain) State() []*big.Int {
+	return ch.state
+}
+
+// Digest 返回步骤电路验证密钥的摘要，即证明的第一个公开输入
+func (ch *Chain) Digest() *big.Int {
+	return ch.digest
+}
+
+// FinalProof 返回最后一步的包装器，包含步骤电路的约束系统、密钥、证明和公开见证者
+func (ch *Chain) FinalProof() (*groth16wrapper.Groth16Wrapper, error) {
+	if ch.steps == 0 {
+		return nil, fmt.Errorf("%w: no IVC step has been proved", utils.ErrMissingAssignment)
+	}
+	if err := ch.prev.GenerateWitness(true); err != nil {
+		return nil, err
+	}
+	return ch.prev, nil
+}
+
+// Verify 验证 IVC 的最终证明，即以 vk 为验证密钥的步骤电路从 z0 经过 steps 步到达状态 z
+func Verify(vk groth16.VerifyingKey, proof groth16.Proof, steps int, z0, z []*big.Int) error {
+	if proof == nil {
+		return fmt.Errorf("%w: proof is nil", utils.ErrInvalidProof)
+	}
+	digest, err := groth16wrapper.CircuitVKHash(vk)
+	if err != nil {
+		return err
+	}
+	if len(z0) != len(z) {
+		return fmt.Errorf("initial state has %d elements, final state has %d", len(z0), len(z))
+	}
+	field := vk.CurveID().ScalarField()
+	public, err := witness.New(field)
+	if err != nil {
+		return err
+	}
+	inputs := append([]*big.Int{digest, big.NewInt(int64(steps))}, append(append([]*big.Int{}, z0...), z...)...)
+	values := make(chan any, len(inputs))
+	for _, input := range inputs {
+		values <- input
+	}
+	close(values)
+	if err := public.Fill(len(inputs), 0, values); err != nil {
+		return err
+	}
+	if err := groth16.Verify(proof, vk, public, recursion_groth16.GetNativeVerifierOptions(field, field)); err != nil {
+		return fmt.Errorf("%w: %w", utils.ErrInvalidProof, err)
+	}
+	return nil
+}
+
+// proveIVCBase 设置占位电路并生成公开输入全为 0 的证明，状态有 n 个元素
+func proveIVCBase(curve ecc.ID, n int, c *cache.Cache) (*groth16wrapper.Groth16Wrapper, error) {
+	base := groth16wrapper.NewWrapper(&ivcBase{Inputs: make([]frontend.Variable, 2+2*n)}, curve)
+	if _, err := c.Setup(base); err != nil {
+		return nil, fmt.Errorf("setup base circuit: %w", err)
+	}
+	assignment := &ivcBase{Inputs: make([]frontend.Variable, 2+2*n), X: 0}
+	for i := range assignment.Inputs {
+		assignment.Inputs[i] = 0
+	}
+	base.SetAssignment(assignment)
+	base.SetRecursionTarget(curve)
+	if err := base.Prove(); err != nil {
+		return nil, fmt.Errorf("prove base circuit: %w", err)
+	}
+	return base, nil
+}
+
+// checkIVCShape 检查步骤电路与占位电路的公开输入和承诺一致，否则两者的证明不能由同一个验证电路验证
+func checkIVCShape(stepCCS, baseCCS constraint.ConstraintSystem) error {
+	stepCommitments := stepCCS.GetCommitments().(constraint.Groth16Commitments)
+	baseCommitments := baseCCS.GetCommitments().(constraint.Groth16Commitments)
+	if stepCCS.GetNbPublicVariables() != baseCCS.GetNbPublicVariables() || len(stepCommitments) != len(baseCommitments) ||
+		!reflect.DeepEqual(
+			stepCommitments.GetPublicAndCommitmentCommitted(stepCommitments.CommitmentIndexes(), stepCCS.GetNbPublicVariables()),
+			baseCommitments.GetPublicAndCommitmentCommitted(baseCommitments.CommitmentIndexes(), baseCCS.GetNbPublicVariables()),
+		) {
+		return fmt.Errorf("step circuit has %d public inputs and %d commitments, expected %d and %d",
+			stepCCS.GetNbPublicVariables(), len(stepCommitments), baseCCS.GetNbPublicVariables(), len(baseCommitments))
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package ivc
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/oliverustc/gnarkabc/utils"
+	"github.com/oliverustc/gnarkabc/wrapper/groth16wrapper"
+	"github.com/oliverustc/gnarkabc/wrapper/testutil"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+)
+
+// ledger 是余额账本的状态转移，状态为余额和已记录的更新次数
+type ledger struct {
+	Amount frontend.Variable
+}
+
+func (l *ledger) Apply(api frontend.API, state []frontend.Variable) ([]frontend.Variable, error) {
+	return []frontend.Variable{api.Add(state[0], l.Amount), api.Add(state[1], 1)}, nil
+}
+
+func (l *ledger) Next(state []*big.Int) ([]*big.Int, error) {
+	amount := big.NewInt(int64(l.Amount.(int)))
+	return []*big.Int{new(big.Int).Add(state[0], amount), new(big.Int).Add(state[1], big.NewInt(1))}, nil
+}
+
+func bigInts(values ...int64) []*big.Int {
+	res := make([]*big.Int, len(values))
+	for i, v := range values {
+		res[i] = big.NewInt(v)
+	}
+	return res
+}
+
+func TestNewErrors(t *testing.T) {
+	z0 := bigInts(100, 0)
+	if _, err := New(ecc.BLS12_377, &ledger{}, z0, nil); !errors.Is(err, utils.ErrUnsupportedCurve) {
+		t.Fatalf("expected ErrUnsupportedCurve, got %v", err)
+	}
+	if _, err := New(ecc.BN254, nil, z0, nil); !errors.Is(err, utils.ErrMissingSetup) {
+		t.Fatalf("expected ErrMissingSetup, got %v", err)
+	}
+	if _, err := New(ecc.BN254, &ledger{}, nil, nil); !errors.Is(err, utils.ErrMissingAssignment) {
+		t.Fatalf("expected ErrMissingAssignment, got %v", err)
+	}
+	var v Chain
+	if _, err := v.FinalProof(); !errors.Is(err, utils.ErrMissingAssignment) {
+		t.Fatalf("expected ErrMissingAssignment, got %v", err)
+	}
+}
+
+// 在测试引擎中检查步骤电路的第一步和衔接的第二步，不设置步骤电路。
+// 第二步以占位电路的证明代替上一步证明，并将占位电路的验证密钥摘要作为步骤电路的摘要
+func TestIVCSolved(t *testing.T) {
+	curve := ecc.BN254
+	field := curve.ScalarField()
+	gadget := ivcGadgets[curve]
+	base, err := proveIVCBase(curve, 2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	circuit, err := gadget.placeholder(base.CCS, &ledger{}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 第一步不检查占位证明的验证密钥和公开输入
+	first, err := gadget.assign(base, &ledger{Amount: 5}, big.NewInt(7), 1, bigInts(100, 0), bigInts(105, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := test.IsSolved(circuit, first, field); err != nil {
+		t.Fatal(err)
+	}
+
+	digest, err := groth16wrapper.CircuitVKHash(base.VK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := groth16wrapper.NewWrapper(base.Circuit, curve)
+	prev.CCS, prev.PK, prev.VK = base.CCS, base.PK, base.VK
+	prev.SetAssignment(&ivcBase{Inputs: []frontend.Variable{digest, 1, 100, 0, 105, 1}, X: 0})
+	prev.SetRecursionTarget(curve)
+	if err := prev.Prove(); err != nil {
+		t.Fatal(err)
+	}
+	second, err := gadget.assign(prev, &ledger{Amount: 3}, digest, 2, bigInts(100, 0), bigInts(108, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := test.IsSolved(circuit, second, field); err != nil {
+		t.Fatal(err)
+	}
+
+	// 上一步的验证密钥与步骤电路的摘要不符时不能衔接
+	other, err := gadget.assign(prev, &ledger{Amount: 3}, big.NewInt(7), 2, bigInts(100, 0), bigInts(108, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := test.IsSolved(circuit, other, field); err == nil {
+		t.Fatal("expected unsolved circuit with a different verifying key digest")
+	}
+	// 状态与上一步的结果不衔接
+	skipped, err := gadget.assign(prev, &ledger{Amount: 3}, digest, 2, bigInts(100, 0), bigInts(103, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := test.IsSolved(circuit, skipped, field); err == nil {
+		t.Fatal("expected unsolved circuit when the state does not follow the previous step")
+	}
+}
+
+func TestIVCRecursion(t *testing.T) {
+	z0 := bigInts(100, 0)
+	chain, err := New(ecc.BN254, &ledger{}, z0, testutil.ArtifactCache(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, amount := range []int{5, 3} {
+		if err := chain.Step(&ledger{Amount: amount}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	final, err := chain.FinalProof()
+	if err != nil {
+		t.Fatal(err)
+	}
+	z := chain.State()
+	if chain.Steps() != 2 || z[0].Int64() != 108 || z[1].Int64() != 2 {
+		t.Fatalf("unexpected state %v after %d steps", z, chain.Steps())
+	}
+	if err := Verify(final.VK, final.Proof, 2, z0, z); err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(final.VK, final.Proof, 1, z0, z); !errors.Is(err, utils.ErrInvalidProof) {
+		t.Fatalf("expected ErrInvalidProof for a wrong step count, got %v", err)
+	}
+}
@@ -0,0 +1,300 @@
+// Package mutation 对电路做自动化的反向测试：由一个合法赋值生成一组变异赋值，
+// 包括翻转公开输入、输入加减一、交换相邻字段以及 uints.U8 字节越界，
+// 检查每个变异赋值都无法求解或无法通过验证
+package mutation
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/oliverustc/gnarkabc/logger"
+	"github.com/oliverustc/gnarkabc/utils"
+	"github.com/oliverustc/gnarkabc/wrapper"
+	"github.com/oliverustc/gnarkabc/wrapper/diagnose"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/schema"
+	"github.com/consensys/gnark/std/math/uints"
+)
+
+// 变异的种类
+const (
+	KindFlip       = "flip"         // 翻转公开输入最低字节的所有位
+	KindOffByOne   = "off-by-one"   // 输入加一或减一，按标量域取模
+	KindSwap       = "swap"         // 交换两个相邻且值不同的输入
+	KindOutOfRange = "out-of-range" // uints.U8 的值加 256，低 8 位不变
+)
+
+var (
+	tVariable = reflect.TypeOf((*frontend.Variable)(nil)).Elem()
+	tU8       = reflect.TypeOf(uints.U8{})
+)
+
+// Mutation 是对合法赋值的一次变异
+type Mutation struct {
+	Kind        string
+	Description string // 如 "Hash+1"、"swap PreImage Hash"
+
+	changes    map[int]*big.Int // 叶子字段下标到变异后的值
+	publicOnly bool             // 只改变了公开输入
+}
+
+// Escape 是没有被拒绝的变异，说明电路可能缺少约束。
+// 变异也可能恰好得到另一个合法赋值，如交换乘积电路的两个因子，需要结合电路的语义判断
+type Escape struct {
+	Mutation Mutation
+	Reason   string
+}
+
+// Report 是在一条曲线和一种证明系统上运行变异的结果
+type Report struct {
+	Scheme    string
+	Curve     string
+	Mutations int
+	Unsolved  int      // 求解失败的变异数
+	Rejected  int      // 求解成功但验证失败的变异数
+	Escaped   []Escape // 求解和验证都通过的变异
+}
+
+// Err 在有变异未被拒绝时返回错误，列出所有未被拒绝的变异
+func (r *Report) Err() error {
+	if len(r.Escaped) == 0 {
+		return nil
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d of %d mutations accepted by %s on %s:", len(r.Escaped), r.Mutations, r.Scheme, r.Curve)
+	for _, e := range r.Escaped {
+		fmt.Fprintf(&sb, "\n\t%s (%s): %s", e.Mutation.Description, e.Mutation.Kind, e.Reason)
+	}
+	return errors.New(sb.String())
+}
+
+// leaf 是赋值中的一个 frontend.Variable 字段
+type leaf struct {
+	diagnose.Field
+	value reflect.Value // 可设置的字段值
+	byte  bool          // 是否为 uints.U8 的 Val
+}
+
+// leaves 按电路结构体中的声明顺序返回赋值的所有叶子字段
+func leaves(assignment frontend.Circuit, field *big.Int) ([]leaf, error) {
+	fields, err := diagnose.Fields(assignment, field)
+	if err != nil {
+		return nil, err
+	}
+	bytes := make(map[uintptr]bool)
+	collectBytes(reflect.ValueOf(assignment), bytes)
+	res := make([]leaf, 0, len(fields))
+	_, err = schema.Walk(field, assignment, tVariable, func(f schema.LeafInfo, tInput reflect.Value) error {
+		if !tInput.CanSet() {
+			return fmt.Errorf("field %s is not settable", f.FullName())
+		}
+		res = append(res, leaf{Field: fields[len(res)], value: tInput, byte: bytes[tInput.Addr().Pointer()]})
+		return nil
+	})
+	return res, err
+}
+
+// collectBytes 记录所有 uints.U8 中 Val 字段的地址
+func collectBytes(v reflect.Value, bytes map[uintptr]bool) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			collectBytes(v.Elem(), bytes)
+		}
+	case reflect.Struct:
+		if v.Type() == tU8 {
+			if v.CanAddr() {
+				bytes[v.Field(0).Addr().Pointer()] = true
+			}
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			collectBytes(v.Field(i), bytes)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			collectBytes(v.Index(i), bytes)
+		}
+	}
+}
+
+// Mutate 由合法赋值生成所有变异，不修改 assignment
+func Mutate(assignment frontend.Circuit, field *big.Int) ([]Mutation, error) {
+	ls, err := leaves(assignment, field)
+	if err != nil {
+		return nil, err
+	}
+	return mutate(ls, field), nil
+}
+
+func mutate(ls []leaf, field *big.Int) []Mutation {
+	values := make([]*big.Int, len(ls))
+	for i, l := range ls {
+		values[i], _ = new(big.Int).SetString(l.Value, 10)
+	}
+	mod := func(v *big.Int) *big.Int { return v.Mod(v, field) }
+	var res []Mutation
+	for i, l := range ls {
+		if l.Public {
+			v := mod(new(big.Int).Xor(values[i], big.NewInt(0xff)))
+			res = append(res, Mutation{
+				Kind: KindFlip, Description: "flip " + l.Name,
+				changes: map[int]*big.Int{i: v}, publicOnly: true,
+			})
+		}
+		for _, d := range []int64{1, -1} {
+			v := mod(new(big.Int).Add(values[i], big.NewInt(d)))
+			res = append(res, Mutation{
+				Kind: KindOffByOne, Description: fmt.Sprintf("%s%+d", l.Name, d),
+				changes: map[int]*big.Int{i: v}, publicOnly: l.Public,
+			})
+		}
+		if l.byte {
+			v := mod(new(big.Int).Add(values[i], big.NewInt(256)))
+			res = append(res, Mutation{
+				Kind: KindOutOfRange, Description: l.Name + "+256",
+				changes: map[int]*big.Int{i: v}, publicOnly: l.Public,
+			})
+		}
+	}
+	for i := 0; i+1 < len(ls); i++ {
+		if values[i].Cmp(values[i+1]) == 0 {
+			continue
+		}
+		res = append(res, Mutation{
+			Kind: KindSwap, Description: fmt.Sprintf("swap %s %s", ls[i].Name, ls[i+1].Name),
+			changes:    map[int]*big.Int{i: values[i+1], i + 1: values[i]},
+			publicOnly: ls[i].Public && ls[i+1].Public,
+		})
+	}
+	return res
+}
+
+// apply 将变异写入赋值中的叶子字段，返回恢复原值的函数
+func apply(ls []leaf, m Mutation) (restore func()) {
+	old := make(map[int]reflect.Value, len(m.changes))
+	for i, v := range m.changes {
+		old[i] = reflect.ValueOf(ls[i].value.Interface())
+		ls[i].value.Set(reflect.ValueOf(new(big.Int).Set(v)))
+	}
+	return func() {
+		for i, v := range old {
+			ls[i].value.Set(v)
+		}
+	}
+}
+
+// Run 在指定的证明系统和曲线上编译电路并完成设置，先用合法赋值生成证明并验证，
+// 再依次检查每个变异赋值：先在测试引擎中求解，求解失败的变异被拒绝，
+// 只有求解成功的变异才生成证明并验证，验证失败的变异被拒绝，否则记录在 Report.Escaped 中。
+// 只改变公开输入的变异还要求合法证明在变异后的公开输入下验证失败。
+// 变异直接写入 cw，Run 返回前恢复合法赋值
+func Run[C, A any](scheme string, cw wrapper.CircuitWrapper[C, A], curveName string, compileParams C, assignParams A) (*Report, error) {
+	ps, err := wrapper.New(scheme, curveName, cw)
+	if err != nil {
+		return nil, err
+	}
+	if err := cw.PreCompile(compileParams); err != nil {
+		return nil, err
+	}
+	if err := ps.Compile(); err != nil {
+		return nil, err
+	}
+	if err := ps.Setup(); err != nil {
+		return nil, err
+	}
+	if err := cw.Assign(assignParams); err != nil {
+		return nil, err
+	}
+	ps.SetAssignment(cw)
+	if err := ps.Prove(); err != nil {
+		return nil, fmt.Errorf("prove with the valid assignment: %w", err)
+	}
+	if err := ps.Verify(); err != nil {
+		return nil, fmt.Errorf("verify with the valid assignment: %w", err)
+	}
+	// Marshal* 返回 base64 文本，Unmarshal* 读取原始字节
+	encoded, err := ps.MarshalProof()
+	if err != nil {
+		return nil, err
+	}
+	proof, err := base64.StdEncoding.DecodeString(string(encoded))
+	if err != nil {
+		return nil, err
+	}
+
+	field := ps.CurveID().ScalarField()
+	ls, err := leaves(cw, field)
+	if err != nil {
+		return nil, err
+	}
+	mutations := mutate(ls, field)
+	report := &Report{Scheme: scheme, Curve: curveName, Mutations: len(mutations)}
+	for _, m := range mutations {
+		restore := apply(ls, m)
+		unsolved, escape, err := check(ps, cw, m, proof, field)
+		restore()
+		if err != nil {
+			return nil, fmt.Errorf("mutation %s: %w", m.Description, err)
+		}
+		switch {
+		case escape != "":
+			report.Escaped = append(report.Escaped, Escape{Mutation: m, Reason: escape})
+		case unsolved:
+			report.Unsolved++
+		default:
+			report.Rejected++
+		}
+	}
+	ps.SetAssignment(cw)
+	logger.Info("%s on %s: %d mutations, %d unsolved, %d rejected, %d escaped",
+		scheme, curveName, report.Mutations, report.Unsolved, report.Rejected, len(report.Escaped))
+	return report, nil
+}
+
+// check 先在测试引擎中求解变异后的赋值，求解成功时再生成证明并验证，
+// 返回变异是否求解失败；变异未被拒绝时返回原因
+func check(ps wrapper.ProofSystem, cw frontend.Circuit, m Mutation, proof []byte, field *big.Int) (bool, string, error) {
+	ps.SetAssignment(cw)
+	if m.publicOnly {
+		// 合法证明不应在变异后的公开输入下通过验证
+		if err := ps.UnmarshalProof(proof); err != nil {
+			return false, "", err
+		}
+		err := ps.Verify()
+		if err == nil {
+			return false, "the valid proof verifies against the mutated public inputs", nil
+		}
+		if !errors.Is(err, utils.ErrInvalidProof) {
+			return false, "", err
+		}
+	}
+	// 测试引擎求解比生成证明快得多，大部分变异在这里就被拒绝
+	err := diagnose.Check(cw, cw, field)
+	if errors.Is(err, utils.ErrUnsatisfiedConstraint) {
+		return true, "", nil
+	}
+	if err != nil {
+		return false, "", err
+	}
+	err = ps.Prove()
+	if errors.Is(err, utils.ErrUnsatisfiedConstraint) {
+		return true, "", nil
+	}
+	if err != nil {
+		return false, "", err
+	}
+	err = ps.Verify()
+	if errors.Is(err, utils.ErrInvalidProof) {
+		return false, "", nil
+	}
+	if err != nil {
+		return false, "", err
+	}
+	return false, "the mutated assignment is solved and its proof verifies", nil
+}
@@ -0,0 +1,159 @@
+package mutation
+
+import (
+	"encoding/binary"
+	"math/big"
+	"testing"
+
+	"github.com/oliverustc/gnarkabc/circuits"
+	"github.com/oliverustc/gnarkabc/hash/mimchash"
+	"github.com/oliverustc/gnarkabc/utils"
+	"github.com/oliverustc/gnarkabc/wrapper"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/math/uints"
+)
+
+// bytesCircuit 断言 Bytes 是 Value 的小端字节分解，字节由 uints 做范围检查
+type bytesCircuit struct {
+	Bytes [4]uints.U8
+	Value frontend.Variable `gnark:",public"`
+}
+
+func (c *bytesCircuit) Define(api frontend.API) error {
+	uapi, err := uints.New[uints.U32](api)
+	if err != nil {
+		return err
+	}
+	uapi.AssertEq(uapi.ValueOf(c.Value), uapi.PackLSB(c.Bytes[:]...))
+	return nil
+}
+
+func (c *bytesCircuit) PreCompile(params circuits.NoParams) error {
+	return nil
+}
+
+func (c *bytesCircuit) Assign(value uint32) error {
+	var bts [4]byte
+	binary.LittleEndian.PutUint32(bts[:], value)
+	copy(c.Bytes[:], uints.NewU8Array(bts[:]))
+	c.Value = value
+	return nil
+}
+
+// sumCircuit 只约束两个字节之和，交换两个字节的变异不会被拒绝
+type sumCircuit struct {
+	Bytes [2]uints.U8
+	Sum   frontend.Variable `gnark:",public"`
+}
+
+func (c *sumCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(api.Add(c.Bytes[0].Val, c.Bytes[1].Val), c.Sum)
+	return nil
+}
+
+func (c *sumCircuit) PreCompile(params circuits.NoParams) error {
+	return nil
+}
+
+func (c *sumCircuit) Assign(bts [2]byte) error {
+	copy(c.Bytes[:], uints.NewU8Array(bts[:]))
+	c.Sum = int(bts[0]) + int(bts[1])
+	return nil
+}
+
+func TestMutate(t *testing.T) {
+	field := ecc.BN254.ScalarField()
+	assignment := &circuits.Product{P: 3, Q: 4, N: 12}
+	mutations, err := Mutate(assignment, field)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := make(map[string]int)
+	for _, m := range mutations {
+		kinds[m.Kind]++
+	}
+	// 翻转公开的 N，三个字段各加减一，交换 P、Q 和 Q、N
+	if kinds[KindFlip] != 1 || kinds[KindOffByOne] != 6 || kinds[KindSwap] != 2 || kinds[KindOutOfRange] != 0 {
+		t.Fatalf("unexpected mutations %v", kinds)
+	}
+	if assignment.P != 3 || assignment.Q != 4 || assignment.N != 12 {
+		t.Fatalf("assignment modified: %+v", assignment)
+	}
+
+	var bc bytesCircuit
+	bc.Assign(0x01020304)
+	mutations, err = Mutate(&bc, field)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byteMutations := 0
+	for _, m := range mutations {
+		if m.Kind == KindOutOfRange {
+			byteMutations++
+		}
+		// 0 减一按标量域取模，不会得到负数
+		for _, v := range m.changes {
+			if v.Sign() < 0 || v.Cmp(field) >= 0 {
+				t.Fatalf("%s: value %s out of the field", m.Description, v)
+			}
+		}
+	}
+	if byteMutations != 4 {
+		t.Fatalf("expected 4 out-of-range mutations, got %d", byteMutations)
+	}
+}
+
+// 每条曲线和每种证明系统上，所有变异都应被拒绝；-short 时只检查 BN254
+func TestRun(t *testing.T) {
+	curveNames := utils.CurveNameList
+	if testing.Short() {
+		curveNames = []string{"BN254"}
+	}
+	for _, curveName := range curveNames {
+		preImage := big.NewInt(int64(utils.RandInt(1, 100000))).Bytes()
+		hash := mimchash.MiMCHash(mimchash.MiMCCaseMap[curveName].Hash, [][]byte{preImage})
+		for _, scheme := range wrapper.SchemeList {
+			var reports []*Report
+			// 交换两个不同的因子得到另一个合法赋值，因子相同时不生成交换
+			report, err := Run(scheme, &circuits.Product{}, curveName, circuits.NoParams{}, circuits.ProductAssign{P: 3, Q: 3})
+			if err != nil {
+				t.Fatalf("product on %s with %s: %v", curveName, scheme, err)
+			}
+			reports = append(reports, report)
+			report, err = Run(scheme, &circuits.MimcHash{}, curveName, circuits.NoParams{}, circuits.HashAssign{PreImage: preImage, Hash: hash})
+			if err != nil {
+				t.Fatalf("mimc on %s with %s: %v", curveName, scheme, err)
+			}
+			reports = append(reports, report)
+			report, err = Run(scheme, &bytesCircuit{}, curveName, circuits.NoParams{}, uint32(0x00ff1234))
+			if err != nil {
+				t.Fatalf("bytes on %s with %s: %v", curveName, scheme, err)
+			}
+			reports = append(reports, report)
+			for _, r := range reports {
+				if err := r.Err(); err != nil {
+					t.Fatal(err)
+				}
+				if r.Unsolved+r.Rejected != r.Mutations {
+					t.Fatalf("%s on %s: %d of %d mutations counted", r.Scheme, r.Curve, r.Unsolved+r.Rejected, r.Mutations)
+				}
+			}
+		}
+	}
+}
+
+// 约束不足的电路中，未被拒绝的变异记录在报告中
+func TestRunEscaped(t *testing.T) {
+	for _, scheme := range wrapper.SchemeList {
+		report, err := Run(scheme, &sumCircuit{}, "BN254", circuits.NoParams{}, [2]byte{1, 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(report.Escaped) != 1 || report.Escaped[0].Mutation.Kind != KindSwap || report.Err() == nil {
+			t.Fatalf("%s: expected the swap of the two bytes to escape, got %+v", scheme, report.Escaped)
+		}
+		t.Log(report.Err())
+	}
+}
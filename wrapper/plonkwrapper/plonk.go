@@ -1,11 +1,15 @@
 package plonkwrapper
 
 import (
-	"errors"
+	"crypto/sha256"
+	"fmt"
 	"math/big"
 	"time"
 
 	"github.com/oliverustc/gnarkabc/logger"
+	"github.com/oliverustc/gnarkabc/utils"
+	"github.com/oliverustc/gnarkabc/wrapper/diagnose"
+	"github.com/oliverustc/gnarkabc/wrapper/store"
 
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark-crypto/kzg"
@@ -22,6 +26,7 @@ import (
 	bw6_761cs "github.com/consensys/gnark/constraint/bw6-761"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/frontend/cs/scs"
+	recursion_plonk "github.com/consensys/gnark/std/recursion/plonk"
 	"github.com/consensys/gnark/test/unsafekzg"
 )
 
@@ -35,14 +40,20 @@ type PlonkWrapper struct {
 	WitnessPublic witness.Witness             // 公开见证者
 	CCS           constraint.ConstraintSystem // 约束系统
 
-	CompileTime   time.Duration      // 编译时间
-	SetupTime     time.Duration      // 设置时间
-	ProveTime     time.Duration      // 证明时间
-	VerifyTime    time.Duration      // 验证时间
-	ConstraintNum int                // 约束数量
-	PK            plonk.ProvingKey   // 证明密钥/
-	VK            plonk.VerifyingKey // 验证密钥
-	Proof         plonk.Proof        // 生成的证明
+	CompileTime   time.Duration       // 编译时间
+	SetupTime     time.Duration       // 设置时间
+	ProveTime     time.Duration       // 证明时间
+	VerifyTime    time.Duration       // 验证时间
+	ConstraintNum int                 // 约束数量
+	PK            plonk.ProvingKey    // 证明密钥/
+	VK            plonk.VerifyingKey  // 验证密钥
+	Proof         plonk.Proof         // 生成的证明
+	SRS           kzg.SRS             // 规范形式的 SRS，为空时使用 unsafekzg 生成仅供测试的 SRS
+	Store         store.ArtifactStore // 参数、证明和见证者的存储位置，默认为 output/ 目录
+
+	// RecursionTarget 是在其电路中递归验证本证明的外层曲线，设置后 Prove 和 Verify 自动使用
+	// recursion_plonk 的原生选项，使挑战的哈希与外层电路一致；为 ecc.UNKNOWN 时不使用
+	RecursionTarget ecc.ID
 }
 
 // NewWrapper 创建新的PLONK包装器实例
@@ -51,17 +62,18 @@ func NewWrapper(circuit frontend.Circuit, curve ecc.ID) *PlonkWrapper {
 		Circuit: circuit,
 		Curve:   curve,
 		Field:   curve.ScalarField(),
+		Store:   store.NewFS(store.DefaultDir),
 	}
 }
 
 // Compile 编译电路
-func (p *PlonkWrapper) Compile() {
+func (p *PlonkWrapper) Compile() error {
 	logger.Debug("compiling circuit ...")
 	var err error
 	start := time.Now()
 	p.CCS, err = frontend.Compile(p.Field, scs.NewBuilder, p.Circuit)
 	if err != nil {
-		logger.Fatal("compile circuit failed. %s", err.Error())
+		return fmt.Errorf("compile circuit failed: %w", err)
 	}
 	p.CompileTime = time.Since(start)
 	logger.Debug("circuit compiled, took: %s", p.CompileTime.String())
@@ -69,31 +81,53 @@ func (p *PlonkWrapper) Compile() {
 		p.ConstraintNum = p.CCS.GetNbConstraints()
 		logger.Debug("constraint number: %d", p.ConstraintNum)
 	}
+	return nil
 }
 
 // Setup 设置电路的证明系统
-func (p *PlonkWrapper) Setup() {
+func (p *PlonkWrapper) Setup() error {
 	logger.Debug("setting up circuit ...")
+	if p.CCS == nil {
+		return fmt.Errorf("%w: constraint system is nil, compile first", utils.ErrMissingSetup)
+	}
 	var srs, srsLagrange kzg.SRS
 	var err error
 	start := time.Now()
 
 	// 提取 SRS 创建逻辑，避免代码重复
-	srs, srsLagrange, err = p.createSRS(p.CCS)
+	if p.SRS != nil {
+		srs, srsLagrange, err = deriveSRS(p.SRS, p.Curve, p.CCS)
+	} else {
+		srs, srsLagrange, err = p.createSRS(p.CCS)
+	}
 	if err != nil {
-		logger.Fatal("create SRS failed. %s", err.Error())
+		return fmt.Errorf("create SRS failed: %w", err)
 	}
 
 	p.PK, p.VK, err = plonk.Setup(p.CCS, srs, srsLagrange)
 	if err != nil {
-		logger.Fatal("setup circuit failed. %s", err.Error())
+		return fmt.Errorf("setup circuit failed: %w", err)
 	}
 
 	p.SetupTime = time.Since(start)
 	logger.Debug("circuit setup, took: %s", p.SetupTime.String())
+	return nil
+}
+
+// SRSDigest 返回已加载 SRS 的 sha256 摘要，SRS 为空（Setup 使用 unsafekzg）时返回 nil，
+// 参数缓存以此区分由不同 SRS 生成的 PK/VK
+func (p *PlonkWrapper) SRSDigest() ([]byte, error) {
+	if p.SRS == nil {
+		return nil, nil
+	}
+	h := sha256.New()
+	if _, err := p.SRS.WriteRawTo(h); err != nil {
+		return nil, fmt.Errorf("digest SRS failed: %w", err)
+	}
+	return h.Sum(nil), nil
 }
 
-// createSRS 创建结构化参考字符串(SRS)
+// createSRS 使用 unsafekzg 创建结构化参考字符串(SRS)，其有毒废料未被销毁，仅适用于测试
 func (p *PlonkWrapper) createSRS(scs constraint.ConstraintSystem) (kzg.SRS, kzg.SRS, error) {
 	switch p.Curve {
 	case ecc.BN254:
@@ -111,154 +145,239 @@ func (p *PlonkWrapper) createSRS(scs constraint.ConstraintSystem) (kzg.SRS, kzg.
 	case ecc.BLS24_317:
 		return unsafekzg.NewSRS(scs.(*bls24_317cs.SparseR1CS))
 	}
-	return nil, nil, errors.New("invalid curve ID")
+	return nil, nil, fmt.Errorf("%w: %s", utils.ErrUnsupportedCurve, p.Curve.String())
+}
+
+// CheckAssignment 在测试引擎中检查当前赋值是否满足电路，不需要编译和设置。
+// 不满足时返回 *diagnose.Failure，包含失败的断言、源码位置和涉及的电路字段
+func (p *PlonkWrapper) CheckAssignment() error {
+	if p.Assignment == nil {
+		return utils.ErrMissingAssignment
+	}
+	return diagnose.Check(p.Circuit, p.Assignment, p.Field)
 }
 
-// SetAssignment 设置电路的赋值
+// SetAssignment 设置电路的赋值，并清空由旧赋值生成的见证者
 func (p *PlonkWrapper) SetAssignment(assignment frontend.Circuit) {
 	p.Assignment = assignment
+	p.WitnessFull = nil
+	p.WitnessPublic = nil
 }
 
-func (p *PlonkWrapper) GenerateWitness(public bool) {
+// GenerateWitness 生成见证者数据
+// public: 是否只生成公开输入的见证者
+func (p *PlonkWrapper) GenerateWitness(public bool) error {
 	var err error
 	if public {
 		if p.WitnessFull != nil {
 			p.WitnessPublic, err = p.WitnessFull.Public()
 			if err != nil {
-				logger.Fatal("generate public witness from witnessfull failed. %s", err.Error())
-			}
-		} else {
-			if p.Assignment == nil {
-				logger.Fatal("assignment is nil")
-			}
-			p.WitnessPublic, err = frontend.NewWitness(p.Assignment, p.Field, frontend.PublicOnly())
-			if err != nil {
-				logger.Fatal("generate public witness from assignment failed. %s", err.Error())
+				return fmt.Errorf("generate public witness from witnessfull failed: %w", err)
 			}
+			return nil
 		}
-	} else {
 		if p.Assignment == nil {
-			logger.Fatal("assignment is nil")
+			return utils.ErrMissingAssignment
 		}
-		p.WitnessFull, err = frontend.NewWitness(p.Assignment, p.Field)
+		p.WitnessPublic, err = frontend.NewWitness(p.Assignment, p.Field, frontend.PublicOnly())
 		if err != nil {
-			logger.Fatal("generate full witness failed. %s", err.Error())
+			return fmt.Errorf("generate public witness from assignment failed: %w", err)
 		}
+		return nil
 	}
-
+	if p.Assignment == nil {
+		return utils.ErrMissingAssignment
+	}
+	p.WitnessFull, err = frontend.NewWitness(p.Assignment, p.Field)
+	if err != nil {
+		return fmt.Errorf("generate full witness failed: %w", err)
+	}
+	return nil
 }
 
-// Prove 生成零知识证明，支持可选的证明者选项
-func (p *PlonkWrapper) Prove(opts ...backend.ProverOption) {
+// Prove 生成零知识证明，支持可选的证明者选项。
+// 设置了 RecursionTarget 时先加入递归验证所需的选项，opts 中的同类选项可以覆盖
+func (p *PlonkWrapper) Prove(opts ...backend.ProverOption) error {
 	logger.Debug("proving circuit ...")
+	if p.CCS == nil || p.PK == nil {
+		return fmt.Errorf("%w: constraint system or proving key is nil", utils.ErrMissingSetup)
+	}
 	var err error
 	if p.WitnessFull == nil {
-		p.GenerateWitness(false)
+		if err = p.GenerateWitness(false); err != nil {
+			return err
+		}
 	}
 	start := time.Now()
-	p.Proof, err = plonk.Prove(p.CCS, p.PK, p.WitnessFull, opts...)
+	p.Proof, err = plonk.Prove(p.CCS, p.PK, p.WitnessFull, p.proverOptions(opts)...)
+	if utils.IsUnsatisfiedConstraint(err) {
+		return fmt.Errorf("%w: %w", utils.ErrUnsatisfiedConstraint, err)
+	}
 	if err != nil {
-		logger.Fatal("prove circuit failed. %s", err.Error())
+		return fmt.Errorf("prove failed: %w", err)
 	}
 	p.ProveTime = time.Since(start)
 	logger.Debug("circuit proved, took: %s", p.ProveTime.String())
+	return nil
 }
 
-// Verify 验证零知识证明
-func (p *PlonkWrapper) Verify(opts ...backend.VerifierOption) {
+// Verify 验证零知识证明，RecursionTarget 的处理与 Prove 相同
+func (p *PlonkWrapper) Verify(opts ...backend.VerifierOption) error {
 	logger.Debug("verifying circuit ...")
+	if p.VK == nil {
+		return fmt.Errorf("%w: verifying key is nil", utils.ErrMissingSetup)
+	}
+	if p.Proof == nil {
+		return fmt.Errorf("%w: proof is nil", utils.ErrInvalidProof)
+	}
 	var err error
 	if p.WitnessPublic == nil {
-		p.WitnessPublic, err = frontend.NewWitness(p.Assignment, p.Field, frontend.PublicOnly())
-		if err != nil {
-			logger.Fatal("generate public witness failed. %s", err.Error())
+		if err = p.GenerateWitness(true); err != nil {
+			return err
 		}
 	}
 	start := time.Now()
-	err = plonk.Verify(p.Proof, p.VK, p.WitnessPublic, opts...)
+	err = plonk.Verify(p.Proof, p.VK, p.WitnessPublic, p.verifierOptions(opts)...)
 	if err != nil {
-		logger.Fatal("verify circuit failed. %s", err.Error())
+		return fmt.Errorf("%w: %w", utils.ErrInvalidProof, err)
 	}
 	p.VerifyTime = time.Since(start)
 	logger.Debug("circuit verified, took: %s", p.VerifyTime.String())
+	return nil
+}
+
+// SetRecursionTarget 设置递归验证本证明的外层曲线，ecc.UNKNOWN 表示证明不用于递归
+func (p *PlonkWrapper) SetRecursionTarget(outer ecc.ID) {
+	p.RecursionTarget = outer
+}
+
+// proverOptions 在 opts 之前加入 RecursionTarget 对应的证明者选项
+func (p *PlonkWrapper) proverOptions(opts []backend.ProverOption) []backend.ProverOption {
+	if p.RecursionTarget == ecc.UNKNOWN {
+		return opts
+	}
+	return append([]backend.ProverOption{recursion_plonk.GetNativeProverOptions(p.RecursionTarget.ScalarField(), p.Curve.ScalarField())}, opts...)
+}
+
+// verifierOptions 在 opts 之前加入 RecursionTarget 对应的验证者选项
+func (p *PlonkWrapper) verifierOptions(opts []backend.VerifierOption) []backend.VerifierOption {
+	if p.RecursionTarget == ecc.UNKNOWN {
+		return opts
+	}
+	return append([]backend.VerifierOption{recursion_plonk.GetNativeVerifierOptions(p.RecursionTarget.ScalarField(), p.Curve.ScalarField())}, opts...)
 }
 
 // BenchmarkCompile 对编译过程进行基准测试
-func (p *PlonkWrapper) BenchmarkCompile(iterations int) time.Duration {
+func (p *PlonkWrapper) BenchmarkCompile(iterations int) (time.Duration, error) {
 	logger.Debug("benchmarking compile circuit ...")
 	var compileTime time.Duration
 	for i := 0; i < iterations; i++ {
-		p.Compile()
+		if err := p.Compile(); err != nil {
+			return 0, err
+		}
 		compileTime += p.CompileTime
 	}
 	p.CompileTime = compileTime / time.Duration(iterations)
 	logger.Debug("after %d iterations, compile time: %s", iterations, p.CompileTime.String())
-	return p.CompileTime
+	return p.CompileTime, nil
 }
 
 // BenchmarkSetup 对设置过程进行基准测试
-func (p *PlonkWrapper) BenchmarkSetup(iterations int) time.Duration {
+func (p *PlonkWrapper) BenchmarkSetup(iterations int) (time.Duration, error) {
 	logger.Debug("benchmarking setup ")
 	var setupTime time.Duration
 	for i := 0; i < iterations; i++ {
-		p.Setup()
+		if err := p.Setup(); err != nil {
+			return 0, err
+		}
 		setupTime += p.SetupTime
 	}
 	p.SetupTime = setupTime / time.Duration(iterations)
 	logger.Debug("after %d iterations, setup time: %s", iterations, p.SetupTime.String())
-	return p.SetupTime
+	return p.SetupTime, nil
 }
 
 // BenchmarkProve 对证明生成过程进行基准测试
-func (p *PlonkWrapper) BenchmarkProve(iterations int) time.Duration {
+func (p *PlonkWrapper) BenchmarkProve(iterations int) (time.Duration, error) {
 	logger.Debug("benchmarking proving circuit ...")
 	var proveTime time.Duration
 	for i := 0; i < iterations; i++ {
-		p.Prove()
+		if err := p.Prove(); err != nil {
+			return 0, err
+		}
 		proveTime += p.ProveTime
 	}
 	p.ProveTime = proveTime / time.Duration(iterations)
 	logger.Debug("after %d iterations, prove time: %s", iterations, p.ProveTime.String())
-	return p.ProveTime
+	return p.ProveTime, nil
 }
 
 // BenchmarkVerify 对验证过程进行基准测试
-func (p *PlonkWrapper) BenchmarkVerify(iterations int) time.Duration {
+func (p *PlonkWrapper) BenchmarkVerify(iterations int) (time.Duration, error) {
 	logger.Debug("benchmarking verifying circuit ...")
 	var verifyTime time.Duration
 	for i := 0; i < iterations; i++ {
-		p.Verify()
+		if err := p.Verify(); err != nil {
+			return 0, err
+		}
 		verifyTime += p.VerifyTime
 	}
 	p.VerifyTime = verifyTime / time.Duration(iterations)
 	logger.Debug("after %d iterations, verify time: %s", iterations, p.VerifyTime.String())
-	return p.VerifyTime
+	return p.VerifyTime, nil
+}
+
+// Scheme 返回证明系统名称
+func (p *PlonkWrapper) Scheme() string {
+	return "plonk"
+}
+
+// CurveID 返回包装器使用的曲线
+func (p *PlonkWrapper) CurveID() ecc.ID {
+	return p.Curve
+}
+
+// SetStore 设置 Write*/Read* 和 Solidity 导出使用的存储
+func (p *PlonkWrapper) SetStore(s store.ArtifactStore) {
+	p.Store = s
+}
+
+// GetStore 返回当前存储，未设置时使用默认的 output/ 目录
+func (p *PlonkWrapper) GetStore() store.ArtifactStore {
+	if p.Store == nil {
+		p.Store = store.NewFS(store.DefaultDir)
+	}
+	return p.Store
 }
 
 func (p *PlonkWrapper) GetConstraintNum() int {
 	return p.CCS.GetNbConstraints()
 }
 
-func (p *PlonkWrapper) GetWitnessJson(public bool) []byte {
+func (p *PlonkWrapper) GetWitnessJson(public bool) ([]byte, error) {
+	if p.WitnessFull == nil {
+		if err := p.GenerateWitness(false); err != nil {
+			return nil, err
+		}
+	}
 	schama, err := frontend.NewSchema(p.Field, p.Assignment)
 	if err != nil {
-		logger.Fatal("get schema failed: %v", err)
+		return nil, fmt.Errorf("get schema failed: %w", err)
 	}
 	if public {
 		witness, err := p.WitnessFull.Public()
 		if err != nil {
-			logger.Fatal("get public witness failed: %v", err)
+			return nil, fmt.Errorf("get public witness failed: %w", err)
 		}
 		witnessJson, err := witness.ToJSON(schama)
 		if err != nil {
-			logger.Fatal("get public witness json failed: %v", err)
+			return nil, fmt.Errorf("get public witness json failed: %w", err)
 		}
-		return witnessJson
-	} else {
-		witnessJson, err := p.WitnessFull.ToJSON(schama)
-		if err != nil {
-			logger.Fatal("get witness json failed: %v", err)
-		}
-		return witnessJson
+		return witnessJson, nil
+	}
+	witnessJson, err := p.WitnessFull.ToJSON(schama)
+	if err != nil {
+		return nil, fmt.Errorf("get witness json failed: %w", err)
 	}
+	return witnessJson, nil
 }
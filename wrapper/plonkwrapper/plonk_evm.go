@@ -0,0 +1,77 @@
+package plonkwrapper
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/oliverustc/gnarkabc/logger"
+	"github.com/oliverustc/gnarkabc/utils"
+	"github.com/oliverustc/gnarkabc/wrapper/evm"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	fr_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// VerifyOnEVM 将 Solidity 验证合约部署到进程内的模拟链上并调用 Verify，返回验证结果和消耗的 gas。
+// bytecode 为预编译的 PlonkVerifier 合约部署字节码，为空时使用本地的 solc 编译 ExportSolidity 的输出。
+// 合约拒绝证明时不返回错误，结果体现在 evm.Result.Success 中
+func (p *PlonkWrapper) VerifyOnEVM(bytecode []byte) (evm.Result, error) {
+	if p.Curve != ecc.BN254 {
+		return evm.Result{}, fmt.Errorf("%w: only BN254 curve is supported", utils.ErrUnsupportedCurve)
+	}
+	if p.VK == nil {
+		return evm.Result{}, fmt.Errorf("%w: verifying key is nil", utils.ErrMissingSetup)
+	}
+	calldata, err := p.SolidityCalldata()
+	if err != nil {
+		return evm.Result{}, err
+	}
+	if len(bytecode) == 0 {
+		var source bytes.Buffer
+		if err := p.VK.ExportSolidity(&source); err != nil {
+			return evm.Result{}, fmt.Errorf("export solidity failed: %w", err)
+		}
+		if bytecode, err = evm.Compile(source.Bytes(), "PlonkVerifier"); err != nil {
+			return evm.Result{}, err
+		}
+	}
+	res, err := evm.Run(bytecode, calldata)
+	if err != nil {
+		return evm.Result{}, err
+	}
+	logger.Info("evm verification success: %v, gas used: %d", res.Success, res.GasUsed)
+	return res, nil
+}
+
+// SolidityCalldata 按 Verify(bytes,uint256[]) 的 ABI 编码调用数据
+func (p *PlonkWrapper) SolidityCalldata() ([]byte, error) {
+	proofBytes, err := p.solidityProof()
+	if err != nil {
+		return nil, err
+	}
+	bPublicWitness, err := p.solidityPublicWitness()
+	if err != nil {
+		return nil, err
+	}
+	input := make([]*big.Int, len(bPublicWitness)/fr_bn254.Bytes)
+	for i := range input {
+		input[i] = new(big.Int).SetBytes(bPublicWitness[fr_bn254.Bytes*i : fr_bn254.Bytes*(i+1)])
+	}
+	bytesType, err := abi.NewType("bytes", "", nil)
+	if err != nil {
+		return nil, err
+	}
+	inputType, err := abi.NewType("uint256[]", "", nil)
+	if err != nil {
+		return nil, err
+	}
+	args, err := abi.Arguments{{Type: bytesType}, {Type: inputType}}.Pack(proofBytes, input)
+	if err != nil {
+		return nil, fmt.Errorf("pack calldata failed: %w", err)
+	}
+	selector := crypto.Keccak256([]byte("Verify(bytes,uint256[])"))[:4]
+	return append(selector, args...), nil
+}
@@ -0,0 +1,55 @@
+package plonkwrapper
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+
+	"github.com/oliverustc/gnarkabc/circuits"
+	"github.com/oliverustc/gnarkabc/utils"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestPlonkVerifyOnEVM(t *testing.T) {
+	var circuit circuits.Product
+	circuit.PreCompile(circuits.NoParams{})
+	p := NewWrapper(&circuit, ecc.BN254)
+	if err := p.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	productSRSZKP(t, p, &circuit)
+
+	calldata, err := p.SolidityCalldata()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if selector := crypto.Keccak256([]byte("Verify(bytes,uint256[])"))[:4]; string(calldata[:4]) != string(selector) {
+		t.Fatalf("unexpected selector %x", calldata[:4])
+	}
+
+	if _, err := exec.LookPath("solc"); err != nil {
+		// PLONK 验证合约无法手工汇编，没有 solc 时不检查链上验证
+		t.Skip("solc not found, skip verification with the exported verifier")
+	}
+	res, err := p.VerifyOnEVM(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Success {
+		t.Fatal("verifier rejected a valid proof")
+	}
+	t.Logf("gas used: %d", res.GasUsed)
+}
+
+func TestPlonkVerifyOnEVMErrors(t *testing.T) {
+	p := NewWrapper(nil, ecc.BLS12_381)
+	if _, err := p.VerifyOnEVM(nil); !errors.Is(err, utils.ErrUnsupportedCurve) {
+		t.Fatalf("expected ErrUnsupportedCurve, got %v", err)
+	}
+	p = NewWrapper(nil, ecc.BN254)
+	if _, err := p.VerifyOnEVM(nil); !errors.Is(err, utils.ErrMissingSetup) {
+		t.Fatalf("expected ErrMissingSetup, got %v", err)
+	}
+}
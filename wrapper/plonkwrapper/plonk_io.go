@@ -1,7 +1,7 @@
 package plonkwrapper
 
 import (
-	"os"
+	"fmt"
 
 	"github.com/oliverustc/gnarkabc/logger"
 	"github.com/oliverustc/gnarkabc/utils"
@@ -10,78 +10,89 @@ import (
 	"github.com/consensys/gnark/backend/witness"
 )
 
-func (p *PlonkWrapper) WriteCCS(filePath string) {
+func (p *PlonkWrapper) WriteCCS(filePath string) error {
 	if filePath == "" {
-		logger.Debug("CCS filePath is empty, using default output/ccs")
-		filePath = "output/ccs"
+		logger.Debug("CCS filePath is empty, using default ccs")
+		filePath = "ccs"
+	}
+	if p.CCS == nil {
+		return fmt.Errorf("%w: constraint system is nil", utils.ErrMissingSetup)
 	}
 	logger.Debug("Writing ccs to %s", filePath)
-	utils.EnsureDirExists("output")
-	file, err := os.Create(filePath)
+	file, err := p.GetStore().Create(filePath)
 	if err != nil {
-		logger.Error("failed to create file: %s", err.Error())
+		return &utils.IOError{Op: "create ccs file", Path: filePath, Err: err}
 	}
-	defer file.Close()
 	size, err := p.CCS.WriteTo(file)
 	if err != nil {
-		logger.Error("failed to write ccs to file: %s", err.Error())
+		file.Close()
+		return &utils.IOError{Op: "write ccs", Path: filePath, Err: err}
+	}
+	if err := file.Close(); err != nil {
+		return &utils.IOError{Op: "close ccs file", Path: filePath, Err: err}
 	}
 	logger.Debug("write ccs to %s, size= %d", filePath, size)
+	return nil
 }
 
-func (p *PlonkWrapper) ReadCCS(filePath string) {
+func (p *PlonkWrapper) ReadCCS(filePath string) error {
 	if filePath == "" {
-		logger.Debug("CCS filePath is empty, using default output/ccs")
-		filePath = "output/ccs"
+		logger.Debug("CCS filePath is empty, using default ccs")
+		filePath = "ccs"
 	}
 	logger.Debug("Reading ccs from %s", filePath)
-	utils.EnsureDirExists("output")
-	file, err := os.Open(filePath)
+	file, err := p.GetStore().Open(filePath)
 	if err != nil {
-		logger.Error("failed to open file: %s", err.Error())
-		return
+		return &utils.IOError{Op: "open ccs file", Path: filePath, Err: err}
 	}
 	defer file.Close()
+
+	// 初始化 CCS
 	if p.CCS == nil {
 		p.CCS = plonk.NewCS(p.Curve)
 	}
 	size, err := p.CCS.ReadFrom(file)
 	if err != nil {
-		logger.Error("failed to read ccs from file: %s", err.Error())
+		return &utils.IOError{Op: "read ccs", Path: filePath, Err: err}
 	}
 	logger.Debug("read ccs from %s, size= %d", filePath, size)
+	return nil
 }
 
-func (p *PlonkWrapper) WritePK(filePath string) {
+func (p *PlonkWrapper) WritePK(filePath string) error {
 	if filePath == "" {
-		logger.Debug("Proving Key filePath is empty, using default output/pk")
-		filePath = "output/pk"
+		logger.Debug("Proving Key filePath is empty, using default pk")
+		filePath = "pk"
+	}
+	if p.PK == nil {
+		return fmt.Errorf("%w: proving key is nil", utils.ErrMissingSetup)
 	}
 	logger.Debug("Writing proving key to %s", filePath)
-	utils.EnsureDirExists("output")
-	file, err := os.Create(filePath)
+	file, err := p.GetStore().Create(filePath)
 	if err != nil {
-		logger.Error("failed to create file: %s", err.Error())
+		return &utils.IOError{Op: "create pk file", Path: filePath, Err: err}
 	}
-	defer file.Close()
 	size, err := p.PK.WriteTo(file)
 	if err != nil {
-		logger.Error("failed to write pk to file: %s", err.Error())
+		file.Close()
+		return &utils.IOError{Op: "write pk", Path: filePath, Err: err}
+	}
+	if err := file.Close(); err != nil {
+		return &utils.IOError{Op: "close pk file", Path: filePath, Err: err}
 	}
 	logger.Debug("wrote proving key to %s, size= %d", filePath, size)
+	return nil
 }
 
-func (p *PlonkWrapper) ReadPK(filePath string) {
+func (p *PlonkWrapper) ReadPK(filePath string) error {
 	if filePath == "" {
-		logger.Debug("Proving Key filePath is empty, using default output/pk")
-		filePath = "output/pk"
+		logger.Debug("Proving Key filePath is empty, using default pk")
+		filePath = "pk"
 	}
 	logger.Debug("Reading proving key from %s", filePath)
-	utils.EnsureDirExists("output")
-	file, err := os.Open(filePath)
+	file, err := p.GetStore().Open(filePath)
 	if err != nil {
-		logger.Error("failed to open file: %s", err.Error())
-		return
+		return &utils.IOError{Op: "open pk file", Path: filePath, Err: err}
 	}
 	defer file.Close()
 	if p.PK == nil {
@@ -89,41 +100,46 @@ func (p *PlonkWrapper) ReadPK(filePath string) {
 	}
 	size, err := p.PK.ReadFrom(file)
 	if err != nil {
-		logger.Error("failed to read pk from file: %s", err.Error())
+		return &utils.IOError{Op: "read pk", Path: filePath, Err: err}
 	}
 	logger.Debug("read proving key from %s, size= %d", filePath, size)
+	return nil
 }
 
-func (p *PlonkWrapper) WriteVK(filePath string) {
+func (p *PlonkWrapper) WriteVK(filePath string) error {
 	if filePath == "" {
-		logger.Debug("Verification Key filePath is empty, using default output/vk")
-		filePath = "output/vk"
+		logger.Debug("Verification Key filePath is empty, using default vk")
+		filePath = "vk"
+	}
+	if p.VK == nil {
+		return fmt.Errorf("%w: verifying key is nil", utils.ErrMissingSetup)
 	}
 	logger.Debug("Writing verification key to %s", filePath)
-	utils.EnsureDirExists("output")
-	file, err := os.Create(filePath)
+	file, err := p.GetStore().Create(filePath)
 	if err != nil {
-		logger.Error("failed to create file: %s", err.Error())
+		return &utils.IOError{Op: "create vk file", Path: filePath, Err: err}
 	}
-	defer file.Close()
 	size, err := p.VK.WriteTo(file)
 	if err != nil {
-		logger.Error("failed to write vk to file: %s", err.Error())
+		file.Close()
+		return &utils.IOError{Op: "write vk", Path: filePath, Err: err}
+	}
+	if err := file.Close(); err != nil {
+		return &utils.IOError{Op: "close vk file", Path: filePath, Err: err}
 	}
 	logger.Debug("wrote verification key to %s, size= %d", filePath, size)
+	return nil
 }
 
-func (p *PlonkWrapper) ReadVK(filePath string) {
+func (p *PlonkWrapper) ReadVK(filePath string) error {
 	if filePath == "" {
-		logger.Debug("Verification Key filePath is empty, using default output/vk")
-		filePath = "output/vk"
+		logger.Debug("Verification Key filePath is empty, using default vk")
+		filePath = "vk"
 	}
 	logger.Debug("Reading verification key from %s", filePath)
-	utils.EnsureDirExists("output")
-	file, err := os.Open(filePath)
+	file, err := p.GetStore().Open(filePath)
 	if err != nil {
-		logger.Error("failed to open file: %s", err.Error())
-		return
+		return &utils.IOError{Op: "open vk file", Path: filePath, Err: err}
 	}
 	defer file.Close()
 	if p.VK == nil {
@@ -131,56 +147,65 @@ func (p *PlonkWrapper) ReadVK(filePath string) {
 	}
 	size, err := p.VK.ReadFrom(file)
 	if err != nil {
-		logger.Error("failed to read vk from file: %s", err.Error())
+		return &utils.IOError{Op: "read vk", Path: filePath, Err: err}
 	}
 	logger.Debug("read verification key from %s, size= %d", filePath, size)
+	return nil
 }
 
-func (p *PlonkWrapper) WriteWitness(filePath string, public bool) {
+func (p *PlonkWrapper) WriteWitness(filePath string, public bool) error {
 	if filePath == "" {
 		if public {
-			logger.Debug("Witness filePath is empty, using default output/public_witness")
-			filePath = "output/public_witness"
+			logger.Debug("Witness filePath is empty, using default public_witness")
+			filePath = "public_witness"
 		} else {
-			logger.Debug("Witness filePath is empty, using default output/witness")
-			filePath = "output/witness"
+			logger.Debug("Witness filePath is empty, using default witness")
+			filePath = "witness"
 		}
 	}
+	w := p.WitnessFull
 	if public {
+		if p.WitnessPublic == nil {
+			if err := p.GenerateWitness(true); err != nil {
+				return err
+			}
+		}
+		w = p.WitnessPublic
 		logger.Debug("Writing public witness to %s", filePath)
 	} else {
+		if w == nil {
+			return utils.ErrMissingAssignment
+		}
 		logger.Debug("Writing witness to %s", filePath)
 	}
-	utils.EnsureDirExists("output")
-	file, err := os.Create(filePath)
+	file, err := p.GetStore().Create(filePath)
 	if err != nil {
-		logger.Error("failed to create file: %s", err.Error())
-	}
-	defer file.Close()
-	var size int64
-	if public {
-		size, err = p.WitnessPublic.WriteTo(file)
-	} else {
-		size, err = p.WitnessFull.WriteTo(file)
+		return &utils.IOError{Op: "create witness file", Path: filePath, Err: err}
 	}
+	size, err := w.WriteTo(file)
 	if err != nil {
-		logger.Error("failed to write witness to file: %s", err.Error())
+		file.Close()
+		return &utils.IOError{Op: "write witness", Path: filePath, Err: err}
+	}
+	if err := file.Close(); err != nil {
+		return &utils.IOError{Op: "close witness file", Path: filePath, Err: err}
 	}
 	if public {
 		logger.Debug("wrote public witness to %s, size= %d", filePath, size)
 	} else {
 		logger.Debug("wrote witness to %s, size= %d", filePath, size)
 	}
+	return nil
 }
 
-func (p *PlonkWrapper) ReadWitness(filePath string, public bool) {
+func (p *PlonkWrapper) ReadWitness(filePath string, public bool) error {
 	if filePath == "" {
 		if public {
-			logger.Debug("Witness filePath is empty, using default output/public_witness")
-			filePath = "output/public_witness"
+			logger.Debug("Witness filePath is empty, using default public_witness")
+			filePath = "public_witness"
 		} else {
-			logger.Debug("Witness filePath is empty, using default output/witness")
-			filePath = "output/witness"
+			logger.Debug("Witness filePath is empty, using default witness")
+			filePath = "witness"
 		}
 	}
 	if public {
@@ -188,72 +213,63 @@ func (p *PlonkWrapper) ReadWitness(filePath string, public bool) {
 	} else {
 		logger.Debug("Reading witness from %s", filePath)
 	}
-	utils.EnsureDirExists("output")
-	file, err := os.Open(filePath)
+	file, err := p.GetStore().Open(filePath)
 	if err != nil {
-		logger.Error("failed to open file: %s", err.Error())
-		return
+		return &utils.IOError{Op: "open witness file", Path: filePath, Err: err}
 	}
 	defer file.Close()
-	var size int64
-	if public {
-		if p.WitnessPublic == nil {
-			p.WitnessPublic, err = witness.New(p.Field)
-			if err != nil {
-				logger.Fatal("failed to create witness: %s", err.Error())
-			}
-		}
-		size, err = p.WitnessPublic.ReadFrom(file)
-	} else {
-		if p.WitnessFull == nil {
-			p.WitnessFull, err = witness.New(p.Field)
-			if err != nil {
-				logger.Fatal("failed to create witness: %s", err.Error())
-			}
-		}
-		size, err = p.WitnessFull.ReadFrom(file)
+	w, err := witness.New(p.Field)
+	if err != nil {
+		return fmt.Errorf("failed to create witness: %w", err)
 	}
+	size, err := w.ReadFrom(file)
 	if err != nil {
-		logger.Error("failed to read witness from file: %s", err.Error())
-		return
+		return &utils.IOError{Op: "read witness", Path: filePath, Err: err}
 	}
 	if public {
+		p.WitnessPublic = w
 		logger.Debug("read public witness from %s, size= %d", filePath, size)
 	} else {
+		p.WitnessFull = w
 		logger.Debug("read witness from %s, size= %d", filePath, size)
 	}
+	return nil
 }
 
-func (p *PlonkWrapper) WriteProof(filePath string) {
+func (p *PlonkWrapper) WriteProof(filePath string) error {
 	if filePath == "" {
-		logger.Debug("Proof filePath is empty, using default output/proof")
-		filePath = "output/proof"
+		logger.Debug("Proof filePath is empty, using default proof")
+		filePath = "proof"
+	}
+	if p.Proof == nil {
+		return fmt.Errorf("%w: proof is nil", utils.ErrInvalidProof)
 	}
 	logger.Debug("Writing proof to %s", filePath)
-	utils.EnsureDirExists("output")
-	file, err := os.Create(filePath)
+	file, err := p.GetStore().Create(filePath)
 	if err != nil {
-		logger.Error("failed to create file: %s", err.Error())
+		return &utils.IOError{Op: "create proof file", Path: filePath, Err: err}
 	}
-	defer file.Close()
 	size, err := p.Proof.WriteTo(file)
 	if err != nil {
-		logger.Error("failed to write proof to file: %s", err.Error())
+		file.Close()
+		return &utils.IOError{Op: "write proof", Path: filePath, Err: err}
+	}
+	if err := file.Close(); err != nil {
+		return &utils.IOError{Op: "close proof file", Path: filePath, Err: err}
 	}
 	logger.Debug("wrote proof to %s, size= %d", filePath, size)
+	return nil
 }
 
-func (p *PlonkWrapper) ReadProof(filePath string) {
+func (p *PlonkWrapper) ReadProof(filePath string) error {
 	if filePath == "" {
-		logger.Debug("Proof filePath is empty, using default output/proof")
-		filePath = "output/proof"
+		logger.Debug("Proof filePath is empty, using default proof")
+		filePath = "proof"
 	}
 	logger.Debug("Reading proof from %s", filePath)
-	utils.EnsureDirExists("output")
-	file, err := os.Open(filePath)
+	file, err := p.GetStore().Open(filePath)
 	if err != nil {
-		logger.Error("failed to open file: %s", err.Error())
-		return
+		return &utils.IOError{Op: "open proof file", Path: filePath, Err: err}
 	}
 	defer file.Close()
 	if p.Proof == nil {
@@ -261,7 +277,8 @@ func (p *PlonkWrapper) ReadProof(filePath string) {
 	}
 	size, err := p.Proof.ReadFrom(file)
 	if err != nil {
-		logger.Error("failed to read proof from file: %s", err.Error())
+		return &utils.IOError{Op: "read proof", Path: filePath, Err: err}
 	}
 	logger.Debug("read proof from %s, size= %d", filePath, size)
+	return nil
 }
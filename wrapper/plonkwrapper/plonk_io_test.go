@@ -1,34 +1,60 @@
 package plonkwrapper
 
 import (
+	"errors"
+	"io/fs"
+	"slices"
 	"testing"
 
 	"github.com/oliverustc/gnarkabc/circuits"
 	"github.com/oliverustc/gnarkabc/logger"
 	"github.com/oliverustc/gnarkabc/utils"
+	"github.com/oliverustc/gnarkabc/wrapper/store"
+
+	"github.com/consensys/gnark-crypto/ecc"
 )
 
 func TestPlonkWrite(t *testing.T) {
 	utils.RemoveDir("output")
 	var circuit circuits.Product
-	circuit.PreCompile(nil)
+	circuit.PreCompile(circuits.NoParams{})
 	for _, curveName := range utils.CurveNameList {
 		curve := utils.CurveMap[curveName]
 		p := NewWrapper(&circuit, curve)
-		p.Compile()
-		p.Setup()
-		assignParams := []any{13, 17}
+		if err := p.Compile(); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.Setup(); err != nil {
+			t.Fatal(err)
+		}
+		assignParams := circuits.ProductAssign{P: 13, Q: 17}
 		circuit.Assign(assignParams)
 		p.SetAssignment(&circuit)
-		p.Prove()
-		p.Verify()
+		if err := p.Prove(); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.Verify(); err != nil {
+			t.Fatal(err)
+		}
 		logger.Info("plonk on curve [ %s ] success", curveName)
-		p.WriteCCS("output/ccs_" + curveName)
-		p.WritePK("output/pk_" + curveName)
-		p.WriteVK("output/vk_" + curveName)
-		p.WriteWitness("output/witness_"+curveName, false)
-		p.WriteWitness("output/public_witness_"+curveName, true)
-		p.WriteProof("output/proof_" + curveName)
+		if err := p.WriteCCS("ccs_" + curveName); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.WritePK("pk_" + curveName); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.WriteVK("vk_" + curveName); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.WriteWitness("witness_"+curveName, false); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.WriteWitness("public_witness_"+curveName, true); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.WriteProof("proof_" + curveName); err != nil {
+			t.Fatal(err)
+		}
 		logger.Info("write params success on [ %s ]", curveName)
 	}
 }
@@ -36,21 +62,98 @@ func TestPlonkWrite(t *testing.T) {
 func TestPlonkRead(t *testing.T) {
 	for _, curveName := range utils.CurveNameList {
 		var circuit circuits.Product
-		circuit.PreCompile(nil)
+		circuit.PreCompile(circuits.NoParams{})
 		curve := utils.CurveMap[curveName]
 
 		p := NewWrapper(&circuit, curve)
-		p.ReadCCS("output/ccs_" + curveName)
-		p.ReadPK("output/pk_" + curveName)
-		p.ReadVK("output/vk_" + curveName)
-		p.ReadWitness("output/witness_"+curveName, false)
+		if err := p.ReadCCS("ccs_" + curveName); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.ReadPK("pk_" + curveName); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.ReadVK("vk_" + curveName); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.ReadWitness("witness_"+curveName, false); err != nil {
+			t.Fatal(err)
+		}
 		// 首先基于已有参数自行prove和verify
-		p.Prove()
-		p.ReadWitness("output/public_witness_"+curveName, true)
-		p.Verify()
+		if err := p.Prove(); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.ReadWitness("public_witness_"+curveName, true); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.Verify(); err != nil {
+			t.Fatal(err)
+		}
 		// 然后读取已有的proof仅进行验证
-		p.ReadProof("output/proof_" + curveName)
-		p.Verify()
+		if err := p.ReadProof("proof_" + curveName); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.Verify(); err != nil {
+			t.Fatal(err)
+		}
 		logger.Info("prove and verify success on [ %s ] after read params", curveName)
 	}
 }
+
+func TestPlonkMemoryStore(t *testing.T) {
+	mem := store.NewMemory()
+	var circuit circuits.Product
+	circuit.PreCompile(circuits.NoParams{})
+	zk := NewWrapper(&circuit, ecc.BN254)
+	zk.SetStore(mem)
+	if err := zk.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	if err := zk.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	circuit.Assign(circuits.ProductAssign{P: 13, Q: 17})
+	zk.SetAssignment(&circuit)
+	if err := zk.Prove(); err != nil {
+		t.Fatal(err)
+	}
+	// 文件名为空时使用默认名称
+	for _, write := range []func(string) error{zk.WriteCCS, zk.WriteVK, zk.WriteProof} {
+		if err := write(""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zk.WriteWitness("", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := zk.ExportSolidity(""); err != nil {
+		t.Fatal(err)
+	}
+	names, err := mem.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(names, []string{"PlonkVerifier.sol", "ccs", "proof", "public_witness", "vk"}) {
+		t.Fatalf("unexpected artifacts %v", names)
+	}
+
+	verifier := NewWrapper(&circuit, ecc.BN254)
+	verifier.SetStore(mem)
+	if err := verifier.ReadCCS(""); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifier.ReadVK(""); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifier.ReadProof(""); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifier.ReadWitness("", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifier.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifier.ReadPK(""); !errors.Is(err, utils.ErrIO) || !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected missing pk, got %v", err)
+	}
+}
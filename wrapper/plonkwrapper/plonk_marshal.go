@@ -3,13 +3,18 @@ package plonkwrapper
 import (
 	"bytes"
 	"encoding/base64"
+	"fmt"
 
 	"github.com/consensys/gnark/backend/plonk"
 	"github.com/consensys/gnark/backend/witness"
 	"github.com/oliverustc/gnarkabc/logger"
+	"github.com/oliverustc/gnarkabc/utils"
 )
 
 func (p *PlonkWrapper) MarshalCCS() ([]byte, error) {
+	if p.CCS == nil {
+		return nil, fmt.Errorf("%w: constraint system is nil", utils.ErrMissingSetup)
+	}
 	var buf bytes.Buffer
 	size, err := p.CCS.WriteTo(&buf)
 	if err != nil {
@@ -46,6 +51,9 @@ func (p *PlonkWrapper) UnmarshalCCSFromStr(str string) error {
 }
 
 func (p *PlonkWrapper) MarshalPK() ([]byte, error) {
+	if p.PK == nil {
+		return nil, fmt.Errorf("%w: proving key is nil", utils.ErrMissingSetup)
+	}
 	var buf bytes.Buffer
 	size, err := p.PK.WriteTo(&buf)
 	if err != nil {
@@ -82,6 +90,9 @@ func (p *PlonkWrapper) UnmarshalPKFromStr(str string) error {
 }
 
 func (p *PlonkWrapper) MarshalVK() ([]byte, error) {
+	if p.VK == nil {
+		return nil, fmt.Errorf("%w: verifying key is nil", utils.ErrMissingSetup)
+	}
 	var buf bytes.Buffer
 	size, err := p.VK.WriteTo(&buf)
 	if err != nil {
@@ -118,6 +129,9 @@ func (p *PlonkWrapper) UnmarshalVKFromStr(str string) error {
 }
 
 func (p *PlonkWrapper) MarshalProof() ([]byte, error) {
+	if p.Proof == nil {
+		return nil, fmt.Errorf("%w: proof is nil", utils.ErrInvalidProof)
+	}
 	var buf bytes.Buffer
 	size, err := p.Proof.WriteTo(&buf)
 	if err != nil {
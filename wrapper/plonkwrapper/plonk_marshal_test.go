@@ -24,15 +24,23 @@ func TestPlonkMarshal(t *testing.T) {
 		logger.Info("marshal params on curve: %s", curveName)
 		curve := utils.CurveMap[curveName]
 		var circuit circuits.Product
-		circuit.PreCompile(nil)
+		circuit.PreCompile(circuits.NoParams{})
 		zk := NewWrapper(&circuit, curve)
-		zk.Compile()
-		zk.Setup()
-		assignParams := []any{13, 17}
+		if err := zk.Compile(); err != nil {
+			t.Fatal(err)
+		}
+		if err := zk.Setup(); err != nil {
+			t.Fatal(err)
+		}
+		assignParams := circuits.ProductAssign{P: 13, Q: 17}
 		circuit.Assign(assignParams)
 		zk.SetAssignment(&circuit)
-		zk.Prove()
-		zk.Verify()
+		if err := zk.Prove(); err != nil {
+			t.Fatal(err)
+		}
+		if err := zk.Verify(); err != nil {
+			t.Fatal(err)
+		}
 
 		ccsStr, _ := zk.MarshalCCSToStr()
 		pkStr, _ := zk.MarshalPKToStr()
@@ -41,7 +49,7 @@ func TestPlonkMarshal(t *testing.T) {
 		witnessPublicStr, _ := zk.MarshalWitnessToStr(true)
 		proofStr, _ := zk.MarshalProofToStr()
 		// write str to a json file
-		jsonFile, _ := os.Create("output/plonk_params_" + curveName + ".json")
+		jsonFile, _ := zk.GetStore().Create("plonk_params_" + curveName + ".json")
 		plonkParams := PlonkParams{
 			CCS:           ccsStr,
 			PK:            pkStr,
@@ -61,7 +69,7 @@ func TestPlonkUnmarshal(t *testing.T) {
 		logger.Info("unmarshal params on curve: %s", curveName)
 		curve := utils.CurveMap[curveName]
 		var circuit circuits.Product
-		circuit.PreCompile(nil)
+		circuit.PreCompile(circuits.NoParams{})
 		zk := NewWrapper(&circuit, curve)
 		jsonData, err := os.ReadFile("output/plonk_params_" + curveName + ".json")
 		if err != nil {
@@ -80,7 +88,9 @@ func TestPlonkUnmarshal(t *testing.T) {
 		zk.UnmarshalWitnessFromStr(plonkParams.Witness, false)
 		zk.UnmarshalWitnessFromStr(plonkParams.WitnessPublic, true)
 		zk.UnmarshalProofFromStr(plonkParams.Proof)
-		zk.Verify()
+		if err := zk.Verify(); err != nil {
+			t.Fatal(err)
+		}
 		logger.Info("verify success on curve: %s", curveName)
 	}
 }
@@ -3,8 +3,10 @@ package plonkwrapper
 import (
 	"fmt"
 
-	"github.com/oliverustc/gnarkabc/logger"
+	"github.com/oliverustc/gnarkabc/hash/witnesshash"
+	"github.com/oliverustc/gnarkabc/utils"
 
+	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/backend/plonk"
 	"github.com/consensys/gnark/backend/witness"
 	"github.com/consensys/gnark/constraint"
@@ -14,6 +16,18 @@ import (
 	recursion_plonk "github.com/consensys/gnark/std/recursion/plonk"
 )
 
+// OuterCompileParams OuterCircuit的编译参数，内层验证密钥作为常量编译进电路
+type OuterCompileParams struct {
+	InnerCCS constraint.ConstraintSystem // 内层电路的约束系统
+	InnerVK  plonk.VerifyingKey          // 内层验证密钥
+}
+
+// OuterAssignParams OuterCircuit的赋值参数
+type OuterAssignParams struct {
+	InnerWitness witness.Witness // 内层见证者
+	InnerProof   plonk.Proof     // 内层证明
+}
+
 type OuterCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT] struct {
 	Proof        recursion_plonk.Proof[FR, G1El, G2El]
 	VerifyingKey recursion_plonk.VerifyingKey[FR, G1El, G2El] `gnark:"-"`
@@ -30,30 +44,109 @@ func (oc *OuterCircuit[FR, G1El, G2El, GtEl]) Define(api frontend.API) error {
 	return err
 }
 
-func (oc *OuterCircuit[FR, G1El, G2El, GtEl]) PreCompile(params any) {
-	innerCCS := params.([]any)[0].(constraint.ConstraintSystem)
-	oc.Proof = recursion_plonk.PlaceholderProof[FR, G1El, G2El](innerCCS)
-	oc.InnerWitness = recursion_plonk.PlaceholderWitness[FR](innerCCS)
-	innerVK := params.([]any)[1].(plonk.VerifyingKey)
-	circuitVK, err := recursion_plonk.ValueOfVerifyingKey[FR, G1El, G2El](innerVK)
+func (oc *OuterCircuit[FR, G1El, G2El, GtEl]) PreCompile(params OuterCompileParams) error {
+	if params.InnerCCS == nil || params.InnerVK == nil {
+		return fmt.Errorf("%w: inner constraint system or verifying key is nil", utils.ErrMissingSetup)
+	}
+	oc.Proof = recursion_plonk.PlaceholderProof[FR, G1El, G2El](params.InnerCCS)
+	oc.InnerWitness = recursion_plonk.PlaceholderWitness[FR](params.InnerCCS)
+	circuitVK, err := recursion_plonk.ValueOfVerifyingKey[FR, G1El, G2El](params.InnerVK)
 	if err != nil {
-		logger.Fatal("failed to convert verifying key: %v", err)
+		return fmt.Errorf("failed to convert verifying key: %w", err)
 	}
 	oc.VerifyingKey = circuitVK
+	return nil
+}
+
+func (oc *OuterCircuit[FR, G1El, G2El, GtEl]) Assign(params OuterAssignParams) error {
+	circuitWitness, err := recursion_plonk.ValueOfWitness[FR](params.InnerWitness)
+	if err != nil {
+		return fmt.Errorf("failed to convert witness: %w", err)
+	}
+	circuitProof, err := recursion_plonk.ValueOfProof[FR, G1El, G2El](params.InnerProof)
+	if err != nil {
+		return fmt.Errorf("failed to convert proof: %w", err)
+	}
+	oc.InnerWitness = circuitWitness
+	oc.Proof = circuitProof
+	return nil
+}
+
+// OuterDigestCompileParams OuterCircuitDigest的编译参数，内层验证密钥作为常量编译进电路
+type OuterDigestCompileParams struct {
+	InnerCCS   constraint.ConstraintSystem // 内层电路的约束系统
+	InnerVK    plonk.VerifyingKey          // 内层验证密钥
+	Hash       witnesshash.Kind            // 计算公开输入摘要的哈希函数
+	OuterCurve ecc.ID                      // 外层电路所在的曲线
+}
+
+// OuterDigestAssignParams OuterCircuitDigest的赋值参数，摘要的参数与编译参数一致，
+// 赋值对象不必调用 PreCompile
+type OuterDigestAssignParams struct {
+	InnerWitness witness.Witness  // 内层见证者
+	InnerProof   plonk.Proof      // 内层证明
+	Hash         witnesshash.Kind // 计算公开输入摘要的哈希函数
+	InnerCurve   ecc.ID           // 内层电路所在的曲线
+	OuterCurve   ecc.ID           // 外层电路所在的曲线
+}
+
+// OuterCircuitDigest 与 OuterCircuit 相同，但内层见证者作为私有输入，
+// 外层电路只公开其摘要 Digest，公开输入的个数不再随内层电路增长，摘要与 witnesshash.DigestWitness 的结果一致
+type OuterCircuitDigest[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT] struct {
+	Proof        recursion_plonk.Proof[FR, G1El, G2El]
+	VerifyingKey recursion_plonk.VerifyingKey[FR, G1El, G2El] `gnark:"-"`
+	InnerWitness recursion_plonk.Witness[FR]
+	Digest       frontend.Variable `gnark:",public"`
+
+	kind witnesshash.Kind `gnark:"-"`
 }
 
-func (oc *OuterCircuit[FR, G1El, G2El, GtEl]) Assign(params any) {
-	innerWitness := params.([]any)[0].(witness.Witness)
-	innerProof := params.([]any)[1].(plonk.Proof)
+func (oc *OuterCircuitDigest[FR, G1El, G2El, GtEl]) Define(api frontend.API) error {
+	verifier, err := recursion_plonk.NewVerifier[FR, G1El, G2El, GtEl](api)
+	if err != nil {
+		return fmt.Errorf("failed to create verifier: %w", err)
+	}
+	if err := verifier.AssertProof(oc.VerifyingKey, oc.Proof, oc.InnerWitness, recursion_plonk.WithCompleteArithmetic()); err != nil {
+		return err
+	}
+	digest, err := witnesshash.Define(api, oc.kind, oc.InnerWitness.Public)
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(digest, oc.Digest)
+	return nil
+}
 
-	circuitWitness, err := recursion_plonk.ValueOfWitness[FR](innerWitness)
+func (oc *OuterCircuitDigest[FR, G1El, G2El, GtEl]) PreCompile(params OuterDigestCompileParams) error {
+	if params.InnerCCS == nil || params.InnerVK == nil {
+		return fmt.Errorf("%w: inner constraint system or verifying key is nil", utils.ErrMissingSetup)
+	}
+	oc.Proof = recursion_plonk.PlaceholderProof[FR, G1El, G2El](params.InnerCCS)
+	oc.InnerWitness = recursion_plonk.PlaceholderWitness[FR](params.InnerCCS)
+	circuitVK, err := recursion_plonk.ValueOfVerifyingKey[FR, G1El, G2El](params.InnerVK)
+	if err != nil {
+		return fmt.Errorf("failed to convert verifying key: %w", err)
+	}
+	oc.VerifyingKey = circuitVK
+	oc.kind = params.Hash
+	return nil
+}
+
+func (oc *OuterCircuitDigest[FR, G1El, G2El, GtEl]) Assign(params OuterDigestAssignParams) error {
+	circuitWitness, err := recursion_plonk.ValueOfWitness[FR](params.InnerWitness)
+	if err != nil {
+		return fmt.Errorf("failed to convert witness: %w", err)
+	}
+	circuitProof, err := recursion_plonk.ValueOfProof[FR, G1El, G2El](params.InnerProof)
 	if err != nil {
-		logger.Fatal("failed to convert witness: %v", err)
+		return fmt.Errorf("failed to convert proof: %w", err)
 	}
-	circuitProof, err := recursion_plonk.ValueOfProof[FR, G1El, G2El](innerProof)
+	digest, err := witnesshash.DigestWitness(params.Hash, params.OuterCurve, params.InnerCurve, params.InnerWitness)
 	if err != nil {
-		logger.Fatal("failed to convert proof: %v", err)
+		return fmt.Errorf("failed to compute input digest: %w", err)
 	}
 	oc.InnerWitness = circuitWitness
 	oc.Proof = circuitProof
+	oc.Digest = digest
+	return nil
 }
@@ -1,86 +1,116 @@
 package plonkwrapper
 
 import (
+	"math/big"
 	"testing"
 
 	"github.com/oliverustc/gnarkabc/circuits"
+	"github.com/oliverustc/gnarkabc/hash/witnesshash"
 	"github.com/oliverustc/gnarkabc/logger"
 	"github.com/oliverustc/gnarkabc/utils"
+	"github.com/oliverustc/gnarkabc/wrapper/testutil"
 
+	"github.com/consensys/gnark-crypto/ecc"
+	bw6761fr "github.com/consensys/gnark-crypto/ecc/bw6-761/fr"
+	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/std/algebra/emulated/sw_bn254"
 	"github.com/consensys/gnark/std/algebra/emulated/sw_bw6761"
 	"github.com/consensys/gnark/std/algebra/native/sw_bls12377"
 	recursion_plonk "github.com/consensys/gnark/std/recursion/plonk"
+	"github.com/consensys/gnark/test"
 )
 
 func TestGenerateInnerProofsProduct(t *testing.T) {
 	var circuit circuits.Product
-	circuit.PreCompile(nil)
+	circuit.PreCompile(circuits.NoParams{})
 	for innerCurveName, OuterCurve := range utils.PlonkRecursionMap {
 		innerCurve := utils.CurveMap[innerCurveName]
 		zk := NewWrapper(&circuit, innerCurve)
-		zk.Compile()
-		zk.Setup()
+		if err := zk.Compile(); err != nil {
+			t.Fatal(err)
+		}
+		if err := zk.Setup(); err != nil {
+			t.Fatal(err)
+		}
 		p := utils.RandInt(0, 1000)
 		q := utils.RandInt(0, 1000)
-		assignParams := []any{p, q}
+		assignParams := circuits.ProductAssign{P: p, Q: q}
 		circuit.Assign(assignParams)
 		zk.SetAssignment(&circuit)
-		outerField := OuterCurve.ScalarField()
-		innerField := innerCurve.ScalarField()
-		zk.Prove(recursion_plonk.GetNativeProverOptions(outerField, innerField))
-		zk.Verify(recursion_plonk.GetNativeVerifierOptions(outerField, innerField))
+		zk.SetRecursionTarget(OuterCurve)
+		if err := zk.Prove(); err != nil {
+			t.Fatal(err)
+		}
+		if err := zk.Verify(); err != nil {
+			t.Fatal(err)
+		}
 
-		zk.WriteCCS("output/product_" + innerCurveName + ".ccs")
-		zk.WriteVK("output/product_" + innerCurveName + ".vk")
-		zk.WriteProof("output/product_" + innerCurveName + ".proof")
-		zk.WriteWitness("output/product_"+innerCurveName+".wit", false)
+		if err := zk.WriteCCS("product_" + innerCurveName + ".ccs"); err != nil {
+			t.Fatal(err)
+		}
+		if err := zk.WriteVK("product_" + innerCurveName + ".vk"); err != nil {
+			t.Fatal(err)
+		}
+		if err := zk.WriteProof("product_" + innerCurveName + ".proof"); err != nil {
+			t.Fatal(err)
+		}
+		if err := zk.WriteWitness("product_"+innerCurveName+".wit", false); err != nil {
+			t.Fatal(err)
+		}
 	}
 }
 
-func ReadProductInnerZK(curveName string) *PlonkWrapper {
+func ReadProductInnerZK(t *testing.T, curveName string) *PlonkWrapper {
 	var innerCircuit circuits.Product
-	innerCircuit.PreCompile(nil)
+	innerCircuit.PreCompile(circuits.NoParams{})
 	innerCurve := utils.CurveMap[curveName]
 	innerZK := NewWrapper(&innerCircuit, innerCurve)
-	innerZK.ReadCCS("output/product_" + curveName + ".ccs")
-	innerZK.ReadProof("output/product_" + curveName + ".proof")
-	innerZK.ReadVK("output/product_" + curveName + ".vk")
-	innerZK.ReadWitness("output/product_"+curveName+".wit", false)
+	if err := innerZK.ReadCCS("product_" + curveName + ".ccs"); err != nil {
+		t.Fatal(err)
+	}
+	if err := innerZK.ReadProof("product_" + curveName + ".proof"); err != nil {
+		t.Fatal(err)
+	}
+	if err := innerZK.ReadVK("product_" + curveName + ".vk"); err != nil {
+		t.Fatal(err)
+	}
+	if err := innerZK.ReadWitness("product_"+curveName+".wit", false); err != nil {
+		t.Fatal(err)
+	}
 	return innerZK
 }
 
-func ProductRecursionBN254InBN254() {
-	innerZK := ReadProductInnerZK("BN254")
+func ProductRecursionBN254InBN254(t *testing.T) {
+	innerZK := ReadProductInnerZK(t, "BN254")
 
 	var outerCircuit OuterCircuit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl]
-	outerCircuit.PreCompile([]any{innerZK.CCS, innerZK.VK})
+	if err := outerCircuit.PreCompile(OuterCompileParams{InnerCCS: innerZK.CCS, InnerVK: innerZK.VK}); err != nil {
+		t.Fatal(err)
+	}
 	recursionZK := NewWrapper(&outerCircuit, utils.CurveMap["BN254"])
-	if !utils.CheckFileExists("output/product_recursion_BN254_BN254.vk") {
-		recursionZK.Compile()
-		recursionZK.Setup()
-		recursionZK.WriteCCS("output/product_recursion_BN254_BN254.ccs")
-		recursionZK.WritePK("output/product_recursion_BN254_BN254.pk")
-		recursionZK.WriteVK("output/product_recursion_BN254_BN254.vk")
-	} else {
-		recursionZK.ReadCCS("output/product_recursion_BN254_BN254.ccs")
-		recursionZK.ReadPK("output/product_recursion_BN254_BN254.pk")
-		recursionZK.ReadVK("output/product_recursion_BN254_BN254.vk")
-	}
-
-	assignParams := []any{innerZK.WitnessFull, innerZK.Proof}
-	outerCircuit.Assign(assignParams)
+	if _, err := testutil.ArtifactCache(t).Setup(recursionZK); err != nil {
+		t.Fatal(err)
+	}
+
+	assignParams := OuterAssignParams{InnerWitness: innerZK.WitnessFull, InnerProof: innerZK.Proof}
+	if err := outerCircuit.Assign(assignParams); err != nil {
+		t.Fatal(err)
+	}
 	recursionZK.SetAssignment(&outerCircuit)
-	recursionZK.Prove()
-	recursionZK.Verify()
+	if err := recursionZK.Prove(); err != nil {
+		t.Fatal(err)
+	}
+	if err := recursionZK.Verify(); err != nil {
+		t.Fatal(err)
+	}
 	logger.Info("recursive prove [BN254] innerProof on [BN254] success, took %v", recursionZK.ProveTime)
 }
 
-func ProductRecursionBLS12377InBW6761() {
-	innerZK := ReadProductInnerZK("BW6-761")
+func ProductRecursionBLS12377InBW6761(t *testing.T) {
+	innerZK := ReadProductInnerZK(t, "BLS12-377")
 
 	var outerCircuit OuterCircuit[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT]
-	// outerCircuit.PreCompile([]any{innerZK.CCS, innerZK.VK})
+	// outerCircuit.PreCompile(OuterCompileParams{InnerCCS: innerZK.CCS, InnerVK: innerZK.VK})
 	outerCircuit.InnerWitness = recursion_plonk.PlaceholderWitness[sw_bls12377.ScalarField](innerZK.CCS)
 	outerCircuit.Proof = recursion_plonk.PlaceholderProof[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine](innerZK.CCS)
 	circuitVK, err := recursion_plonk.ValueOfVerifyingKey[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine](innerZK.VK)
@@ -89,53 +119,103 @@ func ProductRecursionBLS12377InBW6761() {
 	}
 	outerCircuit.VerifyingKey = circuitVK
 	recursionZK := NewWrapper(&outerCircuit, utils.CurveMap["BW6-761"])
-	if !utils.CheckFileExists("output/product_recursion_BLS12-377_BW6761.vk") {
-		recursionZK.Compile()
-		recursionZK.Setup()
-		recursionZK.WriteCCS("output/product_recursion_BLS12-377_BW6761.ccs")
-		recursionZK.WritePK("output/product_recursion_BLS12-377_BW6761.pk")
-		recursionZK.WriteVK("output/product_recursion_BLS12-377_BW6761.vk")
-	} else {
-		recursionZK.ReadCCS("output/product_recursion_BLS12-377_BW6761.ccs")
-		recursionZK.ReadPK("output/product_recursion_BLS12-377_BW6761.pk")
-		recursionZK.ReadVK("output/product_recursion_BLS12-377_BW6761.vk")
-	}
-
-	assignParams := []any{innerZK.WitnessFull, innerZK.Proof}
-	outerCircuit.Assign(assignParams)
+	if _, err := testutil.ArtifactCache(t).Setup(recursionZK); err != nil {
+		t.Fatal(err)
+	}
+
+	assignParams := OuterAssignParams{InnerWitness: innerZK.WitnessFull, InnerProof: innerZK.Proof}
+	if err := outerCircuit.Assign(assignParams); err != nil {
+		t.Fatal(err)
+	}
 	recursionZK.SetAssignment(&outerCircuit)
-	recursionZK.Prove()
-	recursionZK.Verify()
+	if err := recursionZK.Prove(); err != nil {
+		t.Fatal(err)
+	}
+	if err := recursionZK.Verify(); err != nil {
+		t.Fatal(err)
+	}
 	logger.Info("recursive prove [BLS12-377] innerProof on [BW6-761] success, took %v", recursionZK.ProveTime)
 }
 
-func ProductRecursionBW6761InBN254() {
-	innerZK := ReadProductInnerZK("BW6-761")
+func ProductRecursionBW6761InBN254(t *testing.T) {
+	innerZK := ReadProductInnerZK(t, "BW6-761")
 
 	var outerCircuit OuterCircuit[sw_bw6761.ScalarField, sw_bw6761.G1Affine, sw_bw6761.G2Affine, sw_bw6761.GTEl]
-	outerCircuit.PreCompile([]any{innerZK.CCS, innerZK.VK})
+	if err := outerCircuit.PreCompile(OuterCompileParams{InnerCCS: innerZK.CCS, InnerVK: innerZK.VK}); err != nil {
+		t.Fatal(err)
+	}
 	recursionZK := NewWrapper(&outerCircuit, utils.CurveMap["BN254"])
-	if !utils.CheckFileExists("output/product_recursion_BW6-761_BN254.vk") {
-		recursionZK.Compile()
-		recursionZK.Setup()
-		recursionZK.WriteCCS("output/product_recursion_BW6-761_BN254.ccs")
-		recursionZK.WritePK("output/product_recursion_BW6-761_BN254.pk")
-		recursionZK.WriteVK("output/product_recursion_BW6-761_BN254.vk")
-	} else {
-		recursionZK.ReadCCS("output/product_recursion_BW6-761_BN254.ccs")
-		recursionZK.ReadPK("output/product_recursion_BW6-761_BN254.pk")
-		recursionZK.ReadVK("output/product_recursion_BW6-761_BN254.vk")
-	}
-	assignParams := []any{innerZK.WitnessFull, innerZK.Proof}
-	outerCircuit.Assign(assignParams)
+	if _, err := testutil.ArtifactCache(t).Setup(recursionZK); err != nil {
+		t.Fatal(err)
+	}
+	assignParams := OuterAssignParams{InnerWitness: innerZK.WitnessFull, InnerProof: innerZK.Proof}
+	if err := outerCircuit.Assign(assignParams); err != nil {
+		t.Fatal(err)
+	}
 	recursionZK.SetAssignment(&outerCircuit)
-	recursionZK.Prove()
-	recursionZK.Verify()
+	if err := recursionZK.Prove(); err != nil {
+		t.Fatal(err)
+	}
+	if err := recursionZK.Verify(); err != nil {
+		t.Fatal(err)
+	}
 	logger.Info("recursive prove [BW6-761] innerProof on [BN254] success, took %v", recursionZK.ProveTime)
 }
 
 func TestProductRecursion(t *testing.T) {
-	// ProductRecursionBN254InBN254()
-	ProductRecursionBLS12377InBW6761()
-	// ProductRecursionBW6761InBN254()
+	// ProductRecursionBN254InBN254(t)
+	ProductRecursionBLS12377InBW6761(t)
+	// ProductRecursionBW6761InBN254(t)
+}
+
+// 在测试引擎中检查 OuterCircuitDigest：唯一的公开输入为内层公开输入的摘要，错误的摘要不能满足电路
+func TestOuterCircuitDigestSolved(t *testing.T) {
+	var inner circuits.Product
+	zk := NewWrapper(&inner, ecc.BLS12_377)
+	if err := zk.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	if err := zk.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	inner.Assign(circuits.ProductAssign{P: 3, Q: 5})
+	zk.SetAssignment(&inner)
+	zk.SetRecursionTarget(ecc.BW6_761)
+	if err := zk.Prove(); err != nil {
+		t.Fatal(err)
+	}
+
+	field := ecc.BW6_761.ScalarField()
+	for _, kind := range []witnesshash.Kind{witnesshash.MiMC, witnesshash.Poseidon2, witnesshash.SHA256} {
+		var circuit, assignment OuterCircuitDigest[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT]
+		compileParams := OuterDigestCompileParams{InnerCCS: zk.CCS, InnerVK: zk.VK, Hash: kind, OuterCurve: ecc.BW6_761}
+		if err := circuit.PreCompile(compileParams); err != nil {
+			t.Fatal(err)
+		}
+		assignParams := OuterDigestAssignParams{InnerWitness: zk.WitnessFull, InnerProof: zk.Proof, Hash: kind, InnerCurve: ecc.BLS12_377, OuterCurve: ecc.BW6_761}
+		if err := assignment.Assign(assignParams); err != nil {
+			t.Fatal(err)
+		}
+		expected, err := witnesshash.Digest(kind, ecc.BW6_761, ecc.BLS12_377, []*big.Int{big.NewInt(15)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected.Cmp(assignment.Digest.(*big.Int)) != 0 {
+			t.Fatalf("%s: assigned digest differs from the native digest", kind)
+		}
+		public, err := frontend.NewWitness(&assignment, field, frontend.PublicOnly())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := public.Vector().(bw6761fr.Vector).Len(); n != 1 {
+			t.Fatalf("%s: expected a single public input, got %d", kind, n)
+		}
+		if err := test.IsSolved(&circuit, &assignment, field); err != nil {
+			t.Fatalf("%s: %v", kind, err)
+		}
+		assignment.Digest = new(big.Int).Add(expected, big.NewInt(1))
+		if err := test.IsSolved(&circuit, &assignment, field); err == nil {
+			t.Fatalf("%s: expected unsolved circuit with a wrong digest", kind)
+		}
+	}
 }
@@ -2,97 +2,122 @@ package plonkwrapper
 
 import (
 	"encoding/hex"
+	"fmt"
 	"math/big"
-	"os"
 	"os/exec"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"text/template"
 
 	"github.com/oliverustc/gnarkabc/logger"
 	"github.com/oliverustc/gnarkabc/utils"
+	"github.com/oliverustc/gnarkabc/wrapper/store"
 
 	"github.com/consensys/gnark-crypto/ecc"
 	fr_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/fr"
 )
 
-func (p *PlonkWrapper) ExportSolidity(filePath string) {
+func (p *PlonkWrapper) ExportSolidity(filePath string) error {
 	if filePath == "" {
-		logger.Info("filePath is empty, using default path: output/PlonkVerifier.sol")
-		filePath = "output/PlonkVerifier.sol"
+		logger.Info("filePath is empty, using default path: PlonkVerifier.sol")
+		filePath = "PlonkVerifier.sol"
 	}
 	if p.Curve != ecc.BN254 {
-		logger.Fatal("only BN254 curve is supported")
+		return fmt.Errorf("%w: only BN254 curve is supported", utils.ErrUnsupportedCurve)
 	}
-	utils.EnsureDirExists("output")
-	solFile, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY, 0666)
+	if p.VK == nil {
+		return fmt.Errorf("%w: verifying key is nil", utils.ErrMissingSetup)
+	}
+	solFile, err := p.GetStore().Create(filePath)
 	if err != nil {
-		logger.Fatal("open file failed: %v", err)
+		return &utils.IOError{Op: "create solidity file", Path: filePath, Err: err}
+	}
+	if err := p.VK.ExportSolidity(solFile); err != nil {
+		solFile.Close()
+		return &utils.IOError{Op: "export solidity", Path: filePath, Err: err}
+	}
+	if err := solFile.Close(); err != nil {
+		return &utils.IOError{Op: "close solidity file", Path: filePath, Err: err}
 	}
-	defer solFile.Close()
-	p.VK.ExportSolidity(solFile)
 	logger.Info("export solidity to %s", filePath)
+	return nil
 }
 
-func (p *PlonkWrapper) ProofMarshall() (proofStr string) {
+// solidityProof 返回 Solidity 验证合约使用的证明编码，仅支持 BN254
+func (p *PlonkWrapper) solidityProof() ([]byte, error) {
 	if p.Curve != ecc.BN254 {
-		logger.Fatal("only BN254 curve is supported")
+		return nil, fmt.Errorf("%w: only BN254 curve is supported", utils.ErrUnsupportedCurve)
+	}
+	if p.Proof == nil {
+		return nil, fmt.Errorf("%w: proof is nil", utils.ErrInvalidProof)
 	}
-
 	_proof, ok := p.Proof.(interface{ MarshalSolidity() []byte })
 	if !ok {
-		logger.Fatal("marshal proof failed")
+		return nil, fmt.Errorf("proof of type %T cannot be marshaled for solidity", p.Proof)
 	}
-	proofStr = hex.EncodeToString(_proof.MarshalSolidity())
-	return
+	return _proof.MarshalSolidity(), nil
 }
 
-func (p *PlonkWrapper) PublicWitnessMarshall() (publicWitnessStr string) {
+// solidityPublicWitness 返回去掉头部后的公开见证者编码，仅支持 BN254
+func (p *PlonkWrapper) solidityPublicWitness() ([]byte, error) {
 	if p.Curve != ecc.BN254 {
-		logger.Fatal("only BN254 curve is supported")
+		return nil, fmt.Errorf("%w: only BN254 curve is supported", utils.ErrUnsupportedCurve)
+	}
+	if p.WitnessPublic == nil {
+		if err := p.GenerateWitness(true); err != nil {
+			return nil, err
+		}
 	}
 	bPublicWitness, err := p.WitnessPublic.MarshalBinary()
 	if err != nil {
-		logger.Fatal("marshal public witness failed: %v", err)
+		return nil, fmt.Errorf("marshal public witness failed: %w", err)
 	}
-	bPublicWitness = bPublicWitness[12:]
-	publicWitnessStr = hex.EncodeToString(bPublicWitness)
-	return
+	return bPublicWitness[12:], nil
 }
 
-func (p *PlonkWrapper) GetPublicInputNum() string {
-	if p.Curve != ecc.BN254 {
-		logger.Fatal("only BN254 curve is supported")
+func (p *PlonkWrapper) ProofMarshall() (string, error) {
+	proofBytes, err := p.solidityProof()
+	if err != nil {
+		return "", err
 	}
-	return strconv.Itoa(len(p.WitnessPublic.Vector().(fr_bn254.Vector)))
+	return hex.EncodeToString(proofBytes), nil
 }
 
-func (p *PlonkWrapper) GenSolProofParams() (proofStr string) {
-	if p.Curve != ecc.BN254 {
-		logger.Fatal("only BN254 curve is supported")
+func (p *PlonkWrapper) PublicWitnessMarshall() (string, error) {
+	bPublicWitness, err := p.solidityPublicWitness()
+	if err != nil {
+		return "", err
 	}
+	return hex.EncodeToString(bPublicWitness), nil
+}
 
-	_proof, ok := p.Proof.(interface{ MarshalSolidity() []byte })
-	if !ok {
-		logger.Fatal("marshal proof failed")
+func (p *PlonkWrapper) GetPublicInputNum() (string, error) {
+	bPublicWitness, err := p.solidityPublicWitness()
+	if err != nil {
+		return "", err
 	}
-	proofStr = hex.EncodeToString(_proof.MarshalSolidity())
-	proofStr = "0x" + proofStr
-	inputStr := p.GenSolInputParams()
-	proofStr += "," + inputStr
-	return
+	return strconv.Itoa(len(bPublicWitness) / fr_bn254.Bytes), nil
 }
 
-func (p *PlonkWrapper) GenSolInputParams() (inputStr string) {
-	if p.Curve != ecc.BN254 {
-		logger.Fatal("only BN254 curve is supported")
+func (p *PlonkWrapper) GenSolProofParams() (string, error) {
+	proofBytes, err := p.solidityProof()
+	if err != nil {
+		return "", err
 	}
-	bPublicWitness, err := p.WitnessPublic.MarshalBinary()
+	inputStr, err := p.GenSolInputParams()
+	if err != nil {
+		return "", err
+	}
+	return "0x" + hex.EncodeToString(proofBytes) + "," + inputStr, nil
+}
+
+func (p *PlonkWrapper) GenSolInputParams() (string, error) {
+	bPublicWitness, err := p.solidityPublicWitness()
 	if err != nil {
-		logger.Fatal("marshal public witness failed: %v", err)
+		return "", err
 	}
-	bPublicWitness = bPublicWitness[12:]
-	nbInputs := len(bPublicWitness) / 32
+	nbInputs := len(bPublicWitness) / fr_bn254.Bytes
 	input := make([]*big.Int, nbInputs)
 	for i := range input {
 		var e fr_bn254.Element
@@ -100,41 +125,39 @@ func (p *PlonkWrapper) GenSolInputParams() (inputStr string) {
 		input[i] = new(big.Int)
 		e.BigInt(input[i])
 	}
-	inputStr = "["
+	inputStr := "["
 	for i := range input {
 		inputStr += input[i].String() + ","
 	}
-	inputStr = inputStr[:len(inputStr)-1]
+	inputStr = strings.TrimSuffix(inputStr, ",")
 	inputStr += "]"
-	return
+	return inputStr, nil
 }
 
 // 编译和ABI生成
 // groth16和plonk在这部分除solidity路径外，没有任何不同，但为了方便后续调用，分别在两个文件中添加了此函数
-func (p *PlonkWrapper) SolCompileAndABIgen(solPath string) {
+func (p *PlonkWrapper) SolCompileAndABIgen(solPath string) error {
+	dir, err := p.solWorkDir()
+	if err != nil {
+		return err
+	}
 	if solPath == "" {
-		logger.Info("solPath is empty, use default path: output/PlonkVerifier.sol")
-		solPath = "output/PlonkVerifier.sol"
-	}
-	compileCmd := exec.Command("solc", "--evm-version", "paris", "--combined-json", "abi,bin", solPath, "-o", "output", "--overwrite")
-	logger.Debug("compile command: %v", compileCmd.String())
-	if out, err := compileCmd.CombinedOutput(); err != nil {
-		logger.Error("failed to compile: %s", err.Error())
-		logger.Error("output : %s", string(out))
-	} else {
-		logger.Debug("compile success: %s", string(out))
-	}
-	abiGenCmd := exec.Command("abigen", "--combined-json", filepath.Join("output", "combined.json"), "--pkg", "main", "--out", filepath.Join("output", "gnark_solidity.go"))
-	logger.Debug("abiGen command: %v", abiGenCmd.String())
-	if out, err := abiGenCmd.CombinedOutput(); err != nil {
-		logger.Error("failed to generate abi: %s", err.Error())
-		logger.Error("output : %s", string(out))
-	} else {
-		logger.Debug("abiGen success: %s", string(out))
+		logger.Info("solPath is empty, use default path: PlonkVerifier.sol")
+		solPath = "PlonkVerifier.sol"
+	}
+	solPath = p.GetStore().(*store.FS).Path(solPath)
+	compileCmd := exec.Command("solc", "--evm-version", "paris", "--combined-json", "abi,bin", solPath, "-o", dir, "--overwrite")
+	if err := utils.RunCommand(compileCmd); err != nil {
+		return fmt.Errorf("failed to compile: %w", err)
 	}
+	abiGenCmd := exec.Command("abigen", "--combined-json", filepath.Join(dir, "combined.json"), "--pkg", "main", "--out", filepath.Join(dir, "gnark_solidity.go"))
+	if err := utils.RunCommand(abiGenCmd); err != nil {
+		return fmt.Errorf("failed to generate abi: %w", err)
+	}
+	return nil
 }
 
-func (p *PlonkWrapper) SolGenMain() {
+func (p *PlonkWrapper) SolGenMain() error {
 	helpers := template.FuncMap{
 		"mul": func(a, b int) int {
 			return a * b
@@ -142,10 +165,16 @@ func (p *PlonkWrapper) SolGenMain() {
 	}
 	tmpl, err := template.New("").Funcs(helpers).Parse(PlonkTemplate)
 	if err != nil {
-		logger.Error("failed to parse template: %s", err.Error())
+		return fmt.Errorf("failed to parse template: %w", err)
+	}
+	prootStr, err := p.ProofMarshall()
+	if err != nil {
+		return err
+	}
+	bPublicWitness, err := p.solidityPublicWitness()
+	if err != nil {
+		return err
 	}
-	prootStr := p.ProofMarshall()
-	inputStr := p.PublicWitnessMarshall()
 
 	data := struct {
 		Proof          string
@@ -154,48 +183,66 @@ func (p *PlonkWrapper) SolGenMain() {
 		NbCommitments  int
 	}{
 		Proof:          prootStr,
-		PublicInputs:   inputStr,
-		NbPublicInputs: len(p.WitnessPublic.Vector().(fr_bn254.Vector)),
+		PublicInputs:   hex.EncodeToString(bPublicWitness),
+		NbPublicInputs: len(bPublicWitness) / fr_bn254.Bytes,
 		// 暂时不懂怎么获取commitments的数量，所以先设置为0
 		NbCommitments: 0,
 	}
-	file, err := os.Create("output/main.go")
+	return writeTemplate(p.GetStore(), "main.go", tmpl, data)
+}
+
+func (p *PlonkWrapper) SolGenGoMod() error {
+	tmpl, err := template.New("").Parse(GoModTemplate)
 	if err != nil {
-		logger.Error("failed to create file: %s", err.Error())
+		return fmt.Errorf("failed to parse template: %w", err)
 	}
-	defer file.Close()
-	tmpl.Execute(file, data)
+	return writeTemplate(p.GetStore(), "go.mod", tmpl, nil)
 }
 
-func (p *PlonkWrapper) SolGenGoMod() {
-	file, err := os.Create("output/go.mod")
+// writeTemplate 将模板渲染结果写入存储中的 name
+func writeTemplate(s store.ArtifactStore, name string, tmpl *template.Template, data any) error {
+	file, err := s.Create(name)
 	if err != nil {
-		logger.Error("failed to create file: %s", err.Error())
+		return &utils.IOError{Op: "create file", Path: name, Err: err}
 	}
-	defer file.Close()
-	tmpl, err := template.New("").Parse(GoModTemplate)
-	if err != nil {
-		logger.Error("failed to parse template: %s", err.Error())
+	if err := tmpl.Execute(file, data); err != nil {
+		file.Close()
+		return &utils.IOError{Op: "execute template", Path: name, Err: err}
+	}
+	if err := file.Close(); err != nil {
+		return &utils.IOError{Op: "close file", Path: name, Err: err}
 	}
-	tmpl.Execute(file, nil)
+	return nil
 }
 
-func (p *PlonkWrapper) SolVerify() {
-	cmdGoModTidy := exec.Command("sh", "-c", "cd output && go mod tidy")
-	logger.Info("running go mod tidy: %s", cmdGoModTidy.String())
-	if out, err := cmdGoModTidy.CombinedOutput(); err != nil {
-		logger.Error("failed to run go mod tidy: %s", err.Error())
-		logger.Error("output : %s", string(out))
-	} else {
-		logger.Info("go mod tidy success: %s", string(out))
+func (p *PlonkWrapper) SolVerify() error {
+	dir, err := p.solWorkDir()
+	if err != nil {
+		return err
+	}
+	cmdGoModTidy := exec.Command("go", "mod", "tidy")
+	cmdGoModTidy.Dir = dir
+	if err := utils.RunCommand(cmdGoModTidy); err != nil {
+		return fmt.Errorf("failed to run go mod tidy: %w", err)
+	}
+
+	cmdGoRun := exec.Command("go", "run", "main.go", "gnark_solidity.go")
+	cmdGoRun.Dir = dir
+	if err := utils.RunCommand(cmdGoRun); err != nil {
+		return fmt.Errorf("%w: solidity verifier rejected the proof: %w", utils.ErrInvalidProof, err)
 	}
+	logger.Info("solidity verifier accepted the proof")
+	return nil
+}
 
-	cmdGoRun := exec.Command("sh", "-c", "cd output && go run main.go gnark_solidity.go")
-	logger.Info("running go run main.go gnark_solidity.go: %s", cmdGoRun.String())
-	if out, err := cmdGoRun.CombinedOutput(); err != nil {
-		logger.Error("failed to run go run main.go gnark_solidity.go: %s", err.Error())
-		logger.Error("output : %s", string(out))
-	} else {
-		logger.Info("go run main.go gnark_solidity.go success: %s", string(out))
+// solWorkDir 返回 solc、abigen 和 go run 使用的目录，这些外部工具要求存储位于文件系统上
+func (p *PlonkWrapper) solWorkDir() (string, error) {
+	fsStore, ok := p.GetStore().(*store.FS)
+	if !ok {
+		return "", fmt.Errorf("solidity toolchain requires a filesystem store, got %T", p.GetStore())
+	}
+	if fsStore.Root == "" {
+		return ".", nil
 	}
+	return fsStore.Root, nil
 }
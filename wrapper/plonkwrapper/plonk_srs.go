@@ -0,0 +1,265 @@
+package plonkwrapper
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/oliverustc/gnarkabc/logger"
+	"github.com/oliverustc/gnarkabc/utils"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377"
+	kzg_bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377/kzg"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	kzg_bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381/kzg"
+	bls24315 "github.com/consensys/gnark-crypto/ecc/bls24-315"
+	kzg_bls24315 "github.com/consensys/gnark-crypto/ecc/bls24-315/kzg"
+	bls24317 "github.com/consensys/gnark-crypto/ecc/bls24-317"
+	kzg_bls24317 "github.com/consensys/gnark-crypto/ecc/bls24-317/kzg"
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	kzg_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/kzg"
+	bw6633 "github.com/consensys/gnark-crypto/ecc/bw6-633"
+	kzg_bw6633 "github.com/consensys/gnark-crypto/ecc/bw6-633/kzg"
+	bw6761 "github.com/consensys/gnark-crypto/ecc/bw6-761"
+	kzg_bw6761 "github.com/consensys/gnark-crypto/ecc/bw6-761/kzg"
+	"github.com/consensys/gnark-crypto/kzg"
+	"github.com/consensys/gnark/constraint"
+)
+
+// LoadSRS 从文件加载 gnark-crypto kzg.SRS 二进制格式的规范形式 SRS，
+// Setup 时会按电路规模截取并自动推导拉格朗日形式
+func (p *PlonkWrapper) LoadSRS(filePath string) error {
+	srs, err := ReadSRS(filePath, p.Curve)
+	if err != nil {
+		return err
+	}
+	p.SRS = srs
+	return nil
+}
+
+// LoadSRSTranscript 从 gnarkabc 格式的 Powers-of-Tau 仪式记录加载 SRS，
+// 逐个校验贡献后使用 beacon 封装得到最终 SRS。记录格式见 ReadSRSTranscript，不能直接读取 snarkjs 的 .ptau 文件
+func (p *PlonkWrapper) LoadSRSTranscript(filePath string, beacon []byte) error {
+	srs, err := ReadSRSTranscript(filePath, p.Curve, beacon)
+	if err != nil {
+		return err
+	}
+	p.SRS = srs
+	return nil
+}
+
+// newSRS 返回指定曲线的空 SRS
+func newSRS(curve ecc.ID) (kzg.SRS, error) {
+	if _, ok := srsCurves[curve]; ok {
+		return kzg.NewSRS(curve), nil
+	}
+	return nil, fmt.Errorf("%w: %s", utils.ErrUnsupportedCurve, curve.String())
+}
+
+// ReadSRS 读取 gnark-crypto kzg.SRS 二进制格式（SRS.WriteTo 的输出）的 SRS，读取时会做子群检查
+func ReadSRS(filePath string, curve ecc.ID) (kzg.SRS, error) {
+	srs, err := newSRS(curve)
+	if err != nil {
+		return nil, err
+	}
+	logger.Debug("Reading srs from %s", filePath)
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, &utils.IOError{Op: "open srs file", Path: filePath, Err: err}
+	}
+	defer file.Close()
+	size, err := srs.ReadFrom(bufio.NewReader(file))
+	if err != nil {
+		return nil, &utils.IOError{Op: "read srs", Path: filePath, Err: err}
+	}
+	logger.Debug("read srs from %s, size= %d", filePath, size)
+	return srs, nil
+}
+
+// WriteSRS 以 gnark-crypto kzg.SRS 二进制格式写出 SRS
+func WriteSRS(filePath string, srs kzg.SRS) error {
+	if srs == nil {
+		return fmt.Errorf("%w: srs is nil", utils.ErrMissingSetup)
+	}
+	file, err := os.Create(filePath)
+	if err != nil {
+		return &utils.IOError{Op: "create srs file", Path: filePath, Err: err}
+	}
+	defer file.Close()
+	size, err := srs.WriteTo(file)
+	if err != nil {
+		return &utils.IOError{Op: "write srs", Path: filePath, Err: err}
+	}
+	logger.Debug("write srs to %s, size= %d", filePath, size)
+	return nil
+}
+
+// ReadSRSTranscript 读取 Powers-of-Tau 仪式记录并封装为 SRS。
+// 记录是 gnarkabc 自定义的格式：大端 uint64 表示的 SRS 规模 N，随后按顺序拼接的 gnark-crypto kzg.MpcSetup 贡献（MpcSetup.WriteTo 的输出）。
+// 第一个贡献基于 InitializeSetup(N) 校验，之后每个贡献基于前一个校验，最后以 beacon 封装。
+// 该格式与 snarkjs 的 .ptau 文件及 Perpetual Powers of Tau 的 challenge/response 文件均不兼容，
+// 这些仪式的结果需要先转换为 kzg.SRS，再用 ReadSRS 读取
+func ReadSRSTranscript(filePath string, curve ecc.ID, beacon []byte) (kzg.SRS, error) {
+	c, ok := srsCurves[curve]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", utils.ErrUnsupportedCurve, curve.String())
+	}
+	logger.Debug("Reading srs transcript from %s", filePath)
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, &utils.IOError{Op: "open srs transcript", Path: filePath, Err: err}
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, &utils.IOError{Op: "stat srs transcript", Path: filePath, Err: err}
+	}
+	r := bufio.NewReader(file)
+
+	var n uint64
+	if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+		return nil, &utils.IOError{Op: "read srs transcript header", Path: filePath, Err: err}
+	}
+	if n < 2 {
+		return nil, fmt.Errorf("%w: transcript declares %d points", utils.ErrSRSTooSmall, n)
+	}
+	// 每个贡献至少包含 N-1 个压缩 G1 点，据此用文件大小限制 N，避免损坏的记录头导致分配过大内存
+	if maxPoints := uint64(info.Size()-8)/uint64(c.g1Size) + 1; n > maxPoints {
+		return nil, &utils.IOError{Op: "read srs transcript header", Path: filePath,
+			Err: fmt.Errorf("transcript declares %d points but can hold at most %d", n, maxPoints)}
+	}
+
+	srs, err := c.seal(r, int(n), beacon)
+	if err != nil {
+		return nil, &utils.IOError{Op: "read srs transcript", Path: filePath, Err: err}
+	}
+	logger.Debug("read srs transcript from %s, size= %d", filePath, n)
+	return srs, nil
+}
+
+// srsCurve 汇总一条曲线上读取仪式记录和截取 SRS 所需的操作，由 newSRSCurve 生成
+type srsCurve struct {
+	g1Size int // 压缩 G1 点的字节数
+	seal   func(r *bufio.Reader, n int, beacon []byte) (kzg.SRS, error)
+	derive func(srs kzg.SRS, sizeLagrange int) (kzg.SRS, kzg.SRS, error)
+}
+
+var srsCurves = map[ecc.ID]srsCurve{
+	ecc.BN254: newSRSCurve(bn254.SizeOfG1AffineCompressed, kzg_bn254.InitializeSetup, kzg_bn254.ToLagrangeG1,
+		func(s *kzg_bn254.SRS) (*[]bn254.G1Affine, *bn254.G1Affine) { return &s.Pk.G1, &s.Vk.G1 }),
+	ecc.BLS12_377: newSRSCurve(bls12377.SizeOfG1AffineCompressed, kzg_bls12377.InitializeSetup, kzg_bls12377.ToLagrangeG1,
+		func(s *kzg_bls12377.SRS) (*[]bls12377.G1Affine, *bls12377.G1Affine) { return &s.Pk.G1, &s.Vk.G1 }),
+	ecc.BLS12_381: newSRSCurve(bls12381.SizeOfG1AffineCompressed, kzg_bls12381.InitializeSetup, kzg_bls12381.ToLagrangeG1,
+		func(s *kzg_bls12381.SRS) (*[]bls12381.G1Affine, *bls12381.G1Affine) { return &s.Pk.G1, &s.Vk.G1 }),
+	ecc.BW6_761: newSRSCurve(bw6761.SizeOfG1AffineCompressed, kzg_bw6761.InitializeSetup, kzg_bw6761.ToLagrangeG1,
+		func(s *kzg_bw6761.SRS) (*[]bw6761.G1Affine, *bw6761.G1Affine) { return &s.Pk.G1, &s.Vk.G1 }),
+	ecc.BLS24_315: newSRSCurve(bls24315.SizeOfG1AffineCompressed, kzg_bls24315.InitializeSetup, kzg_bls24315.ToLagrangeG1,
+		func(s *kzg_bls24315.SRS) (*[]bls24315.G1Affine, *bls24315.G1Affine) { return &s.Pk.G1, &s.Vk.G1 }),
+	ecc.BW6_633: newSRSCurve(bw6633.SizeOfG1AffineCompressed, kzg_bw6633.InitializeSetup, kzg_bw6633.ToLagrangeG1,
+		func(s *kzg_bw6633.SRS) (*[]bw6633.G1Affine, *bw6633.G1Affine) { return &s.Pk.G1, &s.Vk.G1 }),
+	ecc.BLS24_317: newSRSCurve(bls24317.SizeOfG1AffineCompressed, kzg_bls24317.InitializeSetup, kzg_bls24317.ToLagrangeG1,
+		func(s *kzg_bls24317.SRS) (*[]bls24317.G1Affine, *bls24317.G1Affine) { return &s.Pk.G1, &s.Vk.G1 }),
+}
+
+// newSRSCurve 基于各曲线的 InitializeSetup、ToLagrangeG1 和 SRS 字段访问函数生成 srsCurve，
+// points 返回规范形式 G1 点和验证密钥中 G1 生成元的地址
+func newSRSCurve[T, S, G any, PT mpcContribution[T, S], PS interface {
+	*S
+	kzg.SRS
+}](g1Size int, initialize func(int) T, toLagrange func([]G) ([]G, error), points func(*S) (*[]G, *G)) srsCurve {
+	return srsCurve{
+		g1Size: g1Size,
+		seal: func(r *bufio.Reader, n int, beacon []byte) (kzg.SRS, error) {
+			s, err := sealTranscript[T, S, PT](r, initialize(n), beacon)
+			if err != nil {
+				return nil, err
+			}
+			// MpcSetup.ReadFrom 不恢复 Vk.G1，封装后补齐为 G1 生成元
+			g1, vkG1 := points(&s)
+			*vkG1 = (*g1)[0]
+			return PS(&s), nil
+		},
+		derive: func(srs kzg.SRS, sizeLagrange int) (kzg.SRS, kzg.SRS, error) {
+			s, ok := srs.(PS)
+			if !ok {
+				return nil, nil, fmt.Errorf("%w: srs type %T", utils.ErrUnsupportedCurve, srs)
+			}
+			g1, _ := points(s)
+			canonical, lagrange, err := splitSRS(*g1, sizeLagrange, toLagrange)
+			if err != nil {
+				return nil, nil, err
+			}
+			// 复制后只替换 G1 点，验证密钥保持不变
+			c, l := *s, *s
+			g1, _ = points(&c)
+			*g1 = canonical
+			g1, _ = points(&l)
+			*g1 = lagrange
+			return PS(&c), PS(&l), nil
+		},
+	}
+}
+
+// mpcContribution 约束各曲线的 kzg.MpcSetup
+type mpcContribution[T, S any] interface {
+	*T
+	io.ReaderFrom
+	Verify(next *T) error
+	Seal(beaconChallenge []byte) S
+}
+
+// sealTranscript 依次读取并校验贡献，直到读完记录
+func sealTranscript[T, S any, PT mpcContribution[T, S]](r *bufio.Reader, initial T, beacon []byte) (S, error) {
+	prev := initial
+	var nbContributions int
+	for {
+		if _, err := r.Peek(1); errors.Is(err, io.EOF) {
+			break
+		}
+		var next T
+		if _, err := PT(&next).ReadFrom(r); err != nil {
+			var s S
+			return s, fmt.Errorf("read contribution %d: %w", nbContributions, err)
+		}
+		if err := PT(&prev).Verify(&next); err != nil {
+			var s S
+			return s, fmt.Errorf("verify contribution %d: %w", nbContributions, err)
+		}
+		prev = next
+		nbContributions++
+	}
+	if nbContributions == 0 {
+		var s S
+		return s, errors.New("transcript contains no contribution")
+	}
+	logger.Debug("verified %d srs contributions", nbContributions)
+	return PT(&prev).Seal(beacon), nil
+}
+
+// deriveSRS 按约束系统规模截取规范形式 SRS 并推导拉格朗日形式
+func deriveSRS(srs kzg.SRS, curve ecc.ID, ccs constraint.ConstraintSystem) (kzg.SRS, kzg.SRS, error) {
+	c, ok := srsCurves[curve]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %s", utils.ErrUnsupportedCurve, curve.String())
+	}
+	sizeSystem := ccs.GetNbConstraints() + ccs.GetNbPublicVariables()
+	return c.derive(srs, int(ecc.NextPowerOfTwo(uint64(sizeSystem))))
+}
+
+// splitSRS 截取规范形式所需的 sizeLagrange+3 个点（+3 用于盲化多项式的 kzg.Open），
+// 并对前 sizeLagrange 个点做逆 FFT 得到拉格朗日形式
+func splitSRS[G any](g1 []G, sizeLagrange int, toLagrange func([]G) ([]G, error)) ([]G, []G, error) {
+	sizeCanonical := sizeLagrange + 3
+	if len(g1) < sizeCanonical {
+		return nil, nil, fmt.Errorf("%w: got %d points, need %d", utils.ErrSRSTooSmall, len(g1), sizeCanonical)
+	}
+	lagrange, err := toLagrange(g1[:sizeLagrange])
+	if err != nil {
+		return nil, nil, fmt.Errorf("derive lagrange srs failed: %w", err)
+	}
+	return g1[:sizeCanonical], lagrange, nil
+}
@@ -0,0 +1,160 @@
+package plonkwrapper
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/oliverustc/gnarkabc/circuits"
+	"github.com/oliverustc/gnarkabc/logger"
+	"github.com/oliverustc/gnarkabc/utils"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	kzg_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/kzg"
+)
+
+// productSRSZKP 使用已加载的 SRS 完成乘积电路的设置、证明和验证
+func productSRSZKP(t *testing.T, p *PlonkWrapper, circuit *circuits.Product) {
+	t.Helper()
+	if err := p.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	if err := circuit.Assign(circuits.ProductAssign{P: 13, Q: 17}); err != nil {
+		t.Fatal(err)
+	}
+	p.SetAssignment(circuit)
+	if err := p.Prove(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Verify(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPlonkLoadSRS(t *testing.T) {
+	dir := t.TempDir()
+	var circuit circuits.Product
+	circuit.PreCompile(circuits.NoParams{})
+	for _, curveName := range utils.CurveNameList {
+		curve := utils.CurveMap[curveName]
+		p := NewWrapper(&circuit, curve)
+		if err := p.Compile(); err != nil {
+			t.Fatal(err)
+		}
+		// 生成一个比电路所需更大的 SRS，加载后应按电路规模截取
+		srs, _, err := p.createSRS(p.CCS)
+		if err != nil {
+			t.Fatal(err)
+		}
+		srsPath := filepath.Join(dir, "srs_"+curveName)
+		if err := WriteSRS(srsPath, srs); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.LoadSRS(srsPath); err != nil {
+			t.Fatal(err)
+		}
+		productSRSZKP(t, p, &circuit)
+		logger.Info("plonk with loaded srs on curve [ %s ] success", curveName)
+	}
+}
+
+func TestPlonkSRSTooSmall(t *testing.T) {
+	var circuit circuits.Product
+	circuit.PreCompile(circuits.NoParams{})
+	p := NewWrapper(&circuit, ecc.BN254)
+	if err := p.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	srs, err := kzg_bn254.NewSRS(4, big.NewInt(42))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srsPath := filepath.Join(t.TempDir(), "srs")
+	if err := WriteSRS(srsPath, srs); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.LoadSRS(srsPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Setup(); !errors.Is(err, utils.ErrSRSTooSmall) {
+		t.Fatalf("expected ErrSRSTooSmall, got %v", err)
+	}
+	if err := p.LoadSRS(filepath.Join(t.TempDir(), "missing")); !errors.Is(err, utils.ErrIO) {
+		t.Fatalf("expected ErrIO, got %v", err)
+	}
+}
+
+// writeTranscript 模拟多方仪式，按 ReadSRSTranscript 的格式写出记录
+func writeTranscript(t *testing.T, filePath string, size, nbContributions int) {
+	t.Helper()
+	file, err := os.Create(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if err := binary.Write(file, binary.BigEndian, uint64(size)); err != nil {
+		t.Fatal(err)
+	}
+	setup := kzg_bn254.InitializeSetup(size)
+	for range nbContributions {
+		setup.Contribute()
+		if _, err := setup.WriteTo(file); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestPlonkLoadSRSTranscript(t *testing.T) {
+	dir := t.TempDir()
+	var circuit circuits.Product
+	circuit.PreCompile(circuits.NoParams{})
+	p := NewWrapper(&circuit, ecc.BN254)
+	if err := p.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	sizeSystem := p.CCS.GetNbConstraints() + p.CCS.GetNbPublicVariables()
+	size := int(ecc.NextPowerOfTwo(uint64(sizeSystem))) + 3
+
+	transcriptPath := filepath.Join(dir, "transcript")
+	writeTranscript(t, transcriptPath, size, 3)
+	if err := p.LoadSRSTranscript(transcriptPath, []byte("beacon")); err != nil {
+		t.Fatal(err)
+	}
+	productSRSZKP(t, p, &circuit)
+
+	// 仪式规模不足时 Setup 失败
+	smallPath := filepath.Join(dir, "transcript_small")
+	writeTranscript(t, smallPath, size-1, 1)
+	if err := p.LoadSRSTranscript(smallPath, []byte("beacon")); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Setup(); !errors.Is(err, utils.ErrSRSTooSmall) {
+		t.Fatalf("expected ErrSRSTooSmall, got %v", err)
+	}
+
+	// 篡改贡献后校验失败
+	data, err := os.ReadFile(transcriptPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)/2] ^= 0xff
+	tamperedPath := filepath.Join(dir, "transcript_tampered")
+	if err := os.WriteFile(tamperedPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.LoadSRSTranscript(tamperedPath, []byte("beacon")); err == nil {
+		t.Fatal("expected tampered transcript to be rejected")
+	}
+
+	// 记录头声明的规模超出文件可容纳的范围时直接拒绝，不按该规模分配内存
+	hugePath := filepath.Join(dir, "transcript_huge")
+	if err := os.WriteFile(hugePath, binary.BigEndian.AppendUint64(nil, 1<<62), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.LoadSRSTranscript(hugePath, []byte("beacon")); !errors.Is(err, utils.ErrIO) {
+		t.Fatalf("expected ErrIO for oversized header, got %v", err)
+	}
+}
@@ -1 +1,124 @@
 package plonkwrapper
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/oliverustc/gnarkabc/circuits"
+	"github.com/oliverustc/gnarkabc/utils"
+	"github.com/oliverustc/gnarkabc/wrapper/diagnose"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	recursion_plonk "github.com/consensys/gnark/std/recursion/plonk"
+)
+
+// onePublic 只有一个公开变量，用于构造与 Product 不匹配的见证者
+type onePublic struct {
+	A frontend.Variable `gnark:",public"`
+}
+
+func (c *onePublic) Define(api frontend.API) error {
+	api.AssertIsEqual(c.A, c.A)
+	return nil
+}
+
+func TestPlonkErrors(t *testing.T) {
+	var circuit circuits.Product
+	circuit.PreCompile(circuits.NoParams{})
+	zk := NewWrapper(&circuit, ecc.BN254)
+	if err := zk.Setup(); !errors.Is(err, utils.ErrMissingSetup) {
+		t.Fatalf("setup before compile: expected ErrMissingSetup, got %v", err)
+	}
+	if err := zk.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	if err := zk.Prove(); !errors.Is(err, utils.ErrMissingSetup) {
+		t.Fatalf("prove before setup: expected ErrMissingSetup, got %v", err)
+	}
+	if err := zk.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	if err := zk.Prove(); !errors.Is(err, utils.ErrMissingAssignment) {
+		t.Fatalf("prove without assignment: expected ErrMissingAssignment, got %v", err)
+	}
+
+	// N != P * Q
+	zk.SetAssignment(&circuits.Product{P: 3, Q: 4, N: 13})
+	if err := zk.Prove(); !errors.Is(err, utils.ErrUnsatisfiedConstraint) {
+		t.Fatalf("prove with wrong assignment: expected ErrUnsatisfiedConstraint, got %v", err)
+	}
+	// 见证者与约束系统不匹配属于输入错误，不应报告为约束不满足
+	w, err := frontend.NewWitness(&onePublic{A: 1}, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	zk.WitnessFull = w
+	if err := zk.Prove(); err == nil || errors.Is(err, utils.ErrUnsatisfiedConstraint) {
+		t.Fatalf("prove with mismatched witness: expected a plain error, got %v", err)
+	}
+
+	zk.SetAssignment(&circuits.Product{P: 3, Q: 4, N: 12})
+	if err := zk.Prove(); err != nil {
+		t.Fatal(err)
+	}
+	// 公开输入与证明不匹配
+	zk.SetAssignment(&circuits.Product{P: 3, Q: 4, N: 13})
+	if err := zk.Verify(); !errors.Is(err, utils.ErrInvalidProof) {
+		t.Fatalf("verify with wrong public input: expected ErrInvalidProof, got %v", err)
+	}
+
+	if err := zk.ReadProof("not_exist_proof"); !errors.Is(err, utils.ErrIO) {
+		t.Fatalf("read missing file: expected ErrIO, got %v", err)
+	}
+}
+
+// 设置 RecursionTarget 后以外层曲线的哈希计算挑战，只有对应的验证者选项能验证
+func TestPlonkTargetOptions(t *testing.T) {
+	var circuit circuits.Product
+	zk := NewWrapper(&circuit, ecc.BW6_761)
+	if err := zk.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	if err := zk.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	circuit.Assign(circuits.ProductAssign{P: 13, Q: 17})
+	zk.SetAssignment(&circuit)
+	zk.SetRecursionTarget(ecc.BN254)
+	if err := zk.Prove(); err != nil {
+		t.Fatal(err)
+	}
+	if err := zk.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	if err := zk.Verify(recursion_plonk.GetNativeVerifierOptions(ecc.BN254.ScalarField(), ecc.BW6_761.ScalarField())); err != nil {
+		t.Fatal(err)
+	}
+	zk.SetRecursionTarget(ecc.UNKNOWN)
+	if err := zk.Verify(); !errors.Is(err, utils.ErrInvalidProof) {
+		t.Fatalf("verify without recursion target: expected ErrInvalidProof, got %v", err)
+	}
+}
+
+// CheckAssignment 不需要编译和设置，失败时指出断言所在的源码行和涉及的字段
+func TestPlonkCheckAssignment(t *testing.T) {
+	var circuit circuits.Product
+	zk := NewWrapper(&circuit, ecc.BLS12_381)
+	if err := zk.CheckAssignment(); !errors.Is(err, utils.ErrMissingAssignment) {
+		t.Fatalf("check without assignment: expected ErrMissingAssignment, got %v", err)
+	}
+	zk.SetAssignment(&circuits.Product{P: 3, Q: 4, N: 12})
+	if err := zk.CheckAssignment(); err != nil {
+		t.Fatal(err)
+	}
+	zk.SetAssignment(&circuits.Product{P: 3, Q: 4, N: 13})
+	err := zk.CheckAssignment()
+	var failure *diagnose.Failure
+	if !errors.Is(err, utils.ErrUnsatisfiedConstraint) || !errors.As(err, &failure) {
+		t.Fatalf("check with wrong assignment: expected *diagnose.Failure, got %v", err)
+	}
+	if failure.Source != "api.AssertIsEqual(tc.N, api.Mul(tc.P, tc.Q))" || len(failure.Involved) != 3 {
+		t.Fatalf("unexpected failure %v", failure)
+	}
+}
@@ -0,0 +1,122 @@
+package wrapper
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/oliverustc/gnarkabc/utils"
+	"github.com/oliverustc/gnarkabc/wrapper/groth16wrapper"
+	"github.com/oliverustc/gnarkabc/wrapper/plonkwrapper"
+	"github.com/oliverustc/gnarkabc/wrapper/store"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+)
+
+// 支持的证明系统名称
+const (
+	SchemeGroth16 = "groth16"
+	SchemePlonk   = "plonk"
+)
+
+var SchemeList = []string{SchemeGroth16, SchemePlonk}
+
+// ProofSystem 是 Groth16Wrapper 和 PlonkWrapper 的公共接口，
+// 便于基准测试、命令行工具等按名称选择证明系统
+type ProofSystem interface {
+	Scheme() string
+	CurveID() ecc.ID
+
+	Compile() error
+	Setup() error
+	SetAssignment(assignment frontend.Circuit)
+	CheckAssignment() error
+	SetRecursionTarget(outer ecc.ID)
+	GenerateWitness(public bool) error
+	Prove(opts ...backend.ProverOption) error
+	Verify(opts ...backend.VerifierOption) error
+	GetConstraintNum() int
+	GetWitnessJson(public bool) ([]byte, error)
+
+	BenchmarkCompile(iterations int) (time.Duration, error)
+	BenchmarkSetup(iterations int) (time.Duration, error)
+	BenchmarkProve(iterations int) (time.Duration, error)
+	BenchmarkVerify(iterations int) (time.Duration, error)
+
+	SetStore(s store.ArtifactStore)
+	GetStore() store.ArtifactStore
+	WriteCCS(filePath string) error
+	ReadCCS(filePath string) error
+	WritePK(filePath string) error
+	ReadPK(filePath string) error
+	WriteVK(filePath string) error
+	ReadVK(filePath string) error
+	WriteWitness(filePath string, public bool) error
+	ReadWitness(filePath string, public bool) error
+	WriteProof(filePath string) error
+	ReadProof(filePath string) error
+
+	MarshalCCS() ([]byte, error)
+	UnmarshalCCS(data []byte) error
+	MarshalPK() ([]byte, error)
+	UnmarshalPK(data []byte) error
+	MarshalVK() ([]byte, error)
+	UnmarshalVK(data []byte) error
+	MarshalWitness(public bool) ([]byte, error)
+	UnmarshalWitness(data []byte, public bool) error
+	MarshalProof() ([]byte, error)
+	UnmarshalProof(data []byte) error
+
+	ExportSolidity(filePath string) error
+}
+
+var (
+	_ ProofSystem = (*groth16wrapper.Groth16Wrapper)(nil)
+	_ ProofSystem = (*plonkwrapper.PlonkWrapper)(nil)
+)
+
+// New 根据证明系统名称和曲线名称创建包装器
+func New(scheme string, curveName string, circuit frontend.Circuit) (ProofSystem, error) {
+	curve, ok := utils.CurveMap[curveName]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", utils.ErrUnsupportedCurve, curveName)
+	}
+	switch scheme {
+	case SchemeGroth16:
+		return groth16wrapper.NewWrapper(circuit, curve), nil
+	case SchemePlonk:
+		return plonkwrapper.NewWrapper(circuit, curve), nil
+	default:
+		return nil, fmt.Errorf("unknown scheme: %s", scheme)
+	}
+}
+
+// ZKP 按名称选择证明系统，依次完成编译、设置、证明和验证
+func ZKP[C, A any](scheme string, cw CircuitWrapper[C, A], curveName string, compileParams C, assignParams A) (ProofSystem, error) {
+	ps, err := New(scheme, curveName, cw)
+	if err != nil {
+		return nil, err
+	}
+	return ps, runZKP(ps, cw, compileParams, assignParams)
+}
+
+func runZKP[C, A any](ps ProofSystem, cw CircuitWrapper[C, A], compileParams C, assignParams A) error {
+	if err := cw.PreCompile(compileParams); err != nil {
+		return err
+	}
+	if err := ps.Compile(); err != nil {
+		return err
+	}
+	if err := ps.Setup(); err != nil {
+		return err
+	}
+	if err := cw.Assign(assignParams); err != nil {
+		return err
+	}
+	ps.SetAssignment(cw)
+	if err := ps.Prove(); err != nil {
+		return err
+	}
+	return ps.Verify()
+}
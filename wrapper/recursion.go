@@ -0,0 +1,193 @@
+package wrapper
+
+import (
+	"fmt"
+
+	"github.com/oliverustc/gnarkabc/utils"
+	"github.com/oliverustc/gnarkabc/wrapper/groth16wrapper"
+	"github.com/oliverustc/gnarkabc/wrapper/plonkwrapper"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_bls12381"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_bn254"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_bw6761"
+	"github.com/consensys/gnark/std/algebra/native/sw_bls12377"
+	"github.com/consensys/gnark/std/algebra/native/sw_bls24315"
+	"github.com/consensys/gnark/std/math/emulated"
+)
+
+// Recursion 是验证内层证明的外层电路及其包装器，由 NewRecursion 创建。
+// 内嵌的 ProofSystem 即外层电路的包装器，依次调用 PreCompile、Compile、Setup、Assign、Prove、Verify 完成递归证明。
+// 内外层的证明系统可以不同，如在 Groth16 外层电路中验证无需逐电路设置的 PLONK 证明，内层证明须以 ProveInner 生成
+type Recursion struct {
+	ProofSystem                  // 外层电路的包装器
+	Circuit     frontend.Circuit // 外层电路，类型由内外层曲线决定
+
+	InnerScheme string
+	InnerCurve  ecc.ID
+
+	preCompile func(inner ProofSystem) error
+	assign     func(inner ProofSystem) error
+}
+
+// recursionGadget 描述一条内层曲线在电路中的验证方式
+type recursionGadget struct {
+	native  ecc.ID // 内层曲线只能在该外层曲线中原生验证，ecc.UNKNOWN 表示使用非原生算术，可在任意外层曲线中验证
+	groth16 func(r *Recursion)
+	plonk   func(r *Recursion)
+}
+
+// recursionGadgets 列出 gnark 递归验证器支持的内层曲线。
+// BLS12-377 和 BLS24-315 分别与 BW6-761、BW6-633 构成 2-chain，其余曲线使用非原生算术
+var recursionGadgets = map[ecc.ID]recursionGadget{
+	ecc.BN254: {
+		groth16: groth16Recursion[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl],
+		plonk:   plonkRecursion[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl],
+	},
+	ecc.BLS12_381: {
+		groth16: groth16Recursion[sw_bls12381.ScalarField, sw_bls12381.G1Affine, sw_bls12381.G2Affine, sw_bls12381.GTEl],
+		plonk:   plonkRecursion[sw_bls12381.ScalarField, sw_bls12381.G1Affine, sw_bls12381.G2Affine, sw_bls12381.GTEl],
+	},
+	ecc.BW6_761: {
+		groth16: groth16Recursion[sw_bw6761.ScalarField, sw_bw6761.G1Affine, sw_bw6761.G2Affine, sw_bw6761.GTEl],
+		plonk:   plonkRecursion[sw_bw6761.ScalarField, sw_bw6761.G1Affine, sw_bw6761.G2Affine, sw_bw6761.GTEl],
+	},
+	ecc.BLS12_377: {
+		native:  ecc.BW6_761,
+		groth16: groth16Recursion[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT],
+		plonk:   plonkRecursion[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT],
+	},
+	ecc.BLS24_315: {
+		native:  ecc.BW6_633,
+		groth16: groth16Recursion[sw_bls24315.ScalarField, sw_bls24315.G1Affine, sw_bls24315.G2Affine, sw_bls24315.GT],
+		plonk:   plonkRecursion[sw_bls24315.ScalarField, sw_bls24315.G1Affine, sw_bls24315.G2Affine, sw_bls24315.GT],
+	},
+}
+
+// NewRecursion 创建在 outerCurve 上以 outerScheme 证明、验证 innerCurve 上 innerScheme 证明的外层电路，
+// 根据曲线对选择原生或非原生的验证器类型，不支持的组合返回 utils.ErrUnsupportedCurve。
+// 内层验证密钥作为常量编译进外层电路，内层见证者的可见性与 groth16wrapper、plonkwrapper 中的外层电路一致
+func NewRecursion(innerScheme, innerCurveName, outerScheme, outerCurveName string) (*Recursion, error) {
+	innerCurve, ok := utils.CurveMap[innerCurveName]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", utils.ErrUnsupportedCurve, innerCurveName)
+	}
+	outerCurve, ok := utils.CurveMap[outerCurveName]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", utils.ErrUnsupportedCurve, outerCurveName)
+	}
+	gadget, ok := recursionGadgets[innerCurve]
+	if !ok {
+		return nil, fmt.Errorf("%w: recursive verification of %s proofs is not supported", utils.ErrUnsupportedCurve, innerCurveName)
+	}
+	if gadget.native != ecc.UNKNOWN && gadget.native != outerCurve {
+		return nil, fmt.Errorf("%w: %s proofs can only be verified in %s circuits, got %s",
+			utils.ErrUnsupportedCurve, innerCurveName, gadget.native.String(), outerCurveName)
+	}
+
+	r := &Recursion{InnerScheme: innerScheme, InnerCurve: innerCurve}
+	switch innerScheme {
+	case SchemeGroth16:
+		gadget.groth16(r)
+	case SchemePlonk:
+		gadget.plonk(r)
+	default:
+		return nil, fmt.Errorf("unknown scheme: %s", innerScheme)
+	}
+	outer, err := New(outerScheme, outerCurveName, r.Circuit)
+	if err != nil {
+		return nil, err
+	}
+	r.ProofSystem = outer
+	return r, nil
+}
+
+// PreCompile 根据内层包装器的约束系统和验证密钥确定外层电路的形状，之后调用 Compile 编译外层电路
+func (r *Recursion) PreCompile(inner ProofSystem) error {
+	if err := r.checkInner(inner); err != nil {
+		return err
+	}
+	return r.preCompile(inner)
+}
+
+// Assign 使用内层包装器的证明和完整见证者为外层电路赋值，之后调用 Prove 生成递归证明
+func (r *Recursion) Assign(inner ProofSystem) error {
+	if err := r.checkInner(inner); err != nil {
+		return err
+	}
+	if err := r.assign(inner); err != nil {
+		return err
+	}
+	r.SetAssignment(r.Circuit)
+	return nil
+}
+
+// ProveInner 将内层包装器的递归目标设为外层曲线，生成内层证明并在电路外验证，内层包装器须已完成设置和赋值。
+// 之后内层包装器的 Prove 和 Verify 会继续使用递归验证所需的哈希选项
+func (r *Recursion) ProveInner(inner ProofSystem) error {
+	if err := r.checkInner(inner); err != nil {
+		return err
+	}
+	inner.SetRecursionTarget(r.CurveID())
+	if err := inner.Prove(); err != nil {
+		return err
+	}
+	return inner.Verify()
+}
+
+func (r *Recursion) checkInner(inner ProofSystem) error {
+	if inner == nil {
+		return fmt.Errorf("%w: inner wrapper is nil", utils.ErrMissingSetup)
+	}
+	if inner.Scheme() != r.InnerScheme || inner.CurveID() != r.InnerCurve {
+		return fmt.Errorf("inner wrapper is %s on %s, expected %s on %s",
+			inner.Scheme(), inner.CurveID().String(), r.InnerScheme, r.InnerCurve.String())
+	}
+	return nil
+}
+
+func groth16Recursion[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](r *Recursion) {
+	oc := new(groth16wrapper.OuterCircuitConstant[FR, G1El, G2El, GtEl])
+	r.Circuit = oc
+	r.preCompile = func(inner ProofSystem) error {
+		g, ok := inner.(*groth16wrapper.Groth16Wrapper)
+		if !ok {
+			return fmt.Errorf("inner wrapper of type %T is not supported", inner)
+		}
+		return oc.PreCompile(groth16wrapper.OuterConstantCompileParams{InnerCCS: g.CCS, InnerVK: g.VK})
+	}
+	r.assign = func(inner ProofSystem) error {
+		g, ok := inner.(*groth16wrapper.Groth16Wrapper)
+		if !ok {
+			return fmt.Errorf("inner wrapper of type %T is not supported", inner)
+		}
+		if g.Proof == nil || g.WitnessFull == nil {
+			return fmt.Errorf("%w: inner proof or witness is nil", utils.ErrMissingSetup)
+		}
+		return oc.Assign(groth16wrapper.OuterConstantAssignParams{InnerWitness: g.WitnessFull, InnerProof: g.Proof})
+	}
+}
+
+func plonkRecursion[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](r *Recursion) {
+	oc := new(plonkwrapper.OuterCircuit[FR, G1El, G2El, GtEl])
+	r.Circuit = oc
+	r.preCompile = func(inner ProofSystem) error {
+		p, ok := inner.(*plonkwrapper.PlonkWrapper)
+		if !ok {
+			return fmt.Errorf("inner wrapper of type %T is not supported", inner)
+		}
+		return oc.PreCompile(plonkwrapper.OuterCompileParams{InnerCCS: p.CCS, InnerVK: p.VK})
+	}
+	r.assign = func(inner ProofSystem) error {
+		p, ok := inner.(*plonkwrapper.PlonkWrapper)
+		if !ok {
+			return fmt.Errorf("inner wrapper of type %T is not supported", inner)
+		}
+		if p.Proof == nil || p.WitnessFull == nil {
+			return fmt.Errorf("%w: inner proof or witness is nil", utils.ErrMissingSetup)
+		}
+		return oc.Assign(plonkwrapper.OuterAssignParams{InnerWitness: p.WitnessFull, InnerProof: p.Proof})
+	}
+}
@@ -0,0 +1,202 @@
+package wrapper
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/oliverustc/gnarkabc/circuits"
+	"github.com/oliverustc/gnarkabc/utils"
+	"github.com/oliverustc/gnarkabc/wrapper/groth16wrapper"
+	"github.com/oliverustc/gnarkabc/wrapper/plonkwrapper"
+	"github.com/oliverustc/gnarkabc/wrapper/testutil"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_bn254"
+	"github.com/consensys/gnark/std/algebra/native/sw_bls12377"
+	"github.com/consensys/gnark/test"
+)
+
+func TestNewRecursion(t *testing.T) {
+	for _, tc := range []struct {
+		innerScheme, innerCurve, outerScheme, outerCurve string
+	}{
+		{SchemeGroth16, "BN254", SchemeGroth16, "BN254"},
+		{SchemePlonk, "BN254", SchemePlonk, "BN254"},
+		{SchemeGroth16, "BLS12-377", SchemePlonk, "BW6-761"},
+		{SchemePlonk, "BW6-761", SchemeGroth16, "BN254"},
+		{SchemeGroth16, "BLS12-381", SchemeGroth16, "BN254"},
+		{SchemePlonk, "BLS24-315", SchemeGroth16, "BW6-633"},
+	} {
+		r, err := NewRecursion(tc.innerScheme, tc.innerCurve, tc.outerScheme, tc.outerCurve)
+		if err != nil {
+			t.Fatalf("%+v: %v", tc, err)
+		}
+		if r.Scheme() != tc.outerScheme || r.CurveID() != utils.CurveMap[tc.outerCurve] {
+			t.Fatalf("%+v: unexpected outer wrapper %s on %s", tc, r.Scheme(), r.CurveID().String())
+		}
+	}
+
+	// 按曲线对选择原生或非原生的验证器类型
+	r, err := NewRecursion(SchemeGroth16, "BLS12-377", SchemeGroth16, "BW6-761")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := r.Circuit.(*groth16wrapper.OuterCircuitConstant[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT]); !ok {
+		t.Fatalf("unexpected outer circuit type %T", r.Circuit)
+	}
+	r, err = NewRecursion(SchemePlonk, "BN254", SchemeGroth16, "BW6-761")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := r.Circuit.(*plonkwrapper.OuterCircuit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl]); !ok {
+		t.Fatalf("unexpected outer circuit type %T", r.Circuit)
+	}
+
+	for _, tc := range []struct {
+		innerScheme, innerCurve, outerScheme, outerCurve string
+	}{
+		{SchemeGroth16, "BLS12-377", SchemeGroth16, "BN254"},
+		{SchemePlonk, "BLS24-315", SchemePlonk, "BW6-761"},
+		{SchemeGroth16, "BLS24-317", SchemeGroth16, "BN254"},
+		{SchemeGroth16, "BW6-633", SchemeGroth16, "BN254"},
+		{SchemeGroth16, "secp256k1", SchemeGroth16, "BN254"},
+		{SchemeGroth16, "BN254", SchemeGroth16, "secp256k1"},
+	} {
+		if _, err := NewRecursion(tc.innerScheme, tc.innerCurve, tc.outerScheme, tc.outerCurve); !errors.Is(err, utils.ErrUnsupportedCurve) {
+			t.Fatalf("%+v: expected ErrUnsupportedCurve, got %v", tc, err)
+		}
+	}
+	if _, err := NewRecursion("stark", "BN254", SchemeGroth16, "BN254"); err == nil {
+		t.Fatal("expected error for unknown inner scheme")
+	}
+	if _, err := NewRecursion(SchemeGroth16, "BN254", "stark", "BN254"); err == nil {
+		t.Fatal("expected error for unknown outer scheme")
+	}
+}
+
+// 在测试引擎中检查原生递归的外层电路，不进行外层的设置和证明
+func TestRecursionSolved(t *testing.T) {
+	inner, err := Groth16ZKP(&circuits.Product{}, "BLS12-377", circuits.NoParams{}, circuits.ProductAssign{P: 13, Q: 17})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewRecursion(SchemeGroth16, "BLS12-377", SchemeGroth16, "BW6-761")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 内层包装器的证明系统或曲线不一致时拒绝
+	other, err := New(SchemePlonk, "BLS12-377", &circuits.Product{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.PreCompile(other); err == nil {
+		t.Fatal("expected error for mismatched inner wrapper")
+	}
+	if err := r.PreCompile(inner); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Assign(inner); err != nil {
+		t.Fatal(err)
+	}
+	if err := test.IsSolved(r.Circuit, r.Circuit, ecc.BW6_761.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// crossSchemes 列出内外层证明系统不同的组合
+var crossSchemes = [][2]string{{SchemePlonk, SchemeGroth16}, {SchemeGroth16, SchemePlonk}}
+
+// newCrossRecursion 创建外层电路，以 ProveInner 生成内层乘积电路的证明并确定外层电路的形状
+func newCrossRecursion(t *testing.T, innerScheme, innerCurve, outerScheme, outerCurve string) (*Recursion, ProofSystem) {
+	r, err := NewRecursion(innerScheme, innerCurve, outerScheme, outerCurve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	circuit := &circuits.Product{}
+	inner, err := New(innerScheme, innerCurve, circuit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := inner.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	if err := inner.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	circuit.Assign(circuits.ProductAssign{P: 13, Q: 17})
+	inner.SetAssignment(circuit)
+	if err := r.ProveInner(inner); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.PreCompile(inner); err != nil {
+		t.Fatal(err)
+	}
+	return r, inner
+}
+
+// 在测试引擎中检查每一对曲线上外层电路的赋值，原生验证的外层电路还以外层的证明系统编译；
+// 非原生算术的外层电路编译和证明非常耗时，只在测试引擎中检查
+func TestCrossSchemeSolved(t *testing.T) {
+	for _, pair := range utils.RecursionPairList {
+		innerCurve, outerCurve := pair[0], pair[1]
+		native := recursionGadgets[utils.CurveMap[innerCurve]].native != ecc.UNKNOWN
+		for _, schemes := range crossSchemes {
+			r, inner := newCrossRecursion(t, schemes[0], innerCurve, schemes[1], outerCurve)
+			if native {
+				if err := r.Compile(); err != nil {
+					t.Fatalf("%s %s in %s %s: %v", schemes[0], innerCurve, schemes[1], outerCurve, err)
+				}
+			}
+			if err := r.Assign(inner); err != nil {
+				t.Fatal(err)
+			}
+			if err := test.IsSolved(r.Circuit, r.Circuit, r.CurveID().ScalarField()); err != nil {
+				t.Fatalf("%s %s in %s %s: %v", schemes[0], innerCurve, schemes[1], outerCurve, err)
+			}
+		}
+	}
+
+	// 不设置递归目标时 PLONK 证明以 SHA-256 计算挑战，外层电路无法验证
+	r, inner := newCrossRecursion(t, SchemePlonk, "BLS12-377", SchemeGroth16, "BW6-761")
+	inner.SetRecursionTarget(ecc.UNKNOWN)
+	if err := inner.Prove(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Assign(inner); err != nil {
+		t.Fatal(err)
+	}
+	if err := test.IsSolved(r.Circuit, r.Circuit, ecc.BW6_761.ScalarField()); err == nil {
+		t.Fatal("expected unsolved circuit for an inner proof without native prover options")
+	}
+}
+
+// 在原生验证的曲线对上以另一种证明系统设置、证明并验证外层电路；
+// 非原生算术的曲线对每个组合需要十几分钟，由 TestCrossSchemeSolved 在测试引擎中覆盖
+func TestCrossSchemeRecursion(t *testing.T) {
+	if testing.Short() {
+		t.Skip("full cross-scheme recursion proofs are slow")
+	}
+	c := testutil.ArtifactCache(t)
+	for _, pair := range utils.RecursionPairList {
+		if recursionGadgets[utils.CurveMap[pair[0]]].native == ecc.UNKNOWN {
+			continue
+		}
+		for _, schemes := range crossSchemes {
+			t.Run(schemes[0]+"_"+pair[0]+"_in_"+schemes[1]+"_"+pair[1], func(t *testing.T) {
+				r, inner := newCrossRecursion(t, schemes[0], pair[0], schemes[1], pair[1])
+				if _, err := c.Setup(r); err != nil {
+					t.Fatal(err)
+				}
+				if err := r.Assign(inner); err != nil {
+					t.Fatal(err)
+				}
+				if err := r.Prove(); err != nil {
+					t.Fatal(err)
+				}
+				if err := r.Verify(); err != nil {
+					t.Fatal(err)
+				}
+			})
+		}
+	}
+}
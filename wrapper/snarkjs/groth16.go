@@ -0,0 +1,185 @@
+package snarkjs
+
+import (
+	"fmt"
+
+	"github.com/oliverustc/gnarkabc/utils"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	groth16_bls12381 "github.com/consensys/gnark/backend/groth16/bls12-381"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+)
+
+// Groth16Proof 对应 snarkjs 的 Groth16 proof.json
+type Groth16Proof struct {
+	PiA      G1     `json:"pi_a"`
+	PiB      G2     `json:"pi_b"`
+	PiC      G1     `json:"pi_c"`
+	Protocol string `json:"protocol"`
+	Curve    string `json:"curve"`
+}
+
+// Groth16VerifyingKey 对应 snarkjs 的 Groth16 verification_key.json。
+// snarkjs 验证时不使用 vk_alphabeta_12，导出时省略，导入时忽略
+type Groth16VerifyingKey struct {
+	Protocol string `json:"protocol"`
+	Curve    string `json:"curve"`
+	NPublic  int    `json:"nPublic"`
+	VkAlpha1 G1     `json:"vk_alpha_1"`
+	VkBeta2  G2     `json:"vk_beta_2"`
+	VkGamma2 G2     `json:"vk_gamma_2"`
+	VkDelta2 G2     `json:"vk_delta_2"`
+	IC       []G1   `json:"IC"`
+}
+
+const protocolGroth16 = "groth16"
+
+// ExportGroth16Proof 将 gnark 的 Groth16 证明转换为 snarkjs 格式，带 Pedersen 承诺的证明无法转换
+func ExportGroth16Proof(proof groth16.Proof) (*Groth16Proof, error) {
+	switch p := proof.(type) {
+	case *groth16_bn254.Proof:
+		if len(p.Commitments) > 0 {
+			return nil, fmt.Errorf("%w: proof has %d commitments", ErrNotConvertible, len(p.Commitments))
+		}
+		return &Groth16Proof{PiA: fromBN254G1(&p.Ar), PiB: fromBN254G2(&p.Bs), PiC: fromBN254G1(&p.Krs),
+			Protocol: protocolGroth16, Curve: "bn128"}, nil
+	case *groth16_bls12381.Proof:
+		if len(p.Commitments) > 0 {
+			return nil, fmt.Errorf("%w: proof has %d commitments", ErrNotConvertible, len(p.Commitments))
+		}
+		return &Groth16Proof{PiA: fromBLS12381G1(&p.Ar), PiB: fromBLS12381G2(&p.Bs), PiC: fromBLS12381G1(&p.Krs),
+			Protocol: protocolGroth16, Curve: "bls12381"}, nil
+	}
+	return nil, fmt.Errorf("%w: proof type %T", utils.ErrUnsupportedCurve, proof)
+}
+
+// ImportGroth16Proof 将 snarkjs 的 proof.json 转换为 gnark 的 Groth16 证明
+func ImportGroth16Proof(p *Groth16Proof) (groth16.Proof, error) {
+	curve, err := checkHeader(p.Protocol, protocolGroth16, p.Curve)
+	if err != nil {
+		return nil, err
+	}
+	switch curve {
+	case ecc.BN254:
+		var res groth16_bn254.Proof
+		if res.Ar, err = toBN254G1(p.PiA); err != nil {
+			return nil, err
+		}
+		if res.Bs, err = toBN254G2(p.PiB); err != nil {
+			return nil, err
+		}
+		if res.Krs, err = toBN254G1(p.PiC); err != nil {
+			return nil, err
+		}
+		return &res, nil
+	default:
+		var res groth16_bls12381.Proof
+		if res.Ar, err = toBLS12381G1(p.PiA); err != nil {
+			return nil, err
+		}
+		if res.Bs, err = toBLS12381G2(p.PiB); err != nil {
+			return nil, err
+		}
+		if res.Krs, err = toBLS12381G1(p.PiC); err != nil {
+			return nil, err
+		}
+		return &res, nil
+	}
+}
+
+// ExportGroth16VerifyingKey 将 gnark 的 Groth16 验证密钥转换为 snarkjs 格式，带 Pedersen 承诺的电路无法转换
+func ExportGroth16VerifyingKey(vk groth16.VerifyingKey) (*Groth16VerifyingKey, error) {
+	switch v := vk.(type) {
+	case *groth16_bn254.VerifyingKey:
+		if len(v.CommitmentKeys) > 0 {
+			return nil, fmt.Errorf("%w: verifying key has %d commitment keys", ErrNotConvertible, len(v.CommitmentKeys))
+		}
+		res := &Groth16VerifyingKey{Protocol: protocolGroth16, Curve: "bn128", NPublic: len(v.G1.K) - 1,
+			VkAlpha1: fromBN254G1(&v.G1.Alpha), VkBeta2: fromBN254G2(&v.G2.Beta),
+			VkGamma2: fromBN254G2(&v.G2.Gamma), VkDelta2: fromBN254G2(&v.G2.Delta)}
+		for i := range v.G1.K {
+			res.IC = append(res.IC, fromBN254G1(&v.G1.K[i]))
+		}
+		return res, nil
+	case *groth16_bls12381.VerifyingKey:
+		if len(v.CommitmentKeys) > 0 {
+			return nil, fmt.Errorf("%w: verifying key has %d commitment keys", ErrNotConvertible, len(v.CommitmentKeys))
+		}
+		res := &Groth16VerifyingKey{Protocol: protocolGroth16, Curve: "bls12381", NPublic: len(v.G1.K) - 1,
+			VkAlpha1: fromBLS12381G1(&v.G1.Alpha), VkBeta2: fromBLS12381G2(&v.G2.Beta),
+			VkGamma2: fromBLS12381G2(&v.G2.Gamma), VkDelta2: fromBLS12381G2(&v.G2.Delta)}
+		for i := range v.G1.K {
+			res.IC = append(res.IC, fromBLS12381G1(&v.G1.K[i]))
+		}
+		return res, nil
+	}
+	return nil, fmt.Errorf("%w: verifying key type %T", utils.ErrUnsupportedCurve, vk)
+}
+
+// ImportGroth16VerifyingKey 将 snarkjs 的 verification_key.json 转换为 gnark 的 Groth16 验证密钥。
+// gnark 验证时不使用 G1 上的 β、δ，导入后保持为零
+func ImportGroth16VerifyingKey(vk *Groth16VerifyingKey) (groth16.VerifyingKey, error) {
+	curve, err := checkHeader(vk.Protocol, protocolGroth16, vk.Curve)
+	if err != nil {
+		return nil, err
+	}
+	if vk.NPublic < 0 || len(vk.IC) != vk.NPublic+1 {
+		return nil, fmt.Errorf("%w: %d IC points for %d public inputs", utils.ErrCorruptedArtifact, len(vk.IC), vk.NPublic)
+	}
+	switch curve {
+	case ecc.BN254:
+		var res groth16_bn254.VerifyingKey
+		if res.G1.Alpha, err = toBN254G1(vk.VkAlpha1); err != nil {
+			return nil, err
+		}
+		if res.G1.K, err = convertAll(vk.IC, toBN254G1); err != nil {
+			return nil, err
+		}
+		g2, err := convertAll([]G2{vk.VkBeta2, vk.VkGamma2, vk.VkDelta2}, toBN254G2)
+		if err != nil {
+			return nil, err
+		}
+		res.G2.Beta, res.G2.Gamma, res.G2.Delta = g2[0], g2[1], g2[2]
+		if err := res.Precompute(); err != nil {
+			return nil, err
+		}
+		return &res, nil
+	default:
+		var res groth16_bls12381.VerifyingKey
+		if res.G1.Alpha, err = toBLS12381G1(vk.VkAlpha1); err != nil {
+			return nil, err
+		}
+		if res.G1.K, err = convertAll(vk.IC, toBLS12381G1); err != nil {
+			return nil, err
+		}
+		g2, err := convertAll([]G2{vk.VkBeta2, vk.VkGamma2, vk.VkDelta2}, toBLS12381G2)
+		if err != nil {
+			return nil, err
+		}
+		res.G2.Beta, res.G2.Gamma, res.G2.Delta = g2[0], g2[1], g2[2]
+		if err := res.Precompute(); err != nil {
+			return nil, err
+		}
+		return &res, nil
+	}
+}
+
+// checkHeader 检查协议名称并解析曲线
+func checkHeader(protocol, expected, curveName string) (ecc.ID, error) {
+	if protocol != expected {
+		return ecc.UNKNOWN, fmt.Errorf("%w: protocol %q, expected %q", ErrNotConvertible, protocol, expected)
+	}
+	return CurveID(curveName)
+}
+
+func convertAll[P, T any](points []P, convert func(P) (T, error)) ([]T, error) {
+	res := make([]T, len(points))
+	for i, p := range points {
+		var err error
+		if res[i], err = convert(p); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
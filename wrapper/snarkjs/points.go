@@ -0,0 +1,111 @@
+package snarkjs
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/oliverustc/gnarkabc/utils"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	bls12381fp "github.com/consensys/gnark-crypto/ecc/bls12-381/fp"
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	bn254fp "github.com/consensys/gnark-crypto/ecc/bn254/fp"
+)
+
+// 以下为各曲线的点与 snarkjs 坐标之间的转换，导入时检查点在曲线上且属于正确的子群
+
+func bigInt(e interface{ BigInt(*big.Int) *big.Int }) *big.Int {
+	return e.BigInt(new(big.Int))
+}
+
+func errInvalidPoint(group string) error {
+	return fmt.Errorf("%w: %s point is not on the curve or not in the correct subgroup", utils.ErrCorruptedArtifact, group)
+}
+
+func fromBN254G1(p *bn254.G1Affine) G1 {
+	if p.IsInfinity() {
+		return infinityG1
+	}
+	return newG1(bigInt(&p.X), bigInt(&p.Y))
+}
+
+func fromBN254G2(p *bn254.G2Affine) G2 {
+	if p.IsInfinity() {
+		return infinityG2
+	}
+	return newG2(bigInt(&p.X.A0), bigInt(&p.X.A1), bigInt(&p.Y.A0), bigInt(&p.Y.A1))
+}
+
+func toBN254G1(p G1) (bn254.G1Affine, error) {
+	var res bn254.G1Affine
+	x, y, infinity, err := p.coordinates(bn254fp.Modulus())
+	if err != nil || infinity {
+		return res, err
+	}
+	res.X.SetBigInt(x)
+	res.Y.SetBigInt(y)
+	if !res.IsOnCurve() || !res.IsInSubGroup() {
+		return res, errInvalidPoint("g1")
+	}
+	return res, nil
+}
+
+func toBN254G2(p G2) (bn254.G2Affine, error) {
+	var res bn254.G2Affine
+	c, infinity, err := p.coordinates(bn254fp.Modulus())
+	if err != nil || infinity {
+		return res, err
+	}
+	res.X.A0.SetBigInt(c[0])
+	res.X.A1.SetBigInt(c[1])
+	res.Y.A0.SetBigInt(c[2])
+	res.Y.A1.SetBigInt(c[3])
+	if !res.IsOnCurve() || !res.IsInSubGroup() {
+		return res, errInvalidPoint("g2")
+	}
+	return res, nil
+}
+
+func fromBLS12381G1(p *bls12381.G1Affine) G1 {
+	if p.IsInfinity() {
+		return infinityG1
+	}
+	return newG1(bigInt(&p.X), bigInt(&p.Y))
+}
+
+func fromBLS12381G2(p *bls12381.G2Affine) G2 {
+	if p.IsInfinity() {
+		return infinityG2
+	}
+	return newG2(bigInt(&p.X.A0), bigInt(&p.X.A1), bigInt(&p.Y.A0), bigInt(&p.Y.A1))
+}
+
+func toBLS12381G1(p G1) (bls12381.G1Affine, error) {
+	var res bls12381.G1Affine
+	x, y, infinity, err := p.coordinates(bls12381fp.Modulus())
+	if err != nil || infinity {
+		return res, err
+	}
+	res.X.SetBigInt(x)
+	res.Y.SetBigInt(y)
+	if !res.IsOnCurve() || !res.IsInSubGroup() {
+		return res, errInvalidPoint("g1")
+	}
+	return res, nil
+}
+
+func toBLS12381G2(p G2) (bls12381.G2Affine, error) {
+	var res bls12381.G2Affine
+	c, infinity, err := p.coordinates(bls12381fp.Modulus())
+	if err != nil || infinity {
+		return res, err
+	}
+	res.X.A0.SetBigInt(c[0])
+	res.X.A1.SetBigInt(c[1])
+	res.Y.A0.SetBigInt(c[2])
+	res.Y.A1.SetBigInt(c[3])
+	if !res.IsOnCurve() || !res.IsInSubGroup() {
+		return res, errInvalidPoint("g2")
+	}
+	return res, nil
+}
@@ -0,0 +1,191 @@
+// Package snarkjs 在 gnark 的证明、验证密钥、公开见证者与 snarkjs 的
+// proof.json、verification_key.json、public.json 之间相互转换，
+// 便于前端使用 snarkjs 验证 gnark 生成的 Groth16 证明。支持 BN254 和 BLS12-381
+package snarkjs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/oliverustc/gnarkabc/utils"
+	"github.com/oliverustc/gnarkabc/wrapper/store"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/witness"
+)
+
+// snarkjs 使用的文件名
+const (
+	ProofFile  = "proof.json"
+	VKFile     = "verification_key.json"
+	PublicFile = "public.json"
+)
+
+// ErrNotConvertible 表示 gnark 的对象包含 snarkjs 格式无法表达的内容，例如 Pedersen 承诺
+var ErrNotConvertible = errors.New("not convertible to snarkjs format")
+
+// CurveName 返回曲线在 snarkjs 中的名称
+func CurveName(curve ecc.ID) (string, error) {
+	switch curve {
+	case ecc.BN254:
+		return "bn128", nil
+	case ecc.BLS12_381:
+		return "bls12381", nil
+	}
+	return "", fmt.Errorf("%w: %s", utils.ErrUnsupportedCurve, curve.String())
+}
+
+// CurveID 解析 snarkjs 的曲线名称，与 snarkjs 一样接受 BN254 的几种别名
+func CurveID(name string) (ecc.ID, error) {
+	switch name {
+	case "bn128", "bn254", "alt_bn128":
+		return ecc.BN254, nil
+	case "bls12381":
+		return ecc.BLS12_381, nil
+	}
+	return ecc.UNKNOWN, fmt.Errorf("%w: snarkjs curve %s", utils.ErrUnsupportedCurve, name)
+}
+
+// G1 是 snarkjs 中的 G1 点，以十进制字符串表示的射影坐标 [x, y, z]，仿射点 z 为 "1"，无穷远点为 ["0", "1", "0"]
+type G1 [3]string
+
+// G2 是 snarkjs 中的 G2 点，每个坐标为 [c0, c1]，仿射点 z 为 ["1", "0"]
+type G2 [3][2]string
+
+var (
+	infinityG1 = G1{"0", "1", "0"}
+	infinityG2 = G2{{"0", "0"}, {"1", "0"}, {"0", "0"}}
+)
+
+func newG1(x, y *big.Int) G1 {
+	return G1{x.String(), y.String(), "1"}
+}
+
+func newG2(x0, x1, y0, y1 *big.Int) G2 {
+	return G2{{x0.String(), x1.String()}, {y0.String(), y1.String()}, {"1", "0"}}
+}
+
+// coordinates 解析仿射坐标，modulus 为基域模数
+func (p G1) coordinates(modulus *big.Int) (x, y *big.Int, infinity bool, err error) {
+	switch p[2] {
+	case "0":
+		return nil, nil, true, nil
+	case "1":
+	default:
+		return nil, nil, false, fmt.Errorf("%w: g1 point is not affine", utils.ErrCorruptedArtifact)
+	}
+	if x, err = parseElement(p[0], modulus); err != nil {
+		return nil, nil, false, err
+	}
+	if y, err = parseElement(p[1], modulus); err != nil {
+		return nil, nil, false, err
+	}
+	return x, y, false, nil
+}
+
+// coordinates 解析仿射坐标，返回 x.c0, x.c1, y.c0, y.c1
+func (p G2) coordinates(modulus *big.Int) (c [4]*big.Int, infinity bool, err error) {
+	switch p[2] {
+	case [2]string{"0", "0"}:
+		return c, true, nil
+	case [2]string{"1", "0"}:
+	default:
+		return c, false, fmt.Errorf("%w: g2 point is not affine", utils.ErrCorruptedArtifact)
+	}
+	for i, s := range []string{p[0][0], p[0][1], p[1][0], p[1][1]} {
+		if c[i], err = parseElement(s, modulus); err != nil {
+			return c, false, err
+		}
+	}
+	return c, false, nil
+}
+
+// parseElement 解析十进制表示的域元素，拒绝不小于模数的值
+func parseElement(s string, modulus *big.Int) (*big.Int, error) {
+	v, ok := new(big.Int).SetString(s, 10)
+	if !ok || v.Sign() < 0 || v.Cmp(modulus) >= 0 {
+		return nil, fmt.Errorf("%w: invalid field element %q", utils.ErrCorruptedArtifact, s)
+	}
+	return v, nil
+}
+
+// Public 是 snarkjs 的 public.json，按顺序列出十进制表示的公开输入
+type Public []string
+
+// ExportPublic 将 curve 上的公开见证者转换为 public.json
+func ExportPublic(w witness.Witness, curve ecc.ID) (Public, error) {
+	values, nbPublic, err := utils.WitnessValues(w, curve.ScalarField())
+	if err != nil {
+		return nil, err
+	}
+	if nbPublic != len(values) {
+		return nil, fmt.Errorf("%w: witness has %d secret values, expected a public witness", ErrNotConvertible, len(values)-nbPublic)
+	}
+	public := make(Public, nbPublic)
+	for i := range public {
+		public[i] = values[i].String()
+	}
+	return public, nil
+}
+
+// ImportPublic 将 public.json 转换为指定曲线上的公开见证者
+func ImportPublic(p Public, curve ecc.ID) (witness.Witness, error) {
+	field := curve.ScalarField()
+	values := make(chan any, len(p))
+	for _, s := range p {
+		v, err := parseElement(s, field)
+		if err != nil {
+			return nil, err
+		}
+		values <- v
+	}
+	close(values)
+	w, err := witness.New(field)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.Fill(len(p), 0, values); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// WriteJSON 以缩进的 JSON 格式将 v 写入存储
+func WriteJSON(s store.ArtifactStore, name string, v any) error {
+	data, err := json.MarshalIndent(v, "", " ")
+	if err != nil {
+		return err
+	}
+	file, err := s.Create(name)
+	if err != nil {
+		return &utils.IOError{Op: "create snarkjs file", Path: name, Err: err}
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return &utils.IOError{Op: "write snarkjs file", Path: name, Err: err}
+	}
+	if err := file.Close(); err != nil {
+		return &utils.IOError{Op: "write snarkjs file", Path: name, Err: err}
+	}
+	return nil
+}
+
+// ReadJSON 从存储读取 JSON 并解析到 v
+func ReadJSON(s store.ArtifactStore, name string, v any) error {
+	file, err := s.Open(name)
+	if err != nil {
+		return &utils.IOError{Op: "open snarkjs file", Path: name, Err: err}
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return &utils.IOError{Op: "read snarkjs file", Path: name, Err: err}
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: %s: %w", utils.ErrCorruptedArtifact, name, err)
+	}
+	return nil
+}
@@ -0,0 +1,330 @@
+// Package store 定义证明系统参数等产物的存放位置，
+// 包装器通过 ArtifactStore 读写 CCS、PK、VK、证明和见证者，而不是固定写入 output/ 目录
+package store
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultDir 是包装器默认使用的产物目录
+const DefaultDir = "output"
+
+// ArtifactStore 是产物的存储位置，name 为存储内的相对路径
+type ArtifactStore interface {
+	Create(name string) (io.WriteCloser, error) // 创建或覆盖产物，Close 后写入生效
+	Open(name string) (io.ReadCloser, error)    // 读取产物，不存在时返回的错误满足 errors.Is(err, fs.ErrNotExist)
+	Exists(name string) bool
+	Remove(name string) error
+	List() ([]string, error) // 按名称排序的全部产物
+}
+
+// FS 将产物保存在文件系统的 Root 目录下，绝对路径的 name 直接使用
+type FS struct {
+	Root string // 根目录，为空时使用当前目录
+}
+
+// NewFS 创建以 root 为根目录的文件系统存储，目录在首次写入时创建
+func NewFS(root string) *FS {
+	return &FS{Root: root}
+}
+
+// Path 返回 name 对应的文件路径
+func (s *FS) Path(name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(s.Root, name)
+}
+
+func (s *FS) Create(name string) (io.WriteCloser, error) {
+	p := s.Path(name)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return nil, err
+	}
+	return os.Create(p)
+}
+
+func (s *FS) Open(name string) (io.ReadCloser, error) {
+	return os.Open(s.Path(name))
+}
+
+func (s *FS) Exists(name string) bool {
+	_, err := os.Stat(s.Path(name))
+	return err == nil
+}
+
+func (s *FS) Remove(name string) error {
+	return os.Remove(s.Path(name))
+}
+
+func (s *FS) List() ([]string, error) {
+	root := s.Root
+	if root == "" {
+		root = "."
+	}
+	var names []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && p == root {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		names = append(names, filepath.ToSlash(rel))
+		return nil
+	})
+	return names, err
+}
+
+// Memory 将产物保存在内存中，适合测试和不落盘的服务
+type Memory struct {
+	mu    sync.RWMutex
+	files map[string][]byte
+}
+
+// NewMemory 创建空的内存存储
+func NewMemory() *Memory {
+	return &Memory{files: make(map[string][]byte)}
+}
+
+func cleanName(name string) string {
+	return path.Clean(filepath.ToSlash(name))
+}
+
+func (s *Memory) Create(name string) (io.WriteCloser, error) {
+	return &memoryWriter{store: s, name: cleanName(name)}, nil
+}
+
+func (s *Memory) Open(name string) (io.ReadCloser, error) {
+	s.mu.RLock()
+	data, ok := s.files[cleanName(name)]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *Memory) Exists(name string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.files[cleanName(name)]
+	return ok
+}
+
+func (s *Memory) Remove(name string) error {
+	name = cleanName(name)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.files[name]; !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	delete(s.files, name)
+	return nil
+}
+
+func (s *Memory) List() ([]string, error) {
+	s.mu.RLock()
+	names := make([]string, 0, len(s.files))
+	for name := range s.files {
+		names = append(names, name)
+	}
+	s.mu.RUnlock()
+	sort.Strings(names)
+	return names, nil
+}
+
+// memoryWriter 在 Close 时把缓冲区写入存储，重复 Close 不会重复写入
+type memoryWriter struct {
+	bytes.Buffer
+	store  *Memory
+	name   string
+	closed bool
+}
+
+func (w *memoryWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	w.store.mu.Lock()
+	w.store.files[w.name] = bytes.Clone(w.Bytes())
+	w.store.mu.Unlock()
+	return nil
+}
+
+// Tar 用于导出和导入 tar 归档形式的产物，便于整体分发。
+// 产物先写入磁盘上的暂存目录，不占用内存；归档只在调用 Flush 或 Close 时一次性流式写出
+type Tar struct {
+	Path    string // 归档文件路径
+	staging *FS    // 暂存目录
+}
+
+// NewTar 打开 tar 归档，文件存在时将已有产物解包到暂存目录
+func NewTar(archivePath string) (*Tar, error) {
+	dir, err := os.MkdirTemp("", "gnarkabc-tar-*")
+	if err != nil {
+		return nil, err
+	}
+	t := &Tar{Path: archivePath, staging: NewFS(dir)}
+	file, err := os.Open(archivePath)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		os.RemoveAll(dir)
+		return nil, err
+	default:
+		defer file.Close()
+		if err := ReadTar(file, t.staging); err != nil {
+			os.RemoveAll(dir)
+			return nil, fmt.Errorf("load tar archive %s: %w", archivePath, err)
+		}
+	}
+	return t, nil
+}
+
+func (t *Tar) Create(name string) (io.WriteCloser, error) {
+	return t.staging.Create(stagingName(name))
+}
+
+func (t *Tar) Open(name string) (io.ReadCloser, error) {
+	return t.staging.Open(stagingName(name))
+}
+
+func (t *Tar) Exists(name string) bool {
+	return t.staging.Exists(stagingName(name))
+}
+
+func (t *Tar) Remove(name string) error {
+	return t.staging.Remove(stagingName(name))
+}
+
+func (t *Tar) List() ([]string, error) {
+	return t.staging.List()
+}
+
+// stagingName 将名称限制在暂存目录内，归档中不允许出现绝对路径或 ..
+func stagingName(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(name)), "/")
+}
+
+// Flush 将暂存目录中的全部产物写为归档，先写临时文件再重命名，避免中途失败破坏原归档
+func (t *Tar) Flush() error {
+	if err := os.MkdirAll(filepath.Dir(t.Path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(t.Path), filepath.Base(t.Path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := WriteTar(tmp, t.staging); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), t.Path)
+}
+
+// Close 写出归档并删除暂存目录
+func (t *Tar) Close() error {
+	err := t.Flush()
+	if rmErr := os.RemoveAll(t.staging.Root); err == nil {
+		err = rmErr
+	}
+	return err
+}
+
+// WriteTar 将存储中的全部产物写为 tar 归档
+func WriteTar(w io.Writer, s ArtifactStore) error {
+	names, err := s.List()
+	if err != nil {
+		return err
+	}
+	tw := tar.NewWriter(w)
+	for _, name := range names {
+		r, err := s.Open(name)
+		if err != nil {
+			return err
+		}
+		err = writeTarEntry(tw, name, r)
+		r.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+// writeTarEntry 写入一个归档条目，文件系统上的产物直接流式复制，不整体读入内存
+func writeTarEntry(tw *tar.Writer, name string, r io.Reader) error {
+	var size int64
+	if file, ok := r.(*os.File); ok {
+		info, err := file.Stat()
+		if err != nil {
+			return err
+		}
+		size = info.Size()
+	} else {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		size, r = int64(len(data)), bytes.NewReader(data)
+	}
+	hdr := &tar.Header{Name: name, Mode: 0644, Size: size, ModTime: time.Now()}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := io.CopyN(tw, r, size)
+	return err
+}
+
+// ReadTar 将 tar 归档中的普通文件写入存储
+func ReadTar(r io.Reader, s ArtifactStore) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		w, err := s.Create(stagingName(hdr.Name))
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(w, tr); err != nil {
+			w.Close()
+			return err
+		}
+		if err := w.Close(); err != nil {
+			return err
+		}
+	}
+}
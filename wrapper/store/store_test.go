@@ -0,0 +1,115 @@
+package store
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func put(t *testing.T, s ArtifactStore, name, data string) {
+	t.Helper()
+	w, err := s.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(w, data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func get(t *testing.T, s ArtifactStore, name string) string {
+	t.Helper()
+	r, err := s.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// checkStore 检查各实现共同的读写、列举和删除行为
+func checkStore(t *testing.T, s ArtifactStore) {
+	t.Helper()
+	put(t, s, "vk", "verifying key")
+	put(t, s, "layer_0/proof_1", "proof")
+	put(t, s, "vk", "new verifying key")
+	if got := get(t, s, "vk"); got != "new verifying key" {
+		t.Fatalf("unexpected content %q", got)
+	}
+	if got := get(t, s, "layer_0/proof_1"); got != "proof" {
+		t.Fatalf("unexpected content %q", got)
+	}
+	if !s.Exists("vk") || s.Exists("pk") {
+		t.Fatal("unexpected Exists result")
+	}
+	names, err := s.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(names, []string{"layer_0/proof_1", "vk"}) {
+		t.Fatalf("unexpected names %v", names)
+	}
+	if err := s.Remove("vk"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Open("vk"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected fs.ErrNotExist, got %v", err)
+	}
+}
+
+func TestFS(t *testing.T) {
+	s := NewFS(filepath.Join(t.TempDir(), "output"))
+	if names, err := s.List(); err != nil || len(names) != 0 {
+		t.Fatalf("expected empty store, got %v, %v", names, err)
+	}
+	checkStore(t, s)
+}
+
+func TestMemory(t *testing.T) {
+	checkStore(t, NewMemory())
+}
+
+func TestTar(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "artifacts.tar")
+	s, err := NewTar(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStore(t, s)
+	// 归档只在 Flush 或 Close 时写出
+	if _, err := os.Stat(archive); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("archive written before flush: %v", err)
+	}
+	put(t, s, "../escape", "data")
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重新打开归档后内容保持不变
+	reopened, err := NewTar(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	names, err := reopened.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(names, []string{"escape", "layer_0/proof_1"}) {
+		t.Fatalf("unexpected names %v", names)
+	}
+	if got := get(t, reopened, "layer_0/proof_1"); got != "proof" {
+		t.Fatalf("unexpected content %q", got)
+	}
+}
@@ -0,0 +1,21 @@
+// Package testutil 提供各包测试共用的辅助函数
+package testutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/oliverustc/gnarkabc/wrapper/cache"
+)
+
+// ArtifactCache 返回测试共用的参数缓存，电路改变后自动重新设置。
+// 缓存放在 output/ 之外，Test*Write 清理 output/ 时不会删除耗时生成的递归电路参数
+func ArtifactCache(t testing.TB) *cache.Cache {
+	t.Helper()
+	c, err := cache.New(filepath.Join(os.TempDir(), "gnarkabc-cache"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
@@ -1,6 +1,8 @@
 package wrapper
 
 import (
+	"fmt"
+
 	"github.com/oliverustc/gnarkabc/utils"
 	"github.com/oliverustc/gnarkabc/wrapper/groth16wrapper"
 	"github.com/oliverustc/gnarkabc/wrapper/plonkwrapper"
@@ -8,34 +10,28 @@ import (
 	"github.com/consensys/gnark/frontend"
 )
 
-type CircuitWrapper interface {
+// CircuitWrapper 带类型化参数的电路包装接口
+// C 为编译前确定电路形状所需的参数类型，A 为赋值参数类型
+type CircuitWrapper[C, A any] interface {
 	frontend.Circuit
-	PreCompile(params any)
-	Assign(params any)
+	PreCompile(params C) error
+	Assign(params A) error
 }
 
-func Groth16ZKP(cw CircuitWrapper, curveName string, compileParams any, assignParams any) *groth16wrapper.Groth16Wrapper {
-	curve := utils.CurveMap[curveName]
+func Groth16ZKP[C, A any](cw CircuitWrapper[C, A], curveName string, compileParams C, assignParams A) (*groth16wrapper.Groth16Wrapper, error) {
+	curve, ok := utils.CurveMap[curveName]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", utils.ErrUnsupportedCurve, curveName)
+	}
 	g := groth16wrapper.NewWrapper(cw, curve)
-	cw.PreCompile(compileParams)
-	g.Compile()
-	g.Setup()
-	cw.Assign(assignParams)
-	g.SetAssignment(cw)
-	g.Prove()
-	g.Verify()
-	return g
+	return g, runZKP(g, cw, compileParams, assignParams)
 }
 
-func PlonkZKP(cw CircuitWrapper, curveName string, compileParams any, assignParams any) *plonkwrapper.PlonkWrapper {
-	curve := utils.CurveMap[curveName]
+func PlonkZKP[C, A any](cw CircuitWrapper[C, A], curveName string, compileParams C, assignParams A) (*plonkwrapper.PlonkWrapper, error) {
+	curve, ok := utils.CurveMap[curveName]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", utils.ErrUnsupportedCurve, curveName)
+	}
 	p := plonkwrapper.NewWrapper(cw, curve)
-	cw.PreCompile(compileParams)
-	p.Compile()
-	p.Setup()
-	cw.Assign(assignParams)
-	p.SetAssignment(cw)
-	p.Prove()
-	p.Verify()
-	return p
+	return p, runZKP(p, cw, compileParams, assignParams)
 }
@@ -1,18 +1,45 @@
 package wrapper
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/oliverustc/gnarkabc/circuits"
+	"github.com/oliverustc/gnarkabc/utils"
 )
 
 func TestZKP(t *testing.T) {
 	// 使用指针类型的Product
-	var cw CircuitWrapper = &circuits.Product{}
+	var cw CircuitWrapper[circuits.NoParams, circuits.ProductAssign] = &circuits.Product{}
 
-	// 对于Product，PreCompile不需要参数，所以传nil
-	// 对于Assign，需要两个整数参数，这里通过[]interface{}传递
-	assignParams := []any{3, 4} // 这将使 P=3, Q=4, N=12
-	_ = Groth16ZKP(cw, "BN254", nil, assignParams)
-	_ = PlonkZKP(cw, "BN254", nil, assignParams)
+	// 对于Product，PreCompile不需要参数，所以传NoParams
+	// 对于Assign，需要两个整数参数，通过ProductAssign传递
+	assignParams := circuits.ProductAssign{P: 3, Q: 4} // 这将使 P=3, Q=4, N=12
+	if _, err := Groth16ZKP(cw, "BN254", circuits.NoParams{}, assignParams); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := PlonkZKP(cw, "BN254", circuits.NoParams{}, assignParams); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProofSystem(t *testing.T) {
+	for _, scheme := range SchemeList {
+		ps, err := ZKP(scheme, &circuits.Product{}, "BN254", circuits.NoParams{}, circuits.ProductAssign{P: 5, Q: 7})
+		if err != nil {
+			t.Fatalf("%s: %v", scheme, err)
+		}
+		if ps.Scheme() != scheme {
+			t.Fatalf("expected scheme %s, got %s", scheme, ps.Scheme())
+		}
+		if _, err := ps.BenchmarkVerify(2); err != nil {
+			t.Fatalf("%s: %v", scheme, err)
+		}
+	}
+	if _, err := New("stark", "BN254", &circuits.Product{}); err == nil {
+		t.Fatal("expected error for unknown scheme")
+	}
+	if _, err := New(SchemeGroth16, "secp256k1", &circuits.Product{}); !errors.Is(err, utils.ErrUnsupportedCurve) {
+		t.Fatalf("expected ErrUnsupportedCurve, got %v", err)
+	}
 }